@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"GoBot/core/discord"
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// runConsole reads commands from in and dispatches them as if sent by the console user, printing the replies to out.
+// Lines starting with / are run as slash commands, e.g. /dist from=Sol to="Sagittarius A*", and
+// /click <message> <custom ID> [values...] clicks a button or picks values in a select menu of a message.
+func runConsole(ctx context.Context, in io.Reader, out io.Writer) {
+	session := discord.NewConsole(out)
+	channel := &discordgo.Channel{ID: consoleChannel, GuildID: consoleGuild, Name: consoleChannel, Type: discordgo.ChannelTypeGuildText}
+	if consoleGuild == "" {
+		channel.Type = discordgo.ChannelTypeDM
+		channel.Name = ""
+	}
+	session.AddChannel(channel)
+	services.SetDiscordSession(session)
+	// Reminders and other jobs post to the console, so they start once it's set up
+	services.StartScheduler(ctx)
+	author := &discordgo.User{ID: consoleUser, Username: consoleUser}
+
+	where := "a DM"
+	if consoleGuild != "" {
+		where = fmt.Sprintf("channel %s in guild %s", consoleChannel, consoleGuild)
+	}
+	fmt.Fprintf(out, "Console mode: messages are sent by %s in %s. Type /quit or press Ctrl-D to exit.\n", consoleUser, where)
+
+	scanner := bufio.NewScanner(in)
+	for id := 1; ; id++ {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "/quit" || line == "/exit":
+			return
+		case strings.HasPrefix(line, "/click"):
+			words := strings.Fields(line)
+			if len(words) < 3 {
+				fmt.Fprintln(out, "Usage: /click <message> <custom ID> [values...]")
+				continue
+			}
+			interaction := consoleInteraction(id, channel, author)
+			interaction.Type = discordgo.InteractionMessageComponent
+			interaction.Message = &discordgo.Message{ID: strings.TrimPrefix(words[1], "#"), ChannelID: channel.ID}
+			interaction.Data = discordgo.MessageComponentInteractionData{CustomID: words[2], Values: words[3:]}
+			dispatch.HandleInteraction(ctx, session, &discordgo.InteractionCreate{Interaction: interaction})
+		case strings.HasPrefix(line, "/"):
+			data, err := dispatch.ParseSlashCommand(line)
+			if err != nil {
+				fmt.Fprintf(out, "Error: %s\n", err)
+				continue
+			}
+			interaction := consoleInteraction(id, channel, author)
+			interaction.Type = discordgo.InteractionApplicationCommand
+			interaction.Data = *data
+			dispatch.HandleInteraction(ctx, session, &discordgo.InteractionCreate{Interaction: interaction})
+		default:
+			dispatch.Dispatch(ctx, session, &discordgo.Message{
+				ID:        "console-" + strconv.Itoa(id),
+				ChannelID: channel.ID,
+				GuildID:   consoleGuild,
+				Content:   line,
+				Author:    author,
+			})
+		}
+	}
+}
+
+// consoleInteraction returns an interaction by the console user in the console channel
+func consoleInteraction(id int, channel *discordgo.Channel, author *discordgo.User) *discordgo.Interaction {
+	interaction := &discordgo.Interaction{
+		ID:        strconv.Itoa(id),
+		ChannelID: channel.ID,
+		GuildID:   consoleGuild,
+		User:      author,
+	}
+	if consoleGuild != "" {
+		interaction.User = nil
+		interaction.Member = &discordgo.Member{GuildID: consoleGuild, User: author}
+	}
+	return interaction
+}
@@ -328,9 +328,10 @@ func UpsertCarrierFollower(followerStationId, nearCarrier, system string, distan
 	return false
 }
 
-// FetchRecentFollowers retrieves followers seen in the last N days with more than minSightings
+// FetchRecentFollowers retrieves a page of the followers seen in the last N days with more than minSightings,
+// starting at offset. A negative limit returns all of them.
 // sortBy can be: "distance", "times", "recent"
-func FetchRecentFollowers(days int, minSightings int, sortBy string) []CarrierFollower {
+func FetchRecentFollowers(days int, minSightings int, sortBy string, offset, limit int) []CarrierFollower {
 	if database == nil {
 		return nil
 	}
@@ -349,11 +350,11 @@ func FetchRecentFollowers(days int, minSightings int, sortBy string) []CarrierFo
 	query := fmt.Sprintf(`
 		SELECT * FROM carrier_followers
 		WHERE last_seen >= ? AND times_seen > ?
-		ORDER BY %s
-		LIMIT 25`, orderClause)
+		ORDER BY %s, follower_station_id
+		LIMIT ? OFFSET ?`, orderClause)
 
 	var followers []CarrierFollower
-	err := database.Select(&followers, query, cutoff, minSightings)
+	err := database.Select(&followers, query, cutoff, minSightings, limit, offset)
 	if err != nil {
 		core.LogErrorF("Failed to fetch recent followers: %s", err)
 		return nil
@@ -361,6 +362,22 @@ func FetchRecentFollowers(days int, minSightings int, sortBy string) []CarrierFo
 	return followers
 }
 
+// CountRecentFollowers returns the number of followers seen in the last N days with more than minSightings
+func CountRecentFollowers(days int, minSightings int) int {
+	if database == nil {
+		return 0
+	}
+	cutoff := time.Now().Unix() - int64(days*24*60*60)
+	var count int
+	err := database.Get(&count, "SELECT COUNT(*) FROM carrier_followers WHERE last_seen >= ? AND times_seen > ?",
+		cutoff, minSightings)
+	if err != nil {
+		core.LogErrorF("Failed to count recent followers: %s", err)
+		return 0
+	}
+	return count
+}
+
 // currentWeekStart returns the Monday of the current UTC week as "2006-01-02"
 func currentWeekStart() string {
 	now := time.Now().UTC()
@@ -471,11 +488,18 @@ func CreateProximityAlert(userID, systemName string, distanceLY float64, carrier
 
 // FetchProximityAlertsByUser returns all proximity alerts for a given user
 func FetchProximityAlertsByUser(userID string) []ProximityAlert {
+	return FetchProximityAlertsPage(userID, 0, -1)
+}
+
+// FetchProximityAlertsPage returns a page of the proximity alerts of a user, newest first, starting at offset.
+// A negative limit returns all of them.
+func FetchProximityAlertsPage(userID string, offset, limit int) []ProximityAlert {
 	if database == nil {
 		return nil
 	}
 	var alerts []ProximityAlert
-	err := database.Select(&alerts, "SELECT * FROM proximity_alerts WHERE user_id = ? ORDER BY created_at DESC", userID)
+	err := database.Select(&alerts, "SELECT * FROM proximity_alerts WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+		userID, limit, offset)
 	if err != nil {
 		core.LogErrorF("Failed to fetch proximity alerts for user %s: %s", userID, err)
 		return nil
@@ -483,6 +507,20 @@ func FetchProximityAlertsByUser(userID string) []ProximityAlert {
 	return alerts
 }
 
+// CountProximityAlertsByUser returns the number of proximity alerts of a user
+func CountProximityAlertsByUser(userID string) int {
+	if database == nil {
+		return 0
+	}
+	var count int
+	err := database.Get(&count, "SELECT COUNT(*) FROM proximity_alerts WHERE user_id = ?", userID)
+	if err != nil {
+		core.LogErrorF("Failed to count proximity alerts for user %s: %s", userID, err)
+		return 0
+	}
+	return count
+}
+
 // FetchAllProximityAlerts returns all active proximity alerts
 func FetchAllProximityAlerts() []ProximityAlert {
 	if database == nil {
@@ -232,7 +232,7 @@ func TestFetchRecentFollowers(t *testing.T) {
 	UpsertCarrierFollower("NEW-003", "OUR-001", "Barnards Star", 50.0, recentTime+200)
 
 	// Fetch recent followers (7 days, more than 1 sighting)
-	followers := FetchRecentFollowers(7, 1, "recent")
+	followers := FetchRecentFollowers(7, 1, "recent", 0, -1)
 
 	if len(followers) != 2 {
 		t.Errorf("Expected 2 recent followers with 2+ sightings, got %d", len(followers))
@@ -282,7 +282,7 @@ func TestFetchRecentFollowers_SortByTimes(t *testing.T) {
 	UpsertCarrierFollower("MANY-001", "OUR-001", "Beta", 50.0, now+2)
 	UpsertCarrierFollower("MANY-001", "OUR-001", "Gamma", 50.0, now+3)
 
-	followers := FetchRecentFollowers(7, 1, "times")
+	followers := FetchRecentFollowers(7, 1, "times", 0, -1)
 
 	if len(followers) < 2 {
 		t.Fatalf("Expected at least 2 followers, got %d", len(followers))
@@ -297,6 +297,30 @@ func TestFetchRecentFollowers_SortByTimes(t *testing.T) {
 	}
 }
 
+func TestFetchRecentFollowers_Paged(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().Unix()
+	for i, id := range []string{"PAG-001", "PAG-002", "PAG-003", "PAG-004", "PAG-005"} {
+		UpsertCarrierFollower(id, "OUR-001", "Sol", 50.0, now+int64(i))
+		UpsertCarrierFollower(id, "OUR-001", "Alpha", 50.0, now+int64(i)+10)
+	}
+	UpsertCarrierFollower("ONCE-001", "OUR-001", "Sol", 50.0, now)
+
+	if count := CountRecentFollowers(7, 1); count != 5 {
+		t.Errorf("Expected 5 followers with 2+ sightings, got %d", count)
+	}
+	first := FetchRecentFollowers(7, 1, "recent", 0, 2)
+	last := FetchRecentFollowers(7, 1, "recent", 4, 2)
+	if len(first) != 2 || first[0].FollowerStationId != "PAG-005" || first[1].FollowerStationId != "PAG-004" {
+		t.Errorf("Expected PAG-005 and PAG-004 on the first page, got %+v", first)
+	}
+	if len(last) != 1 || last[0].FollowerStationId != "PAG-001" {
+		t.Errorf("Expected only PAG-001 on the last page, got %+v", last)
+	}
+}
+
 func TestCreateProximityAlert(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -372,6 +396,27 @@ func TestFetchProximityAlertsByUser(t *testing.T) {
 	}
 }
 
+func TestFetchProximityAlertsPage(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, system := range []string{"Sol", "Sirius", "Achenar"} {
+		CreateProximityAlert("user1", system, 50.0, "")
+	}
+	CreateProximityAlert("user2", "Maia", 50.0, "")
+
+	if count := CountProximityAlertsByUser("user1"); count != 3 {
+		t.Errorf("Expected 3 alerts for user1, got %d", count)
+	}
+	page := FetchProximityAlertsPage("user1", 1, 1)
+	if len(page) != 1 || page[0].SystemName != "Sirius" {
+		t.Errorf("Expected the Sirius alert on the second page, got %+v", page)
+	}
+	if page = FetchProximityAlertsPage("user1", 3, 1); len(page) != 0 {
+		t.Errorf("Expected nothing past the last alert, got %+v", page)
+	}
+}
+
 func TestFetchAllProximityAlerts(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"GoBot/core"
+)
+
+// ComponentState is data kept for a button or select menu whose custom ID can't hold it
+type ComponentState struct {
+	Id        string
+	Namespace string // Namespace of the component handler the state belongs to
+	Data      string
+	ExpiresAt int64 `db:"expires_at"`
+	CreatedAt int64 `db:"created_at"`
+}
+
+const componentStateSchema = `
+CREATE TABLE IF NOT EXISTS component_state (
+	id TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	data TEXT NOT NULL DEFAULT '',
+	expires_at INTEGER NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS component_state_expires_at_index ON component_state (expires_at);
+`
+
+// InitializeComponentStateTable creates the component_state table if it doesn't exist
+func InitializeComponentStateTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize component state table.")
+		return
+	}
+	_, err := database.Exec(componentStateSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create component_state table: %s", err)
+	}
+}
+
+// CreateComponentState stores the state of a component
+func CreateComponentState(state ComponentState) error {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("INSERT INTO component_state (id, namespace, data, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+			state.Id, state.Namespace, state.Data, state.ExpiresAt, time.Now().Unix())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store %s component state: %w", state.Namespace, err)
+	}
+	return nil
+}
+
+// FetchComponentState returns the state of a component, or nil if it doesn't exist or has expired
+func FetchComponentState(id string, now time.Time) *ComponentState {
+	if database == nil {
+		return nil
+	}
+	var state ComponentState
+	err := database.Get(&state, "SELECT * FROM component_state WHERE id = ? AND expires_at > ?", id, now.Unix())
+	if err != nil {
+		if err != sql.ErrNoRows {
+			core.LogErrorF("Failed to fetch component state %s: %s", id, err)
+		}
+		return nil
+	}
+	return &state
+}
+
+// PruneComponentState removes the state that expired before the given time, returning how many were removed
+func PruneComponentState(before time.Time) int64 {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM component_state WHERE expires_at <= ?", before.Unix())
+	})
+	if err != nil {
+		core.LogErrorF("Failed to prune component state: %s", err)
+		return 0
+	}
+	removed, _ := res.RowsAffected()
+	return removed
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComponentState(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(componentStateSchema)
+
+	now := time.Now()
+	if err := CreateComponentState(ComponentState{Id: "a1", Namespace: "carriers", Data: "W7H-6DZ", ExpiresAt: now.Add(time.Hour).Unix()}); err != nil {
+		t.Fatalf("Failed to store state: %s", err)
+	}
+	CreateComponentState(ComponentState{Id: "b2", Namespace: "carriers", ExpiresAt: now.Add(-time.Minute).Unix()})
+	if err := CreateComponentState(ComponentState{Id: "a1", Namespace: "other"}); err == nil {
+		t.Error("Expected an error for a duplicate ID")
+	}
+
+	state := FetchComponentState("a1", now)
+	if state == nil || state.Namespace != "carriers" || state.Data != "W7H-6DZ" {
+		t.Errorf("Expected the stored state, got %+v", state)
+	}
+	if state := FetchComponentState("b2", now); state != nil {
+		t.Errorf("Expected no expired state, got %+v", state)
+	}
+
+	if removed := PruneComponentState(now); removed != 1 {
+		t.Errorf("Expected 1 expired state to be pruned, got %d", removed)
+	}
+	if FetchComponentState("a1", now) == nil {
+		t.Error("Expected the state that hasn't expired to be kept")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"GoBot/core"
 	"github.com/jmoiron/sqlx"
@@ -24,6 +25,9 @@ const (
 	CommandField   FieldName = "command"
 	RoleField      FieldName = "role"
 	UserIdField    FieldName = "user_id"
+	RolesField     FieldName = "roles"
+	ExplainField   FieldName = "explain_denied"
+	ResponseField  FieldName = "response"
 
 	CommandAliasTable TableName = "commandalias"
 	CommandGroupTable TableName = "commandgroup"
@@ -35,7 +39,9 @@ CREATE TABLE IF NOT EXISTS userrole ( id INTEGER PRIMARY KEY AUTOINCREMENT , rol
 CREATE INDEX IF NOT EXISTS userrole_id_index ON userrole (user_id);
 CREATE INDEX IF NOT EXISTS userrole_role_index ON userrole (role);
 
-CREATE TABLE IF NOT EXISTS commandalias ( id INTEGER PRIMARY KEY AUTOINCREMENT , pmenabled INTEGER, group_id INTEGER, command VARCHAR, help VARCHAR, longhelp VARCHAR, value VARCHAR );
+CREATE TABLE IF NOT EXISTS commandalias ( id INTEGER PRIMARY KEY AUTOINCREMENT , pmenabled INTEGER, group_id INTEGER, command VARCHAR, help VARCHAR, longhelp VARCHAR, value VARCHAR,
+	cooldown INTEGER, cooldown_scope TEXT NOT NULL DEFAULT 'channel', channel_mode TEXT NOT NULL DEFAULT '', channels TEXT NOT NULL DEFAULT '',
+	roles TEXT NOT NULL DEFAULT '', explain_denied INTEGER NOT NULL DEFAULT 0, response TEXT );
 CREATE INDEX IF NOT EXISTS commandalias_command_index ON commandalias (command);
 CREATE INDEX IF NOT EXISTS commandalias_pmenabled_index ON commandalias (pmenabled);
 CREATE INDEX IF NOT EXISTS commandalias_group_index ON commandalias (group_id);
@@ -45,12 +51,53 @@ CREATE INDEX IF NOT EXISTS commandgroup_command_index ON commandgroup (command);
 CREATE INDEX IF NOT EXISTS commandgroup_parent_index ON commandgroup (parent);
 `
 
+// Cooldown scopes of a custom command
+const (
+	CooldownPerChannel = "channel"
+	CooldownPerUser    = "user"
+)
+
+// Channel modes of a custom command. Without a mode it can be used in every channel.
+const (
+	ChannelsAllow = "allow" // Only in the listed channels
+	ChannelsDeny  = "deny"  // Everywhere but the listed channels
+)
+
 type CommandAlias struct {
 	Id             int64
 	PMEnabled      bool
 	GroupId        *int `db:"group_id"`
 	Command, Value string
 	Help, Longhelp *string
+	Response       *string // Embed, files and further messages of the reply as JSON, see services.CommandResponse
+	CommandRules
+}
+
+// CommandRules are the cooldown and restrictions of a custom command
+type CommandRules struct {
+	Cooldown      *int   // Seconds between uses, nil for the guild cooldown
+	CooldownScope string `db:"cooldown_scope"` // CooldownPerChannel or CooldownPerUser
+	ChannelMode   string `db:"channel_mode"`   // "", ChannelsAllow or ChannelsDeny
+	Channels      string // Comma separated channel IDs the mode applies to
+	Roles         string // Comma separated role IDs, one of which is required to use the command
+	ExplainDenied bool   `db:"explain_denied"` // Reply with the reason when the command can't be used, instead of ignoring it
+}
+
+// ChannelIds returns the channels of the channel mode
+func (c *CommandRules) ChannelIds() []string {
+	return splitIds(c.Channels)
+}
+
+// RoleIds returns the roles required to use the command
+func (c *CommandRules) RoleIds() []string {
+	return splitIds(c.Roles)
+}
+
+func splitIds(ids string) []string {
+	if ids == "" {
+		return nil
+	}
+	return strings.Split(ids, ",")
 }
 
 type CommandGroup struct {
@@ -62,8 +109,8 @@ type CommandGroup struct {
 
 type UserRole struct {
 	Id     int64
-	Role   int
-	UserId int `db:"user_id"`
+	Role   core.PermissionLevel
+	UserId string `db:"user_id"`
 }
 type count struct {
 	Count int64
@@ -82,12 +129,39 @@ func InitalizeDatabase() {
 	db.MustExec(schema)
 	database = db
 
+	// Migrations for existing databases
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN cooldown INTEGER")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN cooldown_scope TEXT NOT NULL DEFAULT 'channel'")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN channel_mode TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN channels TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN roles TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN explain_denied INTEGER NOT NULL DEFAULT 0")
+	_, _ = database.Exec("ALTER TABLE commandalias ADD COLUMN response TEXT")
+
 	// Initialize carrier table
 	InitializeCarrierTable()
+	InitializeRoleTables()
+	InitializeGuildSettingsTable()
+	InitializeUsageTable()
+	InitializeJobTable()
+	InitializeComponentStateTable()
+	InitializeUserLocaleTable()
+	InitializeCommandRevisionTable()
 }
 
+// Close writes the pending usage records and closes the database
 func Close() {
-	database.Close()
+	if database == nil {
+		return
+	}
+	FlushCommandUsage()
+	err := database.Close()
+	database = nil
+	if err != nil {
+		core.LogErrorF("Failed to close database: %s", err)
+		return
+	}
+	core.LogInfo("Database closed")
 }
 
 func FetchCommandAlias(cmd string) *CommandAlias {
@@ -177,6 +251,60 @@ func CreateCommandAlias(cmd, val string) bool {
 	return true
 }
 
+// SaveCommandAlias creates a command, or replaces all of it if it exists, including its rules. The group must exist.
+func SaveCommandAlias(cmd CommandAlias) error {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return saveCommandAlias(tx, cmd)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save command %s: %w", cmd.Command, err)
+	}
+	return nil
+}
+
+// saveCommandAlias is SaveCommandAlias in a transaction
+func saveCommandAlias(tx *sql.Tx, cmd CommandAlias) (sql.Result, error) {
+	if cmd.CooldownScope == "" {
+		cmd.CooldownScope = CooldownPerChannel
+	}
+	res, err := tx.Exec(`UPDATE commandalias SET value = ?, help = ?, longhelp = ?, pmenabled = ?, group_id = ?, response = ?,
+		cooldown = ?, cooldown_scope = ?, channel_mode = ?, channels = ?, roles = ?, explain_denied = ? WHERE command = ?`,
+		cmd.Value, cmd.Help, cmd.Longhelp, cmd.PMEnabled, cmd.GroupId, cmd.Response,
+		cmd.Cooldown, cmd.CooldownScope, cmd.ChannelMode, cmd.Channels, cmd.Roles, cmd.ExplainDenied, cmd.Command)
+	if err != nil {
+		return res, err
+	}
+	if rows, _ := res.RowsAffected(); rows > 0 {
+		return res, nil
+	}
+	return tx.Exec(`INSERT INTO commandalias (command, value, help, longhelp, pmenabled, group_id, response,
+		cooldown, cooldown_scope, channel_mode, channels, roles, explain_denied) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		cmd.Command, cmd.Value, cmd.Help, cmd.Longhelp, cmd.PMEnabled, cmd.GroupId, cmd.Response,
+		cmd.Cooldown, cmd.CooldownScope, cmd.ChannelMode, cmd.Channels, cmd.Roles, cmd.ExplainDenied)
+}
+
+// SetCommandCooldown sets the cooldown of a command and what it applies to. A nil cooldown uses the guild cooldown.
+func SetCommandCooldown(cmd string, seconds *int, scope string) bool {
+	return updateCommandAliasFields(cmd, "cooldown = ?, cooldown_scope = ?", seconds, scope)
+}
+
+// SetCommandChannels sets where a command can be used. An empty mode allows every channel.
+func SetCommandChannels(cmd string, mode string, channelIds []string) bool {
+	return updateCommandAliasFields(cmd, "channel_mode = ?, channels = ?", mode, strings.Join(channelIds, ","))
+}
+
+func updateCommandAliasFields(cmd string, assignments string, values ...interface{}) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("UPDATE commandalias SET "+assignments+" WHERE command = ?", append(values, cmd)...)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to update command %s: %s", cmd, err)
+		return false
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
+
 func updateTable(table TableName, whereKey FieldName, whereVal interface{}, field FieldName, val interface{}) bool {
 	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
 		if val == nil {
@@ -278,8 +406,17 @@ func FetchOrCreateCommandGroup(cmd string) *CommandGroup {
 }
 
 func FetchCommandGroups() []CommandGroup {
+	return FetchCommandGroupsPage(0, -1)
+}
+
+// FetchCommandGroupsPage returns a page of the command groups by name, starting at offset. A negative limit returns all.
+func FetchCommandGroupsPage(offset, limit int) []CommandGroup {
+	if database == nil {
+		core.LogError("Database isn't open. Shouldn't happen.")
+		return nil
+	}
 	var groups []CommandGroup
-	err := database.Select(&groups, "SELECT * FROM commandgroup ORDER BY command ASC")
+	err := database.Select(&groups, "SELECT * FROM commandgroup ORDER BY command ASC LIMIT ? OFFSET ?", limit, offset)
 	switch err {
 	default:
 		core.LogErrorF("Failed to fetch command groups: %s", err)
@@ -291,6 +428,11 @@ func FetchCommandGroups() []CommandGroup {
 	}
 }
 
+// CountCommandGroups returns the number of command groups
+func CountCommandGroups() int {
+	return countRows("SELECT COUNT(*) FROM commandgroup")
+}
+
 func (c *CommandGroup) FetchCommands() []CommandAlias {
 	var commands []CommandAlias
 	err := database.Select(&commands, "SELECT * FROM commandalias WHERE group_id=? ORDER BY command ASC", c.Id)
@@ -305,9 +447,35 @@ func (c *CommandGroup) FetchCommands() []CommandAlias {
 	}
 }
 
+// FetchCommandNames returns the names of all custom commands and categories
+func FetchCommandNames() []string {
+	if database == nil {
+		core.LogError("Database isn't open. Shouldn't happen.")
+		return nil
+	}
+	var names []string
+	err := database.Select(&names, "SELECT command FROM commandalias UNION SELECT command FROM commandgroup")
+	if err != nil {
+		core.LogErrorF("Failed to fetch command names: %s", err)
+		return nil
+	}
+	return names
+}
+
 func FetchStandaloneCommands() []CommandAlias {
+	return FetchStandaloneCommandsPage(0, -1)
+}
+
+// FetchStandaloneCommandsPage returns a page of the commands outside any group by name, starting at offset.
+// A negative limit returns all.
+func FetchStandaloneCommandsPage(offset, limit int) []CommandAlias {
+	if database == nil {
+		core.LogError("Database isn't open. Shouldn't happen.")
+		return nil
+	}
 	var commands []CommandAlias
-	err := database.Select(&commands, "SELECT * FROM commandalias WHERE group_id IS NULL ORDER BY command ASC")
+	err := database.Select(&commands, "SELECT * FROM commandalias WHERE group_id IS NULL ORDER BY command ASC LIMIT ? OFFSET ?",
+		limit, offset)
 	switch err {
 	default:
 		core.LogErrorF("Failed to fetch standalone commandsXS: %s", err)
@@ -318,3 +486,22 @@ func FetchStandaloneCommands() []CommandAlias {
 		return commands
 	}
 }
+
+// CountStandaloneCommands returns the number of commands outside any group
+func CountStandaloneCommands() int {
+	return countRows("SELECT COUNT(*) FROM commandalias WHERE group_id IS NULL")
+}
+
+// countRows runs a query returning a single count, logging failures as a count of zero
+func countRows(query string, args ...interface{}) int {
+	if database == nil {
+		core.LogError("Database isn't open. Shouldn't happen.")
+		return 0
+	}
+	var n int
+	if err := database.Get(&n, query, args...); err != nil {
+		core.LogErrorF("Failed to count with %q: %s", query, err)
+		return 0
+	}
+	return n
+}
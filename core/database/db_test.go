@@ -0,0 +1,96 @@
+package database
+
+import "testing"
+
+func TestFetchCommandsPage(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+
+	for _, cmd := range []string{"delta", "alpha", "charlie", "bravo"} {
+		CreateCommandAlias(cmd, "text")
+	}
+	FetchOrCreateCommandGroup("rules")
+	FetchOrCreateCommandGroup("guides")
+	UpdateCommandAlias("command", "delta", "group_id", FetchCommandGroup("rules").Id)
+
+	if count := CountStandaloneCommands(); count != 3 {
+		t.Errorf("Expected 3 commands outside a category, got %d", count)
+	}
+	if count := CountCommandGroups(); count != 2 {
+		t.Errorf("Expected 2 categories, got %d", count)
+	}
+	commands := FetchStandaloneCommandsPage(1, 5)
+	if len(commands) != 2 || commands[0].Command != "bravo" || commands[1].Command != "charlie" {
+		t.Errorf("Expected bravo and charlie from the second command on, got %+v", commands)
+	}
+	groups := FetchCommandGroupsPage(0, 1)
+	if len(groups) != 1 || groups[0].Command != "guides" {
+		t.Errorf("Expected only guides on the first page, got %+v", groups)
+	}
+	if all := FetchCommandGroups(); len(all) != 2 {
+		t.Errorf("Expected all categories without a limit, got %d", len(all))
+	}
+}
+
+func TestSaveCommandAlias(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+
+	help := "Greets you"
+	if err := SaveCommandAlias(CommandAlias{Command: "hi", Value: "Hello", Help: &help, PMEnabled: true}); err != nil {
+		t.Fatalf("Failed to create: %s", err)
+	}
+	if err := SaveCommandAlias(CommandAlias{Command: "hi", Value: "Hi there"}); err != nil {
+		t.Fatalf("Failed to replace: %s", err)
+	}
+	cmd := FetchCommandAlias("hi")
+	if cmd == nil || cmd.Value != "Hi there" || cmd.Help != nil || cmd.PMEnabled {
+		t.Errorf("Expected the whole command to be replaced, got %+v", cmd)
+	}
+	if count := CountStandaloneCommands(); count != 1 {
+		t.Errorf("Expected a single command, got %d", count)
+	}
+}
+
+func TestCommandRestrictions(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+	CreateCommandAlias("faq", "Read the FAQ")
+
+	cmd := FetchCommandAlias("faq")
+	if cmd.Cooldown != nil || cmd.CooldownScope != CooldownPerChannel || cmd.ChannelMode != "" || cmd.RoleIds() != nil || cmd.ExplainDenied {
+		t.Fatalf("Expected a new command to have no restrictions, got %+v", cmd)
+	}
+
+	seconds := 60
+	if !SetCommandCooldown("faq", &seconds, CooldownPerUser) {
+		t.Fatal("Failed to set the cooldown")
+	}
+	if !SetCommandChannels("faq", ChannelsAllow, []string{"1", "2"}) {
+		t.Fatal("Failed to set the channels")
+	}
+	UpdateCommandAlias(CommandField, "faq", RolesField, "3")
+	UpdateCommandAlias(CommandField, "faq", ExplainField, true)
+	cmd = FetchCommandAlias("faq")
+	if cmd.Cooldown == nil || *cmd.Cooldown != 60 || cmd.CooldownScope != CooldownPerUser {
+		t.Errorf("Expected a 60 second cooldown per user, got %v per %s", cmd.Cooldown, cmd.CooldownScope)
+	}
+	if cmd.ChannelMode != ChannelsAllow || len(cmd.ChannelIds()) != 2 || cmd.ChannelIds()[1] != "2" {
+		t.Errorf("Expected channels 1 and 2 to be allowed, got %s %v", cmd.ChannelMode, cmd.ChannelIds())
+	}
+	if len(cmd.RoleIds()) != 1 || !cmd.ExplainDenied {
+		t.Errorf("Expected role 3 and explanations, got %v and %t", cmd.RoleIds(), cmd.ExplainDenied)
+	}
+
+	SetCommandCooldown("faq", nil, CooldownPerChannel)
+	SetCommandChannels("faq", "", nil)
+	if cmd = FetchCommandAlias("faq"); cmd.Cooldown != nil || cmd.ChannelMode != "" || cmd.ChannelIds() != nil {
+		t.Errorf("Expected the cooldown and channels to be cleared, got %+v", cmd)
+	}
+	if SetCommandCooldown("missing", nil, CooldownPerChannel) {
+		t.Error("Expected setting the cooldown of a missing command to fail")
+	}
+}
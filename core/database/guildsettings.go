@@ -0,0 +1,258 @@
+package database
+
+import (
+	"database/sql"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"GoBot/core"
+)
+
+// GuildSettingKey is the name of a setting that can be changed per guild
+type GuildSettingKey string
+
+const (
+	GuildPrefix               GuildSettingKey = "prefix"
+	GuildBotChannels          GuildSettingKey = "botchannels"
+	GuildCarrierUpdateChannel GuildSettingKey = "carrierupdatechannel"
+	GuildFlightLogChannel     GuildSettingKey = "flightlogchannel"
+	GuildCooldown             GuildSettingKey = "cooldown"
+	GuildSuggestions          GuildSettingKey = "suggestions" // Can also be set per channel, see ChannelSettingKey
+	GuildLocale               GuildSettingKey = "locale"
+)
+
+// GuildSettingKeys lists all per-guild settings
+var GuildSettingKeys = []GuildSettingKey{GuildPrefix, GuildBotChannels, GuildCarrierUpdateChannel, GuildFlightLogChannel, GuildCooldown, GuildSuggestions, GuildLocale}
+
+// ChannelSettingKey returns the key used to store a setting for a single channel
+func ChannelSettingKey(key GuildSettingKey, channelId string) GuildSettingKey {
+	return GuildSettingKey(string(key) + ":" + channelId)
+}
+
+const guildSettingsSchema = `
+CREATE TABLE IF NOT EXISTS guild_settings (
+	guild_id TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (guild_id, key)
+);
+`
+
+// GuildSettings holds the resolved settings for a guild. Settings not set for the guild
+// fall back to the values in the config file.
+type GuildSettings struct {
+	GuildId                   string
+	CommandPrefix             string
+	BotChannels               []string
+	CarrierUpdateChannelId    string
+	CarrierFlightLogChannelId string
+	CustomCommandCooldown     int
+	SuggestionMode            string
+	ChannelSuggestionModes    map[string]string // Suggestion mode by channel ID, overriding SuggestionMode
+	Locale                    string            // Language of replies, for users who haven't chosen one
+	overrides                 map[GuildSettingKey]bool
+}
+
+type guildSetting struct {
+	GuildId string `db:"guild_id"`
+	Key     GuildSettingKey
+	Value   string
+}
+
+var (
+	guildSettingsCache   = map[string]*GuildSettings{}
+	guildSettingsCacheMu sync.Mutex
+)
+
+// InitializeGuildSettingsTable creates the guild_settings table if it doesn't exist
+func InitializeGuildSettingsTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize guild settings table.")
+		return
+	}
+	_, err := database.Exec(guildSettingsSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create guild_settings table: %s", err)
+	}
+}
+
+// defaultGuildSettings returns the settings from the config file
+func defaultGuildSettings(guildId string) *GuildSettings {
+	return &GuildSettings{
+		GuildId:                   guildId,
+		CommandPrefix:             core.Settings.CommandPrefix(),
+		BotChannels:               core.Settings.BotChannels(),
+		CarrierUpdateChannelId:    core.Settings.CarrierUpdateChannelId(),
+		CarrierFlightLogChannelId: core.Settings.CarrierFlightLogChannelId(),
+		CustomCommandCooldown:     core.Settings.CustomCommandCooldown(),
+		SuggestionMode:            core.Settings.SuggestionMode(),
+		ChannelSuggestionModes:    map[string]string{},
+		Locale:                    core.Settings.Locale(),
+		overrides:                 map[GuildSettingKey]bool{},
+	}
+}
+
+// FetchGuildSettings returns the settings for a guild. An empty guild ID (i.e. a DM) returns the config file settings.
+func FetchGuildSettings(guildId string) *GuildSettings {
+	if guildId == "" || database == nil {
+		return defaultGuildSettings(guildId)
+	}
+
+	guildSettingsCacheMu.Lock()
+	defer guildSettingsCacheMu.Unlock()
+	if settings, ok := guildSettingsCache[guildId]; ok {
+		return settings.clone()
+	}
+
+	var rows []guildSetting
+	err := database.Select(&rows, "SELECT * FROM guild_settings WHERE guild_id = ?", guildId)
+	if err != nil {
+		core.LogErrorF("Failed to fetch settings for guild %s: %s", guildId, err)
+		return defaultGuildSettings(guildId)
+	}
+
+	settings := defaultGuildSettings(guildId)
+	for _, row := range rows {
+		settings.apply(row.Key, row.Value)
+	}
+	guildSettingsCache[guildId] = settings
+	return settings.clone()
+}
+
+// clone copies the settings, so callers can't change the cached ones
+func (g *GuildSettings) clone() *GuildSettings {
+	clone := *g
+	clone.BotChannels = slices.Clone(g.BotChannels)
+	clone.ChannelSuggestionModes = maps.Clone(g.ChannelSuggestionModes)
+	clone.overrides = maps.Clone(g.overrides)
+	return &clone
+}
+
+// FetchGuildSettingsWithOverride returns the settings of all guilds that have set the given key
+func FetchGuildSettingsWithOverride(key GuildSettingKey) []*GuildSettings {
+	if database == nil {
+		return nil
+	}
+	var guildIds []string
+	err := database.Select(&guildIds, "SELECT guild_id FROM guild_settings WHERE key = ? ORDER BY guild_id", key)
+	if err != nil {
+		core.LogErrorF("Failed to fetch guilds with setting %s: %s", key, err)
+		return nil
+	}
+	var settings []*GuildSettings
+	for _, guildId := range guildIds {
+		settings = append(settings, FetchGuildSettings(guildId))
+	}
+	return settings
+}
+
+// SetGuildSetting stores a setting for a guild. The value must already be validated.
+func SetGuildSetting(guildId string, key GuildSettingKey, value string) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(`INSERT INTO guild_settings (guild_id, key, value) VALUES (?, ?, ?)
+			ON CONFLICT(guild_id, key) DO UPDATE SET value = excluded.value`, guildId, key, value)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to set %s for guild %s: %s", key, guildId, err)
+		return false
+	}
+	invalidateGuildSettings(guildId)
+	return true
+}
+
+// ResetGuildSetting removes a setting for a guild, so the config file value is used. Returns true if one was removed.
+func ResetGuildSetting(guildId string, key GuildSettingKey) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM guild_settings WHERE guild_id = ? AND key = ?", guildId, key)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to reset %s for guild %s: %s", key, guildId, err)
+		return false
+	}
+	invalidateGuildSettings(guildId)
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
+
+func invalidateGuildSettings(guildId string) {
+	guildSettingsCacheMu.Lock()
+	defer guildSettingsCacheMu.Unlock()
+	delete(guildSettingsCache, guildId)
+}
+
+func (g *GuildSettings) apply(key GuildSettingKey, value string) {
+	g.overrides[key] = true
+	switch key {
+	case GuildPrefix:
+		g.CommandPrefix = value
+	case GuildBotChannels:
+		g.BotChannels = nil
+		if value != "" {
+			g.BotChannels = strings.Split(value, ",")
+		}
+	case GuildCarrierUpdateChannel:
+		g.CarrierUpdateChannelId = value
+	case GuildFlightLogChannel:
+		g.CarrierFlightLogChannelId = value
+	case GuildCooldown:
+		g.CustomCommandCooldown, _ = strconv.Atoi(value)
+	case GuildSuggestions:
+		g.SuggestionMode = value
+	case GuildLocale:
+		g.Locale = value
+	default:
+		if channelId, ok := strings.CutPrefix(string(key), string(GuildSuggestions)+":"); ok {
+			g.ChannelSuggestionModes[channelId] = value
+			return
+		}
+		delete(g.overrides, key)
+	}
+}
+
+// IsOverridden returns true if the setting is set for the guild rather than taken from the config file
+func (g *GuildSettings) IsOverridden(key GuildSettingKey) bool {
+	return g.overrides[key]
+}
+
+// Value returns the setting formatted the way it's stored
+func (g *GuildSettings) Value(key GuildSettingKey) string {
+	switch key {
+	case GuildPrefix:
+		return g.CommandPrefix
+	case GuildBotChannels:
+		return strings.Join(g.BotChannels, ",")
+	case GuildCarrierUpdateChannel:
+		return g.CarrierUpdateChannelId
+	case GuildFlightLogChannel:
+		return g.CarrierFlightLogChannelId
+	case GuildCooldown:
+		return strconv.Itoa(g.CustomCommandCooldown)
+	case GuildSuggestions:
+		return g.SuggestionMode
+	case GuildLocale:
+		return g.Locale
+	default:
+		return ""
+	}
+}
+
+// IsBotChannel checks if a channel ID is a bot channel in the guild
+func (g *GuildSettings) IsBotChannel(channelID string) bool {
+	for _, id := range g.BotChannels {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestionModeFor returns how to respond to unknown commands in a channel
+func (g *GuildSettings) SuggestionModeFor(channelID string) string {
+	if mode, ok := g.ChannelSuggestionModes[channelID]; ok {
+		return mode
+	}
+	return g.SuggestionMode
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestGuildSettings(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(guildSettingsSchema)
+	defer func() {
+		guildSettingsCache = map[string]*GuildSettings{}
+	}()
+
+	defaults := FetchGuildSettings("guild1")
+	if defaults.IsOverridden(GuildPrefix) {
+		t.Error("Expected prefix not to be overridden before it is set")
+	}
+
+	SetGuildSetting("guild1", GuildPrefix, "!")
+	SetGuildSetting("guild1", GuildBotChannels, "123,456")
+	SetGuildSetting("guild1", GuildCooldown, "30")
+
+	settings := FetchGuildSettings("guild1")
+	if settings.CommandPrefix != "!" {
+		t.Errorf("Expected prefix '!', got %q", settings.CommandPrefix)
+	}
+	if !settings.IsBotChannel("456") || settings.IsBotChannel("789") {
+		t.Errorf("Expected bot channels [123 456], got %v", settings.BotChannels)
+	}
+	if settings.CustomCommandCooldown != 30 {
+		t.Errorf("Expected cooldown 30, got %d", settings.CustomCommandCooldown)
+	}
+	// Changing the returned settings leaves the cached ones alone
+	settings.CommandPrefix, settings.BotChannels[0] = "?", "789"
+	settings.ChannelSuggestionModes["123"] = "off"
+	if again := FetchGuildSettings("guild1"); again.CommandPrefix != "!" || again.BotChannels[0] != "123" || len(again.ChannelSuggestionModes) > 0 {
+		t.Errorf("Expected the cached settings unchanged, got %+v", again)
+	}
+	if other := FetchGuildSettings("guild2"); other.CommandPrefix == "!" {
+		t.Error("Expected guild2 not to use the prefix of guild1")
+	}
+
+	if !ResetGuildSetting("guild1", GuildPrefix) {
+		t.Error("Expected prefix to be reset")
+	}
+	if settings := FetchGuildSettings("guild1"); settings.IsOverridden(GuildPrefix) || settings.CustomCommandCooldown != 30 {
+		t.Error("Expected only the prefix to be reset")
+	}
+
+	if guilds := FetchGuildSettingsWithOverride(GuildBotChannels); len(guilds) != 1 || guilds[0].GuildId != "guild1" {
+		t.Errorf("Expected guild1 to override bot channels, got %d guilds", len(guilds))
+	}
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"GoBot/core"
+)
+
+// MissedPolicy decides what happens to a job that was due while the bot was down
+type MissedPolicy string
+
+const (
+	MissedCatchUp MissedPolicy = "catchup" // Run the job once, late
+	MissedSkip    MissedPolicy = "skip"    // Don't run the missed occurrence. One-shot jobs are dropped.
+)
+
+// ScheduledJob is a job run by the scheduler, once or on a recurring schedule
+type ScheduledJob struct {
+	Id        int64
+	Type      string       // Job type, registered with the scheduler
+	Payload   string       // Job type specific data
+	RunAt     int64        `db:"run_at"` // Next run, Unix time
+	Schedule  string       // Cron expression of recurring jobs, empty for one-shot jobs
+	Missed    MissedPolicy // What to do if the run was missed
+	GuildId   string       `db:"guild_id"`
+	CreatedBy string       `db:"created_by"`
+	CreatedAt int64        `db:"created_at"`
+	LastRun   *int64       `db:"last_run"`
+	LastError *string      `db:"last_error"`
+	Attempts  int          // Failed runs of a one-shot job, which is retried until it succeeds or gives up
+}
+
+// IsRecurring returns true if the job runs on a schedule rather than once
+func (j *ScheduledJob) IsRecurring() bool {
+	return j.Schedule != ""
+}
+
+const scheduledJobSchema = `
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	payload TEXT NOT NULL DEFAULT '',
+	run_at INTEGER NOT NULL,
+	schedule TEXT NOT NULL DEFAULT '',
+	missed TEXT NOT NULL DEFAULT 'catchup',
+	guild_id TEXT NOT NULL DEFAULT '',
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	last_run INTEGER,
+	last_error TEXT,
+	attempts INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS scheduled_jobs_run_at_index ON scheduled_jobs (run_at);
+`
+
+// InitializeJobTable creates the scheduled_jobs table if it doesn't exist
+func InitializeJobTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize job table.")
+		return
+	}
+	_, err := database.Exec(scheduledJobSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create scheduled_jobs table: %s", err)
+	}
+	// Migrations for existing databases
+	_, _ = database.Exec("ALTER TABLE scheduled_jobs ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0")
+}
+
+// CreateScheduledJob stores a new job and returns its ID
+func CreateScheduledJob(job ScheduledJob) (int64, error) {
+	if job.Missed == "" {
+		job.Missed = MissedCatchUp
+	}
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(`INSERT INTO scheduled_jobs (type, payload, run_at, schedule, missed, guild_id, created_by, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, job.Type, job.Payload, job.RunAt, job.Schedule, job.Missed, job.GuildId,
+			job.CreatedBy, time.Now().Unix())
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s job: %w", job.Type, err)
+	}
+	return res.LastInsertId()
+}
+
+// FetchScheduledJob returns a job by ID, or nil if it doesn't exist
+func FetchScheduledJob(id int64) *ScheduledJob {
+	if database == nil {
+		return nil
+	}
+	var job ScheduledJob
+	err := database.Get(&job, "SELECT * FROM scheduled_jobs WHERE id = ?", id)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			core.LogErrorF("Failed to fetch job %d: %s", id, err)
+		}
+		return nil
+	}
+	return &job
+}
+
+// FetchScheduledJobs returns all jobs, the next to run first
+func FetchScheduledJobs() []ScheduledJob {
+	return fetchScheduledJobs("SELECT * FROM scheduled_jobs ORDER BY run_at ASC, id ASC")
+}
+
+// FetchScheduledJobsByType returns the jobs of a type, the next to run first
+func FetchScheduledJobsByType(jobType string) []ScheduledJob {
+	return fetchScheduledJobs("SELECT * FROM scheduled_jobs WHERE type = ? ORDER BY run_at ASC, id ASC", jobType)
+}
+
+// FetchDueJobs returns the jobs that should have run at the given time, oldest first
+func FetchDueJobs(now time.Time) []ScheduledJob {
+	return fetchScheduledJobs("SELECT * FROM scheduled_jobs WHERE run_at <= ? ORDER BY run_at ASC, id ASC", now.Unix())
+}
+
+func fetchScheduledJobs(query string, args ...interface{}) []ScheduledJob {
+	if database == nil {
+		return nil
+	}
+	var jobs []ScheduledJob
+	err := database.Select(&jobs, query, args...)
+	if err != nil {
+		core.LogErrorF("Failed to fetch scheduled jobs: %s", err)
+		return nil
+	}
+	return jobs
+}
+
+// FetchNextJobTime returns when the next job is due, and false if there are no jobs
+func FetchNextJobTime() (time.Time, bool) {
+	if database == nil {
+		return time.Time{}, false
+	}
+	var next sql.NullInt64
+	err := database.Get(&next, "SELECT MIN(run_at) FROM scheduled_jobs")
+	if err != nil {
+		core.LogErrorF("Failed to fetch next job time: %s", err)
+		return time.Time{}, false
+	}
+	if !next.Valid {
+		return time.Time{}, false
+	}
+	return time.Unix(next.Int64, 0), true
+}
+
+// RescheduleJob sets the next run of a job, and records the last run and its error if it ran
+func RescheduleJob(id int64, runAt time.Time, lastRun *time.Time, lastError string) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		if lastRun == nil {
+			return tx.Exec("UPDATE scheduled_jobs SET run_at = ? WHERE id = ?", runAt.Unix(), id)
+		}
+		var errorText *string
+		if lastError != "" {
+			errorText = &lastError
+		}
+		return tx.Exec("UPDATE scheduled_jobs SET run_at = ?, last_run = ?, last_error = ? WHERE id = ?",
+			runAt.Unix(), lastRun.Unix(), errorText, id)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to reschedule job %d: %s", id, err)
+		return false
+	}
+	return true
+}
+
+// RetryJob moves a failed one-shot job to its next attempt, recording the failure
+func RetryJob(id int64, runAt, lastRun time.Time, lastError string) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("UPDATE scheduled_jobs SET run_at = ?, last_run = ?, last_error = ?, attempts = attempts + 1 WHERE id = ?",
+			runAt.Unix(), lastRun.Unix(), lastError, id)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to reschedule job %d for a retry: %s", id, err)
+		return false
+	}
+	return true
+}
+
+// DeleteScheduledJob removes a job. Returns false if it didn't exist.
+func DeleteScheduledJob(id int64) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM scheduled_jobs WHERE id = ?", id)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to delete job %d: %s", id, err)
+		return false
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
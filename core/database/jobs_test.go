@@ -0,0 +1,58 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func setupJobTestDB(t *testing.T) func() {
+	cleanup := setupTestDB(t)
+	database.MustExec(scheduledJobSchema)
+	return cleanup
+}
+
+func TestScheduledJobs(t *testing.T) {
+	cleanup := setupJobTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	if _, ok := FetchNextJobTime(); ok {
+		t.Error("Expected no next job time without jobs")
+	}
+	soon, err := CreateScheduledJob(ScheduledJob{Type: "remind", Payload: "hello", RunAt: now.Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Failed to create job: %s", err)
+	}
+	later, _ := CreateScheduledJob(ScheduledJob{Type: "stats", RunAt: now.Add(time.Hour).Unix(), Schedule: "@weekly", Missed: MissedSkip})
+
+	job := FetchScheduledJob(soon)
+	if job == nil || job.Payload != "hello" || job.Missed != MissedCatchUp || job.IsRecurring() {
+		t.Fatalf("Expected a one-shot job catching up by default, got %+v", job)
+	}
+	if due := FetchDueJobs(now); len(due) != 1 || due[0].Id != soon {
+		t.Errorf("Expected only the first job to be due, got %+v", due)
+	}
+	if next, ok := FetchNextJobTime(); !ok || next.Unix() != job.RunAt {
+		t.Errorf("Expected the next job time to be %d, got %d", job.RunAt, next.Unix())
+	}
+	if jobs := FetchScheduledJobsByType("stats"); len(jobs) != 1 || jobs[0].Id != later {
+		t.Errorf("Expected the stats job by type, got %+v", jobs)
+	}
+
+	RescheduleJob(later, now.Add(2*time.Hour), &now, "boom")
+	job = FetchScheduledJob(later)
+	if job.RunAt != now.Add(2*time.Hour).Unix() || job.LastRun == nil || job.LastError == nil || *job.LastError != "boom" {
+		t.Errorf("Expected the job to be rescheduled with the failed run recorded, got %+v", job)
+	}
+	RescheduleJob(later, now.Add(3*time.Hour), &now, "")
+	if job = FetchScheduledJob(later); job.LastError != nil {
+		t.Errorf("Expected a successful run to clear the error, got %s", *job.LastError)
+	}
+
+	if !DeleteScheduledJob(soon) || DeleteScheduledJob(soon) {
+		t.Error("Expected the job to be deleted once")
+	}
+	if jobs := FetchScheduledJobs(); len(jobs) != 1 {
+		t.Errorf("Expected 1 job left, got %d", len(jobs))
+	}
+}
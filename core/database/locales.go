@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+
+	"GoBot/core"
+)
+
+const userLocaleSchema = `
+CREATE TABLE IF NOT EXISTS user_locales (
+	user_id TEXT PRIMARY KEY,
+	locale TEXT NOT NULL
+);
+`
+
+var (
+	userLocaleCache   = map[string]string{}
+	userLocaleCacheMu sync.Mutex
+)
+
+// InitializeUserLocaleTable creates the user_locales table if it doesn't exist
+func InitializeUserLocaleTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize user locale table.")
+		return
+	}
+	_, err := database.Exec(userLocaleSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create user_locales table: %s", err)
+	}
+}
+
+// FetchUserLocale returns the language a user has chosen for replies, or an empty string if they haven't
+func FetchUserLocale(userId string) string {
+	if userId == "" || database == nil {
+		return ""
+	}
+
+	userLocaleCacheMu.Lock()
+	defer userLocaleCacheMu.Unlock()
+	if locale, ok := userLocaleCache[userId]; ok {
+		return locale
+	}
+
+	var locale string
+	err := database.Get(&locale, "SELECT locale FROM user_locales WHERE user_id = ?", userId)
+	if err != nil && err != sql.ErrNoRows {
+		core.LogErrorF("Failed to fetch the locale of user %s: %s", userId, err)
+		return ""
+	}
+	userLocaleCache[userId] = locale
+	return locale
+}
+
+// SetUserLocale stores the language a user has chosen for replies. The locale must already be validated.
+func SetUserLocale(userId, locale string) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(`INSERT INTO user_locales (user_id, locale) VALUES (?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET locale = excluded.locale`, userId, locale)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to set the locale of user %s: %s", userId, err)
+		return false
+	}
+	invalidateUserLocale(userId)
+	return true
+}
+
+// ResetUserLocale removes the language a user has chosen. Returns true if they had chosen one.
+func ResetUserLocale(userId string) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM user_locales WHERE user_id = ?", userId)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to reset the locale of user %s: %s", userId, err)
+		return false
+	}
+	invalidateUserLocale(userId)
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
+
+func invalidateUserLocale(userId string) {
+	userLocaleCacheMu.Lock()
+	defer userLocaleCacheMu.Unlock()
+	delete(userLocaleCache, userId)
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestUserLocale(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(userLocaleSchema)
+	defer func() {
+		userLocaleCache = map[string]string{}
+	}()
+
+	if locale := FetchUserLocale("user1"); locale != "" {
+		t.Errorf("Expected no locale before one is set, got %q", locale)
+	}
+	SetUserLocale("user1", "de")
+	SetUserLocale("user1", "fr")
+	if locale := FetchUserLocale("user1"); locale != "fr" {
+		t.Errorf("Expected locale 'fr', got %q", locale)
+	}
+	if locale := FetchUserLocale("user2"); locale != "" {
+		t.Errorf("Expected user2 not to use the locale of user1, got %q", locale)
+	}
+
+	if !ResetUserLocale("user1") || ResetUserLocale("user1") {
+		t.Error("Expected the locale to be reset once")
+	}
+	if locale := FetchUserLocale("user1"); locale != "" {
+		t.Errorf("Expected no locale after reset, got %q", locale)
+	}
+}
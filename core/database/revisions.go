@@ -0,0 +1,251 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"GoBot/core"
+)
+
+// Every change to a custom command stores the command as it is after the change, numbered per command.
+// A delete stores the command as it was when deleted, so it can be restored.
+const commandRevisionSchema = `
+CREATE TABLE IF NOT EXISTS commandalias_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	command TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	value TEXT,
+	help TEXT,
+	longhelp TEXT,
+	pmenabled INTEGER NOT NULL DEFAULT 0,
+	category TEXT,
+	response TEXT,
+	cooldown INTEGER,
+	cooldown_scope TEXT NOT NULL DEFAULT 'channel',
+	channel_mode TEXT NOT NULL DEFAULT '',
+	channels TEXT NOT NULL DEFAULT '',
+	roles TEXT NOT NULL DEFAULT '',
+	explain_denied INTEGER NOT NULL DEFAULT 0,
+	user_id TEXT NOT NULL DEFAULT '',
+	user_name TEXT NOT NULL DEFAULT '',
+	reverted_from INTEGER,
+	created_at INTEGER NOT NULL,
+	UNIQUE (command, revision)
+);
+`
+
+// RevisionAction is the kind of change a revision records
+type RevisionAction string
+
+const (
+	RevisionBaseline RevisionAction = "baseline" // Commands that existed before revisions were kept
+	RevisionCreate   RevisionAction = "create"
+	RevisionEdit     RevisionAction = "edit"
+	RevisionHelp     RevisionAction = "help"
+	RevisionCategory RevisionAction = "category"
+	RevisionDM       RevisionAction = "dm"
+	RevisionDelete   RevisionAction = "delete"
+	RevisionRevert   RevisionAction = "revert"
+	RevisionImport   RevisionAction = "import"
+	RevisionRules    RevisionAction = "rules" // Cooldown, channels, roles or explaining denied uses
+)
+
+// CommandRevision is a custom command as it was after a change
+type CommandRevision struct {
+	Id           int64
+	Command      string
+	Revision     int64
+	Action       RevisionAction
+	Value        *string
+	Help         *string
+	Longhelp     *string
+	Response     *string
+	PMEnabled    bool    `db:"pmenabled"`
+	Category     *string // Name of the category, as the category may be deleted
+	UserId       string  `db:"user_id"`
+	UserName     string  `db:"user_name"`
+	RevertedFrom *int64  `db:"reverted_from"`
+	CreatedAt    int64   `db:"created_at"`
+	CommandRules
+}
+
+// snapshotCommand inserts the current state of a command as its next revision
+const snapshotCommand = `
+INSERT INTO commandalias_revisions (command, revision, action, value, help, longhelp, pmenabled, category, response, cooldown,
+	cooldown_scope, channel_mode, channels, roles, explain_denied, user_id, user_name, reverted_from, created_at)
+SELECT a.command, (SELECT COALESCE(MAX(r.revision), 0) + 1 FROM commandalias_revisions r WHERE r.command = a.command),
+	?, a.value, a.help, a.longhelp, COALESCE(a.pmenabled, 0), g.command, a.response, a.cooldown, a.cooldown_scope,
+	a.channel_mode, a.channels, a.roles, a.explain_denied, ?, ?, ?, ?
+FROM commandalias a LEFT JOIN commandgroup g ON g.id = a.group_id
+`
+
+// InitializeCommandRevisionTable creates the commandalias_revisions table if it doesn't exist, and stores a
+// baseline revision of commands that don't have any yet
+func InitializeCommandRevisionTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize command revision table.")
+		return
+	}
+	if _, err := database.Exec(commandRevisionSchema); err != nil {
+		core.LogErrorF("Failed to create commandalias_revisions table: %s", err)
+		return
+	}
+	// Migration for existing databases
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN response TEXT")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN cooldown INTEGER")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN cooldown_scope TEXT NOT NULL DEFAULT 'channel'")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN channel_mode TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN channels TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN roles TEXT NOT NULL DEFAULT ''")
+	_, _ = database.Exec("ALTER TABLE commandalias_revisions ADD COLUMN explain_denied INTEGER NOT NULL DEFAULT 0")
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(snapshotCommand+`WHERE NOT EXISTS (SELECT 1 FROM commandalias_revisions r WHERE r.command = a.command)`,
+			RevisionBaseline, "", "", nil, time.Now().Unix())
+	})
+	if err != nil {
+		core.LogErrorF("Failed to store baseline command revisions: %s", err)
+	}
+}
+
+// RecordCommandRevision stores the current state of a command as a new revision. Call it after the change,
+// except for deletes, which are recorded before the command is removed. Returns false if there's no such command.
+func RecordCommandRevision(cmd string, action RevisionAction, userId, userName string) bool {
+	return recordCommandRevision(cmd, action, userId, userName, nil)
+}
+
+func recordCommandRevision(cmd string, action RevisionAction, userId, userName string, revertedFrom *int64) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(snapshotCommand+`WHERE a.command = ?`, action, userId, userName, revertedFrom, time.Now().Unix(), cmd)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to record a revision of command %s: %s", cmd, err)
+		return false
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
+
+// FetchCommandRevision returns a revision of a command, or nil if there's no such revision
+func FetchCommandRevision(cmd string, revision int64) *CommandRevision {
+	if database == nil {
+		return nil
+	}
+	var rev CommandRevision
+	err := database.Get(&rev, "SELECT * FROM commandalias_revisions WHERE command = ? AND revision = ?", cmd, revision)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			core.LogErrorF("Failed to fetch revision %d of command %s: %s", revision, cmd, err)
+		}
+		return nil
+	}
+	return &rev
+}
+
+// FetchLatestCommandRevision returns the latest revision of a command, or nil if it has none
+func FetchLatestCommandRevision(cmd string) *CommandRevision {
+	if database == nil {
+		return nil
+	}
+	var rev CommandRevision
+	err := database.Get(&rev, "SELECT * FROM commandalias_revisions WHERE command = ? ORDER BY revision DESC LIMIT 1", cmd)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			core.LogErrorF("Failed to fetch the latest revision of command %s: %s", cmd, err)
+		}
+		return nil
+	}
+	return &rev
+}
+
+// FetchCommandRevisions returns a page of the revisions of a command, newest first, starting at offset.
+// A negative limit returns all of them.
+func FetchCommandRevisions(cmd string, offset, limit int) []CommandRevision {
+	if database == nil {
+		return nil
+	}
+	var revisions []CommandRevision
+	err := database.Select(&revisions, "SELECT * FROM commandalias_revisions WHERE command = ? ORDER BY revision DESC LIMIT ? OFFSET ?",
+		cmd, limit, offset)
+	if err != nil {
+		core.LogErrorF("Failed to fetch the revisions of command %s: %s", cmd, err)
+		return nil
+	}
+	return revisions
+}
+
+// CountCommandRevisions returns the number of revisions of a command
+func CountCommandRevisions(cmd string) int {
+	return countRows("SELECT COUNT(*) FROM commandalias_revisions WHERE command = ?", cmd)
+}
+
+// RevertCommand restores a command to a revision, including its rules, creating it again if it was deleted and the category if that
+// was deleted, and records the result as a new revision
+func RevertCommand(rev *CommandRevision, userId, userName string) error {
+	var groupId *int
+	if rev.Category != nil {
+		group := FetchOrCreateCommandGroup(*rev.Category)
+		if group == nil {
+			return fmt.Errorf("failed to restore category %s", *rev.Category)
+		}
+		id := int(group.Id)
+		groupId = &id
+	}
+	cmd := CommandAlias{Command: rev.Command, Value: stringValue(rev.Value), Help: rev.Help, Longhelp: rev.Longhelp,
+		PMEnabled: rev.PMEnabled, GroupId: groupId, Response: rev.Response, CommandRules: rev.CommandRules}
+	if err := SaveCommandAlias(cmd); err != nil {
+		return err
+	}
+	recordCommandRevision(rev.Command, RevisionRevert, userId, userName, &rev.Revision)
+	return nil
+}
+
+// CategorizedCommand is a command with the name of its category, which may not exist yet
+type CategorizedCommand struct {
+	CommandAlias
+	Category *string
+}
+
+// SaveCommandAliases creates or replaces the categories and then the commands, recording a revision of every
+// command with action. Either all of them are saved, or none are.
+func SaveCommandAliases(categories []CommandGroup, commands []CategorizedCommand, action RevisionAction, userId, userName string) error {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		for _, category := range categories {
+			_, err := tx.Exec("INSERT INTO commandgroup (command) SELECT ? WHERE NOT EXISTS (SELECT 1 FROM commandgroup WHERE command = ?)",
+				category.Command, category.Command)
+			if err == nil {
+				_, err = tx.Exec("UPDATE commandgroup SET help = ? WHERE command = ?", category.Help, category.Command)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to save category %s: %w", category.Command, err)
+			}
+		}
+		now := time.Now().Unix()
+		for _, cmd := range commands {
+			cmd.GroupId = nil
+			if cmd.Category != nil {
+				var id int
+				if err := tx.QueryRow("SELECT id FROM commandgroup WHERE command = ?", *cmd.Category).Scan(&id); err != nil {
+					return nil, fmt.Errorf("failed to find category %s of command %s: %w", *cmd.Category, cmd.Command, err)
+				}
+				cmd.GroupId = &id
+			}
+			if _, err := saveCommandAlias(tx, cmd.CommandAlias); err != nil {
+				return nil, fmt.Errorf("failed to save command %s: %w", cmd.Command, err)
+			}
+			if _, err := tx.Exec(snapshotCommand+`WHERE a.command = ?`, action, userId, userName, nil, now, cmd.Command); err != nil {
+				return nil, fmt.Errorf("failed to record a revision of command %s: %w", cmd.Command, err)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
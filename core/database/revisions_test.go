@@ -0,0 +1,151 @@
+package database
+
+import "testing"
+
+func TestCommandRevisions(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+	CreateCommandAlias("old", "before revisions")
+	InitializeCommandRevisionTable()
+
+	if rev := FetchLatestCommandRevision("old"); rev == nil || rev.Action != RevisionBaseline || *rev.Value != "before revisions" {
+		t.Errorf("Expected a baseline revision of an existing command, got %+v", rev)
+	}
+
+	CreateCommandAlias("faq", "first")
+	RecordCommandRevision("faq", RevisionCreate, "1", "cmdr")
+	UpdateCommandAlias(CommandField, "faq", ValueField, "second")
+	RecordCommandRevision("faq", RevisionEdit, "1", "cmdr")
+	FetchOrCreateCommandGroup("info")
+	UpdateCommandAlias(CommandField, "faq", GroupIdField, FetchCommandGroup("info").Id)
+	RecordCommandRevision("faq", RevisionCategory, "2", "other")
+	RecordCommandRevision("faq", RevisionDelete, "2", "other")
+	RemoveCommandAlias("faq")
+	RemoveCommandGroup("info")
+
+	if RecordCommandRevision("faq", RevisionEdit, "1", "cmdr") {
+		t.Error("Expected no revision of a removed command")
+	}
+	if count := CountCommandRevisions("faq"); count != 4 {
+		t.Fatalf("Expected 4 revisions, got %d", count)
+	}
+	revisions := FetchCommandRevisions("faq", 0, 2)
+	if len(revisions) != 2 || revisions[0].Revision != 4 || revisions[0].Action != RevisionDelete || revisions[1].UserName != "other" {
+		t.Errorf("Expected the delete and category revisions first, got %+v", revisions)
+	}
+	if rev := FetchCommandRevision("faq", 3); rev == nil || rev.Category == nil || *rev.Category != "info" {
+		t.Errorf("Expected the category name in revision 3, got %+v", rev)
+	}
+
+	// Reverting to the delete restores the command as it was, and its deleted category
+	if err := RevertCommand(FetchCommandRevision("faq", 4), "1", "cmdr"); err != nil {
+		t.Fatalf("Failed to revert: %s", err)
+	}
+	cmd := FetchCommandAlias("faq")
+	if cmd == nil || cmd.Value != "second" || cmd.GroupId == nil || FetchCommandGroup("info") == nil {
+		t.Fatalf("Expected the command restored in its category, got %+v", cmd)
+	}
+	if err := RevertCommand(FetchCommandRevision("faq", 1), "1", "cmdr"); err != nil {
+		t.Fatalf("Failed to revert: %s", err)
+	}
+	if cmd = FetchCommandAlias("faq"); cmd.Value != "first" || cmd.GroupId != nil {
+		t.Errorf("Expected the first revision, got %+v", cmd)
+	}
+	rev := FetchLatestCommandRevision("faq")
+	if rev.Revision != 6 || rev.Action != RevisionRevert || rev.RevertedFrom == nil || *rev.RevertedFrom != 1 {
+		t.Errorf("Expected the revert recorded as revision 6, got %+v", rev)
+	}
+}
+
+func TestCommandRevisions_Response(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+	InitializeCommandRevisionTable()
+
+	response := `{"embed":{"title":"FAQ"}}`
+	SaveCommandAlias(CommandAlias{Command: "faq", Response: &response})
+	RecordCommandRevision("faq", RevisionCreate, "1", "cmdr")
+	UpdateCommandAlias(CommandField, "faq", ResponseField, nil)
+	RecordCommandRevision("faq", RevisionEdit, "1", "cmdr")
+
+	if rev := FetchCommandRevision("faq", 1); rev == nil || rev.Response == nil || *rev.Response != response {
+		t.Fatalf("Expected the response in revision 1, got %+v", rev)
+	}
+	if err := RevertCommand(FetchCommandRevision("faq", 1), "1", "cmdr"); err != nil {
+		t.Fatalf("Failed to revert: %s", err)
+	}
+	if cmd := FetchCommandAlias("faq"); cmd.Response == nil || *cmd.Response != response {
+		t.Errorf("Expected the response restored, got %+v", cmd)
+	}
+}
+
+func TestCommandRevisions_Rules(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+	InitializeCommandRevisionTable()
+
+	seconds := 60
+	SaveCommandAlias(CommandAlias{Command: "faq", Value: "Read the FAQ",
+		CommandRules: CommandRules{Cooldown: &seconds, CooldownScope: CooldownPerUser, Roles: "5", ExplainDenied: true}})
+	RecordCommandRevision("faq", RevisionCreate, "1", "cmdr")
+	SetCommandCooldown("faq", nil, CooldownPerChannel)
+	SetCommandChannels("faq", ChannelsDeny, []string{"7"})
+	RecordCommandRevision("faq", RevisionRules, "1", "cmdr")
+
+	rev := FetchCommandRevision("faq", 1)
+	if rev == nil || rev.Cooldown == nil || *rev.Cooldown != 60 || rev.CooldownScope != CooldownPerUser || rev.Roles != "5" || !rev.ExplainDenied {
+		t.Fatalf("Expected the rules in revision 1, got %+v", rev)
+	}
+	if rev := FetchCommandRevision("faq", 2); rev == nil || rev.Cooldown != nil || rev.ChannelMode != ChannelsDeny || rev.Channels != "7" {
+		t.Fatalf("Expected the changed rules in revision 2, got %+v", rev)
+	}
+	if err := RevertCommand(FetchCommandRevision("faq", 1), "1", "cmdr"); err != nil {
+		t.Fatalf("Failed to revert: %s", err)
+	}
+	cmd := FetchCommandAlias("faq")
+	if cmd.Cooldown == nil || *cmd.Cooldown != 60 || cmd.CooldownScope != CooldownPerUser || cmd.ChannelMode != "" || cmd.Channels != "" {
+		t.Errorf("Expected the rules of revision 1 restored, got %+v", cmd.CommandRules)
+	}
+}
+
+func TestSaveCommandAliases(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+	database.MustExec(schema)
+	InitializeCommandRevisionTable()
+
+	help := "About us"
+	info := "info"
+	err := SaveCommandAliases([]CommandGroup{{Command: info, Help: &help}},
+		[]CategorizedCommand{{CommandAlias: CommandAlias{Command: "faq", Value: "Read the FAQ"}, Category: &info}},
+		RevisionImport, "1", "cmdr")
+	if err != nil {
+		t.Fatalf("Failed to save: %s", err)
+	}
+	group := FetchCommandGroup(info)
+	if group == nil || *group.Help != help {
+		t.Fatalf("Expected the category with its help, got %+v", group)
+	}
+	if cmd := FetchCommandAlias("faq"); cmd == nil || cmd.GroupId == nil || int64(*cmd.GroupId) != group.Id {
+		t.Fatalf("Expected the command in the category, got %+v", cmd)
+	}
+	if rev := FetchLatestCommandRevision("faq"); rev == nil || rev.Action != RevisionImport || rev.UserName != "cmdr" {
+		t.Errorf("Expected an import revision, got %+v", rev)
+	}
+
+	// A command that fails leaves the ones before it unsaved
+	missing := "missing"
+	err = SaveCommandAliases(nil, []CategorizedCommand{
+		{CommandAlias: CommandAlias{Command: "faq", Value: "Changed"}},
+		{CommandAlias: CommandAlias{Command: "hi", Value: "Hello"}, Category: &missing},
+	}, RevisionImport, "1", "cmdr")
+	if err == nil {
+		t.Fatal("Expected saving a command in a missing category to fail")
+	}
+	if cmd := FetchCommandAlias("faq"); cmd.Value != "Read the FAQ" || CountCommandRevisions("faq") != 1 {
+		t.Errorf("Expected the first command and its revisions unchanged, got %+v", cmd)
+	}
+}
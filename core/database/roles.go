@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+
+	"GoBot/core"
+)
+
+// GuildRole maps a Discord guild role to a bot permission level
+type GuildRole struct {
+	Id      int64
+	Role    core.PermissionLevel
+	GuildId string `db:"guild_id"`
+	RoleId  string `db:"role_id"`
+}
+
+const guildRoleSchema = `
+CREATE TABLE IF NOT EXISTS guildrole (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	role INTEGER NOT NULL,
+	guild_id TEXT NOT NULL,
+	role_id TEXT NOT NULL,
+	UNIQUE (guild_id, role_id)
+);
+`
+
+// InitializeRoleTables creates the guild role mapping table if it doesn't exist
+func InitializeRoleTables() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize role tables.")
+		return
+	}
+	_, err := database.Exec(guildRoleSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create guildrole table: %s", err)
+	}
+}
+
+// FetchUserRole returns the permission level granted to a user, PermissionUser if none
+func FetchUserRole(userId string) core.PermissionLevel {
+	if database == nil {
+		return core.PermissionUser
+	}
+	var role sql.NullInt64
+	err := database.Get(&role, "SELECT MAX(role) FROM userrole WHERE user_id = ?", userId)
+	if err != nil {
+		core.LogErrorF("Failed to fetch role for user %s: %s", userId, err)
+		return core.PermissionUser
+	}
+	return core.PermissionLevel(role.Int64)
+}
+
+// FetchUserRoles returns all granted user roles, highest first
+func FetchUserRoles() []UserRole {
+	if database == nil {
+		return nil
+	}
+	var roles []UserRole
+	err := database.Select(&roles, "SELECT * FROM userrole ORDER BY role DESC, user_id ASC")
+	if err != nil {
+		core.LogErrorF("Failed to fetch user roles: %s", err)
+		return nil
+	}
+	return roles
+}
+
+// SetUserRole grants a permission level to a user, replacing any previous grant
+func SetUserRole(userId string, role core.PermissionLevel) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		if _, err := tx.Exec("DELETE FROM userrole WHERE user_id = ?", userId); err != nil {
+			return nil, err
+		}
+		return tx.Exec("INSERT INTO userrole (role, user_id) VALUES (?, ?)", role, userId)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to set role for user %s: %s", userId, err)
+		return false
+	}
+	return true
+}
+
+// RemoveUserRole revokes any granted permission level from a user. Returns true if one was removed.
+func RemoveUserRole(userId string) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM userrole WHERE user_id = ?", userId)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to remove role for user %s: %s", userId, err)
+		return false
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
+
+// FetchGuildRoles returns the Discord role mappings for a guild
+func FetchGuildRoles(guildId string) []GuildRole {
+	if database == nil {
+		return nil
+	}
+	var roles []GuildRole
+	err := database.Select(&roles, "SELECT * FROM guildrole WHERE guild_id = ? ORDER BY role DESC", guildId)
+	if err != nil {
+		core.LogErrorF("Failed to fetch guild roles for %s: %s", guildId, err)
+		return nil
+	}
+	return roles
+}
+
+// SetGuildRole maps a Discord role in a guild to a permission level
+func SetGuildRole(guildId, roleId string, role core.PermissionLevel) bool {
+	_, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec(`INSERT INTO guildrole (role, guild_id, role_id) VALUES (?, ?, ?)
+			ON CONFLICT(guild_id, role_id) DO UPDATE SET role = excluded.role`, role, guildId, roleId)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to map role %s in guild %s: %s", roleId, guildId, err)
+		return false
+	}
+	return true
+}
+
+// RemoveGuildRole removes a Discord role mapping. Returns true if one was removed.
+func RemoveGuildRole(guildId, roleId string) bool {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM guildrole WHERE guild_id = ? AND role_id = ?", guildId, roleId)
+	})
+	if err != nil {
+		core.LogErrorF("Failed to remove role mapping %s in guild %s: %s", roleId, guildId, err)
+		return false
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+
+	"GoBot/core"
+)
+
+func setupRoleTestDB(t *testing.T) func() {
+	cleanup := setupTestDB(t)
+	database.MustExec(schema)
+	database.MustExec(guildRoleSchema)
+	return cleanup
+}
+
+func TestUserRoles(t *testing.T) {
+	cleanup := setupRoleTestDB(t)
+	defer cleanup()
+
+	if role := FetchUserRole("user1"); role != core.PermissionUser {
+		t.Errorf("Expected user level for unknown user, got %s", role)
+	}
+
+	SetUserRole("user1", core.PermissionCarrierManager)
+	SetUserRole("user1", core.PermissionAdmin)
+	if role := FetchUserRole("user1"); role != core.PermissionAdmin {
+		t.Errorf("Expected admin, got %s", role)
+	}
+	if roles := FetchUserRoles(); len(roles) != 1 {
+		t.Errorf("Expected grant to replace the previous one, got %d roles", len(roles))
+	}
+
+	if !RemoveUserRole("user1") {
+		t.Error("Expected revoke to remove the role")
+	}
+	if RemoveUserRole("user1") {
+		t.Error("Expected second revoke to remove nothing")
+	}
+	if role := FetchUserRole("user1"); role != core.PermissionUser {
+		t.Errorf("Expected user level after revoke, got %s", role)
+	}
+}
+
+func TestGuildRoles(t *testing.T) {
+	cleanup := setupRoleTestDB(t)
+	defer cleanup()
+
+	SetGuildRole("guild1", "role1", core.PermissionCarrierManager)
+	SetGuildRole("guild1", "role1", core.PermissionAdmin)
+	SetGuildRole("guild2", "role2", core.PermissionCarrierManager)
+
+	roles := FetchGuildRoles("guild1")
+	if len(roles) != 1 {
+		t.Fatalf("Expected 1 role mapping for guild1, got %d", len(roles))
+	}
+	if roles[0].RoleId != "role1" || roles[0].Role != core.PermissionAdmin {
+		t.Errorf("Expected role1 mapped to admin, got %s mapped to %s", roles[0].RoleId, roles[0].Role)
+	}
+
+	if !RemoveGuildRole("guild1", "role1") {
+		t.Error("Expected mapping to be removed")
+	}
+	if len(FetchGuildRoles("guild1")) != 0 {
+		t.Error("Expected no role mappings for guild1 after removal")
+	}
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"GoBot/core"
+)
+
+// CommandUsage is a record of a dispatched command
+type CommandUsage struct {
+	Id        int64
+	Command   string
+	UserId    string `db:"user_id"`
+	ChannelId string `db:"channel_id"`
+	GuildId   string `db:"guild_id"`
+	LatencyMs int64  `db:"latency_ms"`
+	Success   bool
+	Slash     bool
+	CreatedAt int64 `db:"created_at"` // Unix time
+}
+
+// UsageCount is the number of uses of a command, or by a user
+type UsageCount struct {
+	Name         string
+	Uses         int64
+	Failures     int64
+	AvgLatencyMs float64 `db:"avg_latency_ms"`
+}
+
+// FailureRate returns the share of uses that failed, 0-1
+func (u UsageCount) FailureRate() float64 {
+	if u.Uses == 0 {
+		return 0
+	}
+	return float64(u.Failures) / float64(u.Uses)
+}
+
+// WeeklyUsage is the usage during a week
+type WeeklyUsage struct {
+	Week     string // Year and week number, e.g. 2026-41
+	Uses     int64
+	Failures int64
+	Users    int64
+}
+
+const commandUsageSchema = `
+CREATE TABLE IF NOT EXISTS command_usage (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	command TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	channel_id TEXT NOT NULL,
+	guild_id TEXT NOT NULL,
+	latency_ms INTEGER NOT NULL,
+	success INTEGER NOT NULL,
+	slash INTEGER NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS command_usage_created_index ON command_usage (created_at);
+CREATE INDEX IF NOT EXISTS command_usage_guild_index ON command_usage (guild_id, created_at);
+`
+
+// Queued records are written when this many are pending
+const usageBatchSize = 20
+
+var (
+	pendingUsage   []CommandUsage
+	pendingUsageMu sync.Mutex
+)
+
+// InitializeUsageTable creates the command_usage table if it doesn't exist
+func InitializeUsageTable() {
+	if database == nil {
+		core.LogError("Database isn't open. Cannot initialize usage table.")
+		return
+	}
+	_, err := database.Exec(commandUsageSchema)
+	if err != nil {
+		core.LogErrorF("Failed to create command_usage table: %s", err)
+	}
+}
+
+// RecordCommandUsage stores a dispatched command
+func RecordCommandUsage(usage CommandUsage) bool {
+	return writeCommandUsage([]CommandUsage{usage})
+}
+
+// QueueCommandUsage queues a dispatched command to be stored with the next batch. Batches are written when
+// full, before usage is fetched and when the database is closed.
+func QueueCommandUsage(usage CommandUsage) bool {
+	if database == nil {
+		return false
+	}
+	pendingUsageMu.Lock()
+	pendingUsage = append(pendingUsage, usage)
+	full := len(pendingUsage) >= usageBatchSize
+	pendingUsageMu.Unlock()
+	if full {
+		FlushCommandUsage()
+	}
+	return true
+}
+
+// FlushCommandUsage writes the queued usage records. Returns the number of records written.
+func FlushCommandUsage() int {
+	pendingUsageMu.Lock()
+	pending := pendingUsage
+	pendingUsage = nil
+	pendingUsageMu.Unlock()
+	if len(pending) == 0 || !writeCommandUsage(pending) {
+		return 0
+	}
+	return len(pending)
+}
+
+func writeCommandUsage(usages []CommandUsage) bool {
+	if database == nil {
+		return false
+	}
+	_, err := executeAndCommit(func(tx *sql.Tx) (res sql.Result, err error) {
+		for _, usage := range usages {
+			res, err = tx.Exec(`INSERT INTO command_usage (command, user_id, channel_id, guild_id, latency_ms, success, slash, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, usage.Command, usage.UserId, usage.ChannelId, usage.GuildId, usage.LatencyMs,
+				usage.Success, usage.Slash, usage.CreatedAt)
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		core.LogErrorF("Failed to record usage of %d commands: %s", len(usages), err)
+		return false
+	}
+	return true
+}
+
+// PruneCommandUsage removes records older than the given time. Returns the number of records removed.
+func PruneCommandUsage(before time.Time) int64 {
+	res, err := executeAndCommit(func(tx *sql.Tx) (sql.Result, error) {
+		return tx.Exec("DELETE FROM command_usage WHERE created_at < ?", before.Unix())
+	})
+	if err != nil {
+		core.LogErrorF("Failed to prune command usage: %s", err)
+		return 0
+	}
+	rows, _ := res.RowsAffected()
+	return rows
+}
+
+// FetchTopCommands returns the most used commands since the given time. An empty guild ID includes all guilds and DMs.
+func FetchTopCommands(guildId string, since time.Time, limit int) []UsageCount {
+	return fetchUsageCounts("command", guildId, since, "uses DESC, name ASC", limit)
+}
+
+// FetchTopUsers returns the users who used the most commands since the given time, with the user ID as name
+func FetchTopUsers(guildId string, since time.Time, limit int) []UsageCount {
+	return fetchUsageCounts("user_id", guildId, since, "uses DESC, name ASC", limit)
+}
+
+// FetchFailingCommands returns the commands with the highest failure rate since the given time,
+// leaving out commands with fewer than minUses uses
+func FetchFailingCommands(guildId string, since time.Time, minUses, limit int) []UsageCount {
+	var failing []UsageCount
+	for _, count := range fetchUsageCounts("command", guildId, since, "CAST(failures AS REAL) / uses DESC, uses DESC", -1) {
+		if count.Uses >= int64(minUses) && count.Failures > 0 && len(failing) < limit {
+			failing = append(failing, count)
+		}
+	}
+	return failing
+}
+
+func fetchUsageCounts(column, guildId string, since time.Time, order string, limit int) []UsageCount {
+	if database == nil {
+		return nil
+	}
+	FlushCommandUsage()
+	var counts []UsageCount
+	err := database.Select(&counts, `SELECT `+column+` AS name, COUNT(*) AS uses, SUM(1 - success) AS failures,
+			AVG(latency_ms) AS avg_latency_ms
+		FROM command_usage WHERE created_at >= ? AND (? = '' OR guild_id = ?)
+		GROUP BY `+column+` ORDER BY `+order+` LIMIT ?`, since.Unix(), guildId, guildId, limit)
+	if err != nil {
+		core.LogErrorF("Failed to fetch command usage by %s: %s", column, err)
+		return nil
+	}
+	return counts
+}
+
+// FetchWeeklyUsage returns the usage per week for the given number of weeks, oldest first
+func FetchWeeklyUsage(guildId string, weeks int, now time.Time) []WeeklyUsage {
+	if database == nil {
+		return nil
+	}
+	FlushCommandUsage()
+	var usage []WeeklyUsage
+	err := database.Select(&usage, `SELECT strftime('%Y-%W', created_at, 'unixepoch') AS week, COUNT(*) AS uses,
+			SUM(1 - success) AS failures, COUNT(DISTINCT user_id) AS users
+		FROM command_usage WHERE created_at >= ? AND (? = '' OR guild_id = ?)
+		GROUP BY week ORDER BY week ASC`, now.AddDate(0, 0, -7*weeks).Unix(), guildId, guildId)
+	if err != nil {
+		core.LogErrorF("Failed to fetch weekly command usage: %s", err)
+		return nil
+	}
+	return usage
+}
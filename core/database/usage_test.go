@@ -0,0 +1,109 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func setupUsageTestDB(t *testing.T) func() {
+	cleanup := setupTestDB(t)
+	database.MustExec(commandUsageSchema)
+	return cleanup
+}
+
+func recordUsage(t *testing.T, command, userId, guildId string, success bool, at time.Time) {
+	usage := CommandUsage{Command: command, UserId: userId, ChannelId: "channel1", GuildId: guildId, LatencyMs: 10,
+		Success: success, CreatedAt: at.Unix()}
+	if !RecordCommandUsage(usage) {
+		t.Fatalf("Failed to record usage of %s", command)
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	cleanup := setupUsageTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	recordUsage(t, "dist", "user1", "guild1", true, now)
+	recordUsage(t, "dist", "user2", "guild1", false, now)
+	recordUsage(t, "dist", "user1", "guild1", true, now)
+	recordUsage(t, "ping", "user1", "guild1", true, now)
+	recordUsage(t, "ping", "user3", "guild2", true, now)
+
+	commands := FetchTopCommands("guild1", now.Add(-time.Hour), 10)
+	if len(commands) != 2 || commands[0].Name != "dist" || commands[0].Uses != 3 || commands[0].Failures != 1 {
+		t.Fatalf("Expected dist with 3 uses and 1 failure first, got %+v", commands)
+	}
+	if all := FetchTopCommands("", now.Add(-time.Hour), 10); all[1].Name != "ping" || all[1].Uses != 2 {
+		t.Errorf("Expected ping with 2 uses across all guilds, got %+v", all)
+	}
+
+	users := FetchTopUsers("guild1", now.Add(-time.Hour), 1)
+	if len(users) != 1 || users[0].Name != "user1" || users[0].Uses != 3 {
+		t.Errorf("Expected user1 with 3 uses, got %+v", users)
+	}
+
+	failing := FetchFailingCommands("guild1", now.Add(-time.Hour), 2, 10)
+	if len(failing) != 1 || failing[0].Name != "dist" {
+		t.Errorf("Expected only dist to be failing, got %+v", failing)
+	}
+	if failing := FetchFailingCommands("guild1", now.Add(-time.Hour), 4, 10); len(failing) != 0 {
+		t.Errorf("Expected commands with few uses to be left out, got %+v", failing)
+	}
+}
+
+func TestWeeklyUsage(t *testing.T) {
+	cleanup := setupUsageTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	recordUsage(t, "dist", "user1", "guild1", true, now.AddDate(0, 0, -7))
+	recordUsage(t, "dist", "user1", "guild1", true, now)
+	recordUsage(t, "dist", "user2", "guild1", false, now)
+	recordUsage(t, "dist", "user2", "guild1", true, now.AddDate(0, 0, -70))
+
+	weeks := FetchWeeklyUsage("guild1", 4, now)
+	if len(weeks) != 2 {
+		t.Fatalf("Expected 2 weeks, got %+v", weeks)
+	}
+	if last := weeks[1]; last.Uses != 2 || last.Users != 2 || last.Failures != 1 {
+		t.Errorf("Expected 2 uses by 2 users with 1 failure this week, got %+v", last)
+	}
+}
+
+func TestPruneCommandUsage(t *testing.T) {
+	cleanup := setupUsageTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	recordUsage(t, "dist", "user1", "guild1", true, now.AddDate(0, 0, -100))
+	recordUsage(t, "dist", "user1", "guild1", true, now)
+
+	if removed := PruneCommandUsage(now.AddDate(0, 0, -90)); removed != 1 {
+		t.Errorf("Expected 1 record removed, got %d", removed)
+	}
+	if commands := FetchTopCommands("", time.Time{}, 10); len(commands) != 1 || commands[0].Uses != 1 {
+		t.Errorf("Expected 1 use left, got %+v", commands)
+	}
+}
+
+func TestQueueCommandUsage(t *testing.T) {
+	cleanup := setupUsageTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i := 0; i < usageBatchSize+1; i++ {
+		QueueCommandUsage(CommandUsage{Command: "dist", UserId: "user1", Success: true, CreatedAt: now.Unix()})
+	}
+	var stored int
+	database.Get(&stored, "SELECT COUNT(*) FROM command_usage")
+	if stored != usageBatchSize {
+		t.Errorf("Expected a full batch to be written, got %d records", stored)
+	}
+	if flushed := FlushCommandUsage(); flushed != 1 {
+		t.Errorf("Expected 1 pending record to be flushed, got %d", flushed)
+	}
+	if flushed := FlushCommandUsage(); flushed != 0 {
+		t.Errorf("Expected nothing left to flush, got %d", flushed)
+	}
+}
@@ -0,0 +1,242 @@
+package discord
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ConsoleBotUserID is the user ID of the bot in console mode
+const ConsoleBotUserID = "console-bot"
+
+// Console is a Session that prints what the bot sends instead of connecting to Discord
+type Console struct {
+	mu       sync.Mutex
+	out      io.Writer
+	nextID   int
+	channels map[string]*discordgo.Channel
+}
+
+// NewConsole returns a console session printing to out
+func NewConsole(out io.Writer) *Console {
+	return &Console{out: out, channels: map[string]*discordgo.Channel{}}
+}
+
+// AddChannel makes a channel known to the console
+func (c *Console) AddChannel(channel *discordgo.Channel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channels[channel.ID] = channel
+}
+
+func (c *Console) BotUserID() string {
+	return ConsoleBotUserID
+}
+
+func (c *Console) Channel(channelID string) (*discordgo.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if channel, ok := c.channels[channelID]; ok {
+		return channel, nil
+	}
+	return nil, fmt.Errorf("unknown channel %s", channelID)
+}
+
+// Member returns a member without roles, as there are no Discord roles in console mode
+func (c *Console) Member(guildID, userID string) (*discordgo.Member, error) {
+	return &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}}, nil
+}
+
+func (c *Console) ChannelMessages(string, int) ([]*discordgo.Message, error) {
+	return nil, nil
+}
+
+func (c *Console) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.print(channelID, "", data.Content, data.Embeds, data.Files, data.Components), nil
+}
+
+func (c *Console) ChannelMessageEditComplex(edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.printEdit(edit.Channel, "edited "+edit.ID, edit.Content, edit.Embeds, edit.Components)
+	return &discordgo.Message{ID: edit.ID, ChannelID: edit.Channel}, nil
+}
+
+func (c *Console) ChannelMessageDelete(channelID, messageID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, "[%s] (deleted %s)\n", c.channelLabel(channelID), messageID)
+	return nil
+}
+
+func (c *Console) UserChannelCreate(userID string) (*discordgo.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	channel := &discordgo.Channel{ID: DMChannelID(userID), Type: discordgo.ChannelTypeDM,
+		Recipients: []*discordgo.User{{ID: userID}}}
+	c.channels[channel.ID] = channel
+	return channel, nil
+}
+
+func (c *Console) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := response.Data
+	switch response.Type {
+	case discordgo.InteractionResponseDeferredChannelMessageWithSource:
+		fmt.Fprintf(c.out, "[%s] (thinking...)\n", c.channelLabel(interaction.ChannelID))
+	case discordgo.InteractionResponseDeferredMessageUpdate:
+	case discordgo.InteractionResponseUpdateMessage:
+		if data != nil {
+			c.printEdit(interaction.ChannelID, "updated "+interactionMessageID(interaction), &data.Content, &data.Embeds, &data.Components)
+		}
+	default:
+		if data != nil {
+			c.print(interaction.ChannelID, interactionNote(data.Flags), data.Content, data.Embeds, data.Files, data.Components)
+		}
+	}
+	return nil
+}
+
+func (c *Console) FollowupMessageCreate(interaction *discordgo.Interaction, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.print(interaction.ChannelID, interactionNote(data.Flags), data.Content, data.Embeds, data.Files, data.Components), nil
+}
+
+func (c *Console) InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.printEdit(interaction.ChannelID, "edited "+interactionMessageID(interaction), edit.Content, edit.Embeds, edit.Components)
+	return &discordgo.Message{ChannelID: interaction.ChannelID}, nil
+}
+
+func (c *Console) InteractionResponseDelete(interaction *discordgo.Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, "[%s] (deleted %s)\n", c.channelLabel(interaction.ChannelID), interactionMessageID(interaction))
+	return nil
+}
+
+// interactionMessageID returns the ID of the message a component interaction came from, or "response" for the
+// response to a command
+func interactionMessageID(interaction *discordgo.Interaction) string {
+	if interaction.Message != nil {
+		return interaction.Message.ID
+	}
+	return "response"
+}
+
+func interactionNote(flags discordgo.MessageFlags) string {
+	if flags&discordgo.MessageFlagsEphemeral != 0 {
+		return "only visible to you"
+	}
+	return ""
+}
+
+// printEdit prints the changed parts of a message
+func (c *Console) printEdit(channelID, note string, content *string, embeds *[]*discordgo.MessageEmbed, components *[]discordgo.MessageComponent) {
+	var text string
+	var embedList []*discordgo.MessageEmbed
+	var componentList []discordgo.MessageComponent
+	if content != nil {
+		text = *content
+	}
+	if embeds != nil {
+		embedList = *embeds
+	}
+	if components != nil {
+		componentList = *components
+	}
+	c.print(channelID, note, text, embedList, nil, componentList)
+}
+
+func (c *Console) print(channelID, note, content string, embeds []*discordgo.MessageEmbed, files []*discordgo.File,
+	components []discordgo.MessageComponent) *discordgo.Message {
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+
+	header := fmt.Sprintf("[%s] #%s", c.channelLabel(channelID), id)
+	if note != "" {
+		header += " (" + note + ")"
+	}
+	lines := []string{header}
+	if content != "" {
+		lines = append(lines, content)
+	}
+	for _, embed := range embeds {
+		lines = append(lines, formatEmbed(embed)...)
+	}
+	for _, file := range files {
+		lines = append(lines, "[file: "+file.Name+"]")
+	}
+	lines = append(lines, formatComponents(components)...)
+	fmt.Fprintln(c.out, strings.Join(lines, "\n"))
+	return &discordgo.Message{ID: id, ChannelID: channelID, Content: content, Embeds: embeds}
+}
+
+func (c *Console) channelLabel(channelID string) string {
+	channel := c.channels[channelID]
+	switch {
+	case channel == nil:
+		return channelID
+	case channel.Type == discordgo.ChannelTypeDM:
+		return "DM"
+	case channel.Name != "":
+		return "#" + channel.Name
+	default:
+		return "#" + channel.ID
+	}
+}
+
+func formatEmbed(embed *discordgo.MessageEmbed) []string {
+	var lines []string
+	if embed.Title != "" {
+		lines = append(lines, "| **"+embed.Title+"**")
+	}
+	for _, line := range strings.Split(embed.Description, "\n") {
+		if line != "" {
+			lines = append(lines, "| "+line)
+		}
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, "| "+field.Name+": "+strings.ReplaceAll(field.Value, "\n", "\n|   "))
+	}
+	if embed.Footer != nil && embed.Footer.Text != "" {
+		lines = append(lines, "| "+embed.Footer.Text)
+	}
+	return lines
+}
+
+// formatComponents prints buttons as [label](custom ID) and select menus as {placeholder: label=value, ...}(custom ID),
+// one line per row
+func formatComponents(components []discordgo.MessageComponent) []string {
+	var lines []string
+	for _, component := range components {
+		row, ok := component.(discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		var items []string
+		for _, item := range row.Components {
+			switch item := item.(type) {
+			case discordgo.Button:
+				items = append(items, fmt.Sprintf("[%s](%s)", item.Label, item.CustomID))
+			case discordgo.SelectMenu:
+				var options []string
+				for _, option := range item.Options {
+					options = append(options, option.Label+"="+option.Value)
+				}
+				items = append(items, fmt.Sprintf("{%s: %s}(%s)", item.Placeholder, strings.Join(options, ", "), item.CustomID))
+			}
+		}
+		lines = append(lines, strings.Join(items, " "))
+	}
+	return lines
+}
@@ -0,0 +1,301 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Fake is an in-memory Session for tests. It records every message the bot sends, edits and deletes,
+// including interaction responses, so tests can assert on what the bot would post.
+type Fake struct {
+	mu         sync.Mutex
+	botUserID  string
+	nextID     int
+	channels   map[string]*discordgo.Channel
+	members    map[string]*discordgo.Member // By guild and user ID
+	messages   []*discordgo.Message         // Sent by the bot, in order, with edits applied
+	deleted    []*discordgo.Message
+	log        []*discordgo.Message // All messages in all channels, oldest first
+	dmDisabled map[string]bool
+	responses  map[string]*discordgo.Message // Message of the response to each interaction, by interaction ID
+	// Flags of deferred responses not filled in yet, by interaction ID. Like Discord, the first followup fills
+	// in the deferred response with these flags, whatever its own.
+	deferred map[string]discordgo.MessageFlags
+}
+
+// NewFake returns a fake session for a bot with the given user ID
+func NewFake(botUserID string) *Fake {
+	return &Fake{
+		botUserID:  botUserID,
+		channels:   map[string]*discordgo.Channel{},
+		members:    map[string]*discordgo.Member{},
+		dmDisabled: map[string]bool{},
+		responses:  map[string]*discordgo.Message{},
+		deferred:   map[string]discordgo.MessageFlags{},
+	}
+}
+
+// AddChannel makes a channel known to the fake
+func (f *Fake) AddChannel(channel *discordgo.Channel) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels[channel.ID] = channel
+}
+
+// AddMember makes a guild member known to the fake
+func (f *Fake) AddMember(guildID string, member *discordgo.Member) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[guildID+":"+member.User.ID] = member
+}
+
+// AddHistory adds a message by someone else to a channel, returned by ChannelMessages
+func (f *Fake) AddHistory(message *discordgo.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, message)
+}
+
+// DisableDMs makes DMs to a user fail, like when the user doesn't allow DMs from server members
+func (f *Fake) DisableDMs(userID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dmDisabled[userID] = true
+}
+
+// Sent returns the messages the bot has sent and not deleted, in order, with edits applied
+func (f *Fake) Sent() []*discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*discordgo.Message(nil), f.messages...)
+}
+
+// SentTo returns the messages the bot has sent to a channel and not deleted
+func (f *Fake) SentTo(channelID string) []*discordgo.Message {
+	var sent []*discordgo.Message
+	for _, message := range f.Sent() {
+		if message.ChannelID == channelID {
+			sent = append(sent, message)
+		}
+	}
+	return sent
+}
+
+// Deleted returns the messages the bot has deleted
+func (f *Fake) Deleted() []*discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*discordgo.Message(nil), f.deleted...)
+}
+
+// DMChannelID returns the ID of the DM channel the fake uses for a user
+func DMChannelID(userID string) string {
+	return "dm-" + userID
+}
+
+func (f *Fake) BotUserID() string {
+	return f.botUserID
+}
+
+func (f *Fake) Channel(channelID string) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if channel, ok := f.channels[channelID]; ok {
+		return channel, nil
+	}
+	return nil, fmt.Errorf("unknown channel %s", channelID)
+}
+
+func (f *Fake) Member(guildID, userID string) (*discordgo.Member, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if member, ok := f.members[guildID+":"+userID]; ok {
+		return member, nil
+	}
+	return nil, fmt.Errorf("unknown member %s in guild %s", userID, guildID)
+}
+
+func (f *Fake) ChannelMessages(channelID string, limit int) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var messages []*discordgo.Message
+	for i := len(f.log) - 1; i >= 0 && len(messages) < limit; i-- {
+		if f.log[i].ChannelID == channelID {
+			messages = append(messages, f.log[i])
+		}
+	}
+	return messages, nil
+}
+
+func (f *Fake) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.channels[channelID]; !ok {
+		return nil, fmt.Errorf("unknown channel %s", channelID)
+	}
+	message := f.record(channelID, data.Content, data.Embeds, data.Files, 0)
+	message.Components = data.Components
+	return message, nil
+}
+
+func (f *Fake) ChannelMessageEditComplex(edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, message := range f.messages {
+		if message.ID == edit.ID && message.ChannelID == edit.Channel {
+			applyEdit(message, edit.Content, edit.Embeds, edit.Components)
+			return message, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown message %s", edit.ID)
+}
+
+func applyEdit(message *discordgo.Message, content *string, embeds *[]*discordgo.MessageEmbed, components *[]discordgo.MessageComponent) {
+	if content != nil {
+		message.Content = *content
+	}
+	if embeds != nil {
+		message.Embeds = *embeds
+	}
+	if components != nil {
+		message.Components = *components
+	}
+	edited := time.Now()
+	message.EditedTimestamp = &edited
+}
+
+// sentMessage returns a message the bot has sent, or nil
+func (f *Fake) sentMessage(messageID string) *discordgo.Message {
+	for _, message := range f.messages {
+		if message.ID == messageID {
+			return message
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ChannelMessageDelete(channelID, messageID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, message := range f.messages {
+		if message.ID == messageID && message.ChannelID == channelID {
+			f.deleted = append(f.deleted, message)
+			f.messages = append(f.messages[:i], f.messages[i+1:]...)
+			for j, logged := range f.log {
+				if logged == message {
+					f.log = append(f.log[:j], f.log[j+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown message %s", messageID)
+}
+
+func (f *Fake) UserChannelCreate(userID string) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dmDisabled[userID] {
+		return nil, fmt.Errorf("cannot send messages to user %s", userID)
+	}
+	channel := &discordgo.Channel{ID: DMChannelID(userID), Type: discordgo.ChannelTypeDM,
+		Recipients: []*discordgo.User{{ID: userID}}}
+	f.channels[channel.ID] = channel
+	return channel, nil
+}
+
+func (f *Fake) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data := response.Data
+	switch response.Type {
+	case discordgo.InteractionResponseUpdateMessage, discordgo.InteractionResponseDeferredMessageUpdate:
+		if interaction.Message == nil || f.sentMessage(interaction.Message.ID) == nil {
+			return fmt.Errorf("unknown message of interaction %s", interaction.ID)
+		}
+		message := f.sentMessage(interaction.Message.ID)
+		if data != nil && response.Type == discordgo.InteractionResponseUpdateMessage {
+			applyEdit(message, &data.Content, &data.Embeds, &data.Components)
+		}
+		f.responses[interaction.ID] = message
+	case discordgo.InteractionResponseDeferredChannelMessageWithSource:
+		var flags discordgo.MessageFlags
+		if data != nil {
+			flags = data.Flags
+		}
+		f.deferred[interaction.ID] = flags
+	default:
+		if data != nil {
+			message := f.record(interaction.ChannelID, data.Content, data.Embeds, data.Files, data.Flags)
+			message.Components = data.Components
+			f.responses[interaction.ID] = message
+		}
+	}
+	return nil
+}
+
+func (f *Fake) FollowupMessageCreate(interaction *discordgo.Interaction, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	flags, deferred := f.deferred[interaction.ID]
+	if !deferred {
+		flags = data.Flags
+	}
+	message := f.record(interaction.ChannelID, data.Content, data.Embeds, data.Files, flags)
+	message.Components = data.Components
+	if deferred {
+		delete(f.deferred, interaction.ID)
+		f.responses[interaction.ID] = message
+	}
+	return message, nil
+}
+
+func (f *Fake) InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message := f.responses[interaction.ID]
+	if message == nil {
+		return nil, fmt.Errorf("no response to interaction %s", interaction.ID)
+	}
+	applyEdit(message, edit.Content, edit.Embeds, edit.Components)
+	return message, nil
+}
+
+func (f *Fake) InteractionResponseDelete(interaction *discordgo.Interaction) error {
+	f.mu.Lock()
+	if _, deferred := f.deferred[interaction.ID]; deferred {
+		delete(f.deferred, interaction.ID)
+		f.mu.Unlock()
+		return nil
+	}
+	message := f.responses[interaction.ID]
+	delete(f.responses, interaction.ID)
+	f.mu.Unlock()
+	if message == nil {
+		return fmt.Errorf("no response to interaction %s", interaction.ID)
+	}
+	return f.ChannelMessageDelete(message.ChannelID, message.ID)
+}
+
+func (f *Fake) record(channelID, content string, embeds []*discordgo.MessageEmbed, files []*discordgo.File, flags discordgo.MessageFlags) *discordgo.Message {
+	f.nextID++
+	message := &discordgo.Message{
+		ID:        strconv.Itoa(f.nextID),
+		ChannelID: channelID,
+		Content:   content,
+		Embeds:    embeds,
+		Flags:     flags,
+		Author:    &discordgo.User{ID: f.botUserID, Bot: true},
+	}
+	for _, file := range files {
+		message.Attachments = append(message.Attachments, &discordgo.MessageAttachment{Filename: file.Name})
+	}
+	f.messages = append(f.messages, message)
+	f.log = append(f.log, message)
+	return message
+}
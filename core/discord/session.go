@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Session is the part of the Discord API used by the dispatcher, handlers and services.
+// Live implements it for a real connection, Fake records what the bot does for tests.
+type Session interface {
+	// BotUserID returns the user ID of the bot itself
+	BotUserID() string
+	// Channel looks up a channel, using the state cache when possible
+	Channel(channelID string) (*discordgo.Channel, error)
+	// Member looks up a member of a guild, using the state cache when possible
+	Member(guildID, userID string) (*discordgo.Member, error)
+	// ChannelMessages returns up to limit of the most recent messages in a channel, newest first
+	ChannelMessages(channelID string, limit int) ([]*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageEditComplex(edit *discordgo.MessageEdit) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string) error
+	// UserChannelCreate opens the DM channel with a user
+	UserChannelCreate(userID string) (*discordgo.Channel, error)
+	InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error
+	FollowupMessageCreate(interaction *discordgo.Interaction, data *discordgo.WebhookParams) (*discordgo.Message, error)
+	// InteractionResponseEdit edits the response to an interaction. For a component whose message was updated,
+	// it edits that message.
+	InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error)
+	// InteractionResponseDelete deletes the response to an interaction, such as a deferred "thinking..." message
+	InteractionResponseDelete(interaction *discordgo.Interaction) error
+}
+
+// Live is a Session backed by a discordgo connection
+type Live struct {
+	session *discordgo.Session
+}
+
+// NewLive wraps a discordgo session
+func NewLive(s *discordgo.Session) *Live {
+	return &Live{session: s}
+}
+
+func (l *Live) BotUserID() string {
+	return l.session.State.User.ID
+}
+
+func (l *Live) Channel(channelID string) (*discordgo.Channel, error) {
+	if channel, err := l.session.State.Channel(channelID); err == nil {
+		return channel, nil
+	}
+	return l.session.Channel(channelID)
+}
+
+func (l *Live) Member(guildID, userID string) (*discordgo.Member, error) {
+	if member, err := l.session.State.Member(guildID, userID); err == nil {
+		return member, nil
+	}
+	return l.session.GuildMember(guildID, userID)
+}
+
+func (l *Live) ChannelMessages(channelID string, limit int) ([]*discordgo.Message, error) {
+	return l.session.ChannelMessages(channelID, limit, "", "", "")
+}
+
+func (l *Live) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	return l.session.ChannelMessageSendComplex(channelID, data)
+}
+
+func (l *Live) ChannelMessageEditComplex(edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	return l.session.ChannelMessageEditComplex(edit)
+}
+
+func (l *Live) ChannelMessageDelete(channelID, messageID string) error {
+	return l.session.ChannelMessageDelete(channelID, messageID)
+}
+
+func (l *Live) UserChannelCreate(userID string) (*discordgo.Channel, error) {
+	return l.session.UserChannelCreate(userID)
+}
+
+func (l *Live) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	return l.session.InteractionRespond(interaction, response)
+}
+
+func (l *Live) FollowupMessageCreate(interaction *discordgo.Interaction, data *discordgo.WebhookParams) (*discordgo.Message, error) {
+	return l.session.FollowupMessageCreate(interaction, true, data)
+}
+
+func (l *Live) InteractionResponseEdit(interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	return l.session.InteractionResponseEdit(interaction, edit)
+}
+
+func (l *Live) InteractionResponseDelete(interaction *discordgo.Interaction) error {
+	return l.session.InteractionResponseDelete(interaction)
+}
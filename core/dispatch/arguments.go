@@ -0,0 +1,367 @@
+package dispatch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"GoBot/core/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ArgType describes how a positional argument is parsed and validated.
+type ArgType int
+
+const (
+	ArgString      ArgType = iota // A single word, or a "quoted string"
+	ArgNumber                     // A floating point number
+	ArgInteger                    // A whole number
+	ArgStationId                  // A carrier callsign such as W7H-6DZ (upper-cased)
+	ArgSystemName                 // A system name. Consumes the rest of the line when it's the last argument
+	ArgRest                       // The rest of the line, verbatim
+	ArgUser                       // A user @mention or ID, parsed to the user ID
+	ArgDiscordRole                // A Discord role @mention or ID, parsed to the role ID
+)
+
+// HelpFlag is recognised for every command and makes the dispatcher reply with usage instead of running the command.
+const HelpFlag = "help"
+
+var (
+	stationIdArgPattern = regexp.MustCompile(`^[A-Z0-9]{3}-[A-Z0-9]{3}$`)
+	userArgPattern      = regexp.MustCompile(`^(?:<@!?)?([0-9]+)>?$`)
+	roleArgPattern      = regexp.MustCompile(`^(?:<@&)?([0-9]+)>?$`)
+)
+
+// AutocompleteFunc returns the slash command autocomplete choices for what the user has typed so far.
+type AutocompleteFunc func(typed string) []*discordgo.ApplicationCommandOptionChoice
+
+// Arg declares a positional argument for a command.
+type Arg struct {
+	Name         string           // Name used in usage text and for looking up the parsed value
+	Type         ArgType          // How the argument is parsed
+	Optional     bool             // Optional arguments must come after all required ones
+	Help         string           // Short description of the argument
+	Choices      []string         // If set, the value must be one of these (case-insensitive)
+	Autocomplete AutocompleteFunc // Optional autocomplete for the slash command option
+}
+
+// Flag declares a per-command flag, given as --name or -short.
+type Flag struct {
+	Name  string // Long name, used as --name
+	Short string // Optional short name, used as -short
+	Help  string // Short description of the flag
+}
+
+// ArgumentError is returned when a command line doesn't match the declared arguments. Its message is in the
+// catalogs, to be shown in the language of the reply.
+type ArgumentError struct {
+	Key  string        // Catalog key of the message
+	Args []interface{} // Values filled into the message
+}
+
+func argumentError(key string, args ...interface{}) *ArgumentError {
+	return &ArgumentError{Key: key, Args: args}
+}
+
+func (e *ArgumentError) Error() string {
+	return e.Localize(i18n.DefaultLocale)
+}
+
+// Localize returns the message in a language
+func (e *ArgumentError) Localize(locale string) string {
+	return i18n.T(locale, e.Key, e.Args...)
+}
+
+// Params holds the parsed positional arguments and flags of a command.
+type Params struct {
+	values map[string]interface{}
+	flags  map[string]bool
+}
+
+// Has returns true if the named argument was given.
+func (p Params) Has(name string) bool {
+	_, ok := p.values[name]
+	return ok
+}
+
+// String returns the named argument as a string, or "" if it wasn't given.
+func (p Params) String(name string) string {
+	switch v := p.values[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+// Number returns the named numeric argument, or 0 if it wasn't given.
+func (p Params) Number(name string) float64 {
+	return p.NumberOr(name, 0)
+}
+
+// NumberOr returns the named numeric argument, or def if it wasn't given.
+func (p Params) NumberOr(name string, def float64) float64 {
+	switch v := p.values[name].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	default:
+		return def
+	}
+}
+
+// Int returns the named whole number argument, or 0 if it wasn't given.
+func (p Params) Int(name string) int64 {
+	switch v := p.values[name].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// Flag returns true if the named flag was given.
+func (p Params) Flag(name string) bool {
+	return p.flags[name]
+}
+
+func (p *Params) set(name string, value interface{}) {
+	if p.values == nil {
+		p.values = map[string]interface{}{}
+	}
+	p.values[name] = value
+}
+
+func (p *Params) setFlag(name string) {
+	if p.flags == nil {
+		p.flags = map[string]bool{}
+	}
+	p.flags[name] = true
+}
+
+// Usage returns the generated usage line for the command, e.g. "#carrierjump <carrier> <time...> [--here]".
+func (c *MessageCommand) Usage(prefix string) string {
+	parts := []string{prefix + c.Command}
+	for _, arg := range c.Args {
+		name := arg.Name
+		if len(arg.Choices) > 0 {
+			name = strings.Join(arg.Choices, "|")
+		}
+		if arg.Type == ArgRest || arg.Type == ArgSystemName {
+			name += "..."
+		}
+		if arg.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	for _, flag := range c.Flags {
+		parts = append(parts, "[--"+flag.Name+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *MessageCommand) lookupFlag(token string) string {
+	if token == "--"+HelpFlag || token == "-h" {
+		return HelpFlag
+	}
+	for _, flag := range c.Flags {
+		if token == "--"+flag.Name || (flag.Short != "" && token == "-"+flag.Short) {
+			return flag.Name
+		}
+	}
+	return ""
+}
+
+// Parse parses the raw argument string according to the declared arguments and flags.
+// Tokens that aren't claimed by a declared argument are returned as free-form words,
+// which is only allowed for commands that don't declare any arguments.
+func (c *MessageCommand) Parse(raw string) (Params, []string, error) {
+	var params Params
+	var words []string
+
+	tokens, err := tokenize(raw)
+	if err != nil {
+		return params, nil, err
+	}
+
+	argIdx := 0
+	for i, tok := range tokens {
+		if !tok.quoted && strings.HasPrefix(tok.text, "-") {
+			if name := c.lookupFlag(tok.text); name != "" {
+				params.setFlag(name)
+				continue
+			}
+		}
+		if argIdx >= len(c.Args) {
+			if len(c.Args) > 0 {
+				return params, nil, argumentError("dispatch.arg_too_many", tok.text)
+			}
+			words = append(words, tok.text)
+			continue
+		}
+
+		arg := c.Args[argIdx]
+		argIdx++
+		if arg.Type == ArgRest || (arg.Type == ArgSystemName && argIdx == len(c.Args)) {
+			// Flags at the end are still flags, as the usage shows them after the arguments
+			end := len(raw)
+			for j := len(tokens) - 1; j > i && !tokens[j].quoted; j-- {
+				name := c.lookupFlag(tokens[j].text)
+				if name == "" {
+					break
+				}
+				params.setFlag(name)
+				end = tokens[j].start
+			}
+			rest := strings.TrimSpace(raw[tok.start:end])
+			if arg.Type == ArgSystemName {
+				rest = cleanSystemName(rest)
+			}
+			if rest == "" {
+				break
+			}
+			params.set(arg.Name, rest)
+			break
+		}
+		value, err := arg.convert(tok.text)
+		if err != nil {
+			return params, nil, err
+		}
+		params.set(arg.Name, value)
+	}
+
+	for _, arg := range c.Args {
+		if !arg.Optional && !params.Has(arg.Name) {
+			return params, nil, argumentError("dispatch.arg_missing", arg.Name)
+		}
+	}
+	return params, words, nil
+}
+
+func (a *Arg) convert(text string) (interface{}, error) {
+	if len(a.Choices) > 0 {
+		for _, choice := range a.Choices {
+			if strings.EqualFold(choice, text) {
+				return choice, nil
+			}
+		}
+		return nil, argumentError("dispatch.arg_choices", a.Name, strings.Join(a.Choices, ", "))
+	}
+
+	switch a.Type {
+	case ArgNumber:
+		num, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, argumentError("dispatch.arg_number", a.Name, text)
+		}
+		return num, nil
+	case ArgInteger:
+		num, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, argumentError("dispatch.arg_integer", a.Name, text)
+		}
+		return num, nil
+	case ArgStationId:
+		id := strings.ToUpper(text)
+		if !stationIdArgPattern.MatchString(id) {
+			return nil, argumentError("dispatch.arg_station_id", a.Name, text)
+		}
+		return id, nil
+	case ArgSystemName:
+		return cleanSystemName(text), nil
+	case ArgUser:
+		match := userArgPattern.FindStringSubmatch(text)
+		if match == nil {
+			return nil, argumentError("dispatch.arg_user", a.Name, text)
+		}
+		return match[1], nil
+	case ArgDiscordRole:
+		match := roleArgPattern.FindStringSubmatch(text)
+		if match == nil {
+			return nil, argumentError("dispatch.arg_role", a.Name, text)
+		}
+		return match[1], nil
+	default:
+		return text, nil
+	}
+}
+
+// cleanSystemName strips surrounding quotes and collapses whitespace in a system name.
+func cleanSystemName(name string) string {
+	if tokens, err := tokenize(name); err == nil && len(tokens) == 1 && tokens[0].quoted {
+		name = tokens[0].text
+	}
+	return strings.Join(strings.Fields(name), " ")
+}
+
+type token struct {
+	text       string
+	start, end int // Byte offsets into the raw string
+	quoted     bool
+}
+
+func isQuote(r rune) bool {
+	return r == '"' || r == '“' || r == '”'
+}
+
+// tokenize splits a string on whitespace, honouring double quotes (including the curly ones
+// phones like to insert). A backslash escapes a quote inside a quoted string.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+	offsets := make([]int, len(runes)+1)
+	pos := 0
+	for i, r := range runes {
+		offsets[i] = pos
+		pos += len(string(r))
+	}
+	offsets[len(runes)] = pos
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		if isQuote(runes[i]) {
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) && isQuote(runes[i+1]) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if isQuote(runes[i]) {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, argumentError("dispatch.arg_unterminated")
+			}
+			tokens = append(tokens, token{sb.String(), offsets[start], offsets[i], true})
+			continue
+		}
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		tokens = append(tokens, token{string(runes[start:i]), offsets[start], offsets[i], false})
+	}
+	return tokens, nil
+}
@@ -0,0 +1,189 @@
+package dispatch
+
+import (
+	"testing"
+)
+
+func TestTokenize_Quotes(t *testing.T) {
+	tokens, err := tokenize(`foo "bar baz"  “curly quotes” "esc\"aped"`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"foo", "bar baz", "curly quotes", `esc"aped`}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, tok := range tokens {
+		if tok.text != expected[i] {
+			t.Errorf("Token %d: expected %q, got %q", i, expected[i], tok.text)
+		}
+	}
+	if tokens[0].quoted || !tokens[1].quoted {
+		t.Error("Expected only quoted tokens to be marked as quoted")
+	}
+}
+
+func TestTokenize_Unterminated(t *testing.T) {
+	if _, err := tokenize(`foo "bar`); err == nil {
+		t.Error("Expected error for unterminated quote, got nil")
+	}
+}
+
+func TestParse_RestKeepsWordsThatLookLikeFlags(t *testing.T) {
+	cmd := MessageCommand{Command: "addcmd", Args: []Arg{
+		{Name: "command", Type: ArgString},
+		{Name: "text", Type: ArgRest},
+	}}
+	params, _, err := cmd.Parse(" foo get help here\n  and more")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.String("command") != "foo" {
+		t.Errorf("Expected command='foo', got %q", params.String("command"))
+	}
+	if params.String("text") != "get help here\n  and more" {
+		t.Errorf("Expected text to be kept verbatim, got %q", params.String("text"))
+	}
+	if params.Flag(HelpFlag) {
+		t.Error("Expected bare 'help' not to set the help flag")
+	}
+}
+
+func TestParse_FlagsAfterRest(t *testing.T) {
+	cmd := MessageCommand{Command: "remindme", Args: []Arg{
+		{Name: "when", Type: ArgString},
+		{Name: "text", Type: ArgRest},
+	}, Flags: []Flag{{Name: "dm"}, {Name: "embed", Short: "e"}}}
+	params, _, err := cmd.Parse("2h check the --dm carrier --dm  -e")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.Flag("dm") || !params.Flag("embed") {
+		t.Error("Expected the flags after the text to be set")
+	}
+	if params.String("text") != "check the --dm carrier" {
+		t.Errorf("Expected the flags at the end left out of the text, got %q", params.String("text"))
+	}
+
+	params, _, _ = cmd.Parse(`2h check "--dm"`)
+	if params.Flag("dm") || params.String("text") != `check "--dm"` {
+		t.Errorf("Expected a quoted flag to stay in the text, got %q", params.String("text"))
+	}
+	if _, _, err := cmd.Parse("2h --dm"); err == nil {
+		t.Error("Expected the text to be missing when only a flag follows")
+	}
+}
+
+func TestParse_TypedArguments(t *testing.T) {
+	cmd := MessageCommand{Command: "test", Args: []Arg{
+		{Name: "carrier", Type: ArgStationId},
+		{Name: "distance", Type: ArgNumber},
+		{Name: "system", Type: ArgSystemName},
+	}}
+	params, _, err := cmd.Parse(`w7h-6dz 12.5   Thuecheae   MT-Q e5-8`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.String("carrier") != "W7H-6DZ" {
+		t.Errorf("Expected carrier='W7H-6DZ', got %q", params.String("carrier"))
+	}
+	if params.Number("distance") != 12.5 {
+		t.Errorf("Expected distance=12.5, got %f", params.Number("distance"))
+	}
+	if params.String("system") != "Thuecheae MT-Q e5-8" {
+		t.Errorf("Expected system='Thuecheae MT-Q e5-8', got %q", params.String("system"))
+	}
+}
+
+func TestParse_QuotedSystemName(t *testing.T) {
+	cmd := MessageCommand{Command: "test", Args: []Arg{
+		{Name: "from", Type: ArgSystemName},
+		{Name: "to", Type: ArgSystemName},
+	}}
+	params, _, err := cmd.Parse(`"Beagle Point" Sol`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.String("from") != "Beagle Point" || params.String("to") != "Sol" {
+		t.Errorf("Expected from='Beagle Point' to='Sol', got %q and %q", params.String("from"), params.String("to"))
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cmd := MessageCommand{Command: "test", Args: []Arg{
+		{Name: "carrier", Type: ArgStationId},
+		{Name: "field", Type: ArgString, Choices: []string{"jump", "dest"}},
+		{Name: "count", Type: ArgNumber, Optional: true},
+	}}
+	tests := []struct {
+		input string
+		name  string
+	}{
+		{"", "missing argument"},
+		{"W7H-6DZ", "missing second argument"},
+		{"nope jump", "invalid station id"},
+		{"W7H-6DZ status", "invalid choice"},
+		{"W7H-6DZ jump abc", "invalid number"},
+		{"W7H-6DZ jump 1 2", "too many arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := cmd.Parse(tt.input)
+			if err == nil {
+				t.Errorf("Expected error for %q, got nil", tt.input)
+			}
+		})
+	}
+}
+
+func TestParse_Flags(t *testing.T) {
+	cmd := MessageCommand{Command: "help", Flags: []Flag{{Name: "here", Short: "H"}}}
+	params, words, err := cmd.Parse("carriers --here")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.Flag("here") {
+		t.Error("Expected here flag to be set")
+	}
+	if len(words) != 1 || words[0] != "carriers" {
+		t.Errorf("Expected free-form words [carriers], got %v", words)
+	}
+
+	params, _, _ = cmd.Parse("here -h")
+	if params.Flag("here") {
+		t.Error("Expected bare 'here' not to set the here flag")
+	}
+	if !params.Flag(HelpFlag) {
+		t.Error("Expected -h to set the help flag")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	cmd := MessageCommand{Command: "carrierclear", Args: []Arg{
+		{Name: "carrier", Type: ArgStationId},
+		{Name: "field", Type: ArgString, Choices: []string{"jump", "all"}},
+		{Name: "note", Type: ArgRest, Optional: true},
+	}, Flags: []Flag{{Name: "here"}}}
+	expected := "#carrierclear <carrier> <jump|all> [note...] [--here]"
+	if usage := cmd.Usage("#"); usage != expected {
+		t.Errorf("Expected %q, got %q", expected, usage)
+	}
+}
+
+func TestParse_UserAndRole(t *testing.T) {
+	cmd := MessageCommand{Command: "test", Args: []Arg{
+		{Name: "user", Type: ArgUser},
+		{Name: "role", Type: ArgDiscordRole},
+	}}
+	params, _, err := cmd.Parse("<@!123456> <@&987654>")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.String("user") != "123456" || params.String("role") != "987654" {
+		t.Errorf("Expected user='123456' role='987654', got %q and %q", params.String("user"), params.String("role"))
+	}
+
+	if _, _, err := cmd.Parse("<@&123456> 987654"); err == nil {
+		t.Error("Expected error for role mention given as user, got nil")
+	}
+}
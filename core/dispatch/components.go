@@ -0,0 +1,202 @@
+package dispatch
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom IDs are "namespace:action:expiry:data". The expiry is a Unix time in base 36, empty if the component
+// doesn't expire. Data starting with $ refers to state stored in the database, a literal $ is doubled.
+const (
+	componentIdLimit = 100 // Discord limit on custom ID length
+	componentSep     = ":"
+	stateMarker      = "$"
+)
+
+// ComponentHandler handles a click on a button, or a choice in a select menu. The message has the author,
+// channel and guild of the click. Replies are sent as new messages, ephemeral by default, and Message.Update
+// replaces the message the component is on. A click the handler doesn't respond to is acknowledged without changes.
+type ComponentHandler func(m *Message, click *ComponentClick)
+
+// componentRoute is the handler of a namespace, with the permission needed to click its components
+type componentRoute struct {
+	handler ComponentHandler
+	spec    *MessageCommand
+}
+
+// ComponentClick is a click on a component, parsed from its custom ID
+type ComponentClick struct {
+	Namespace string
+	Action    string
+	Data      string   // Data of the custom ID, or the stored state it refers to
+	Values    []string // Chosen values of a select menu
+}
+
+// ErrComponentExpired is returned for components past their expiry, or whose stored state is gone
+var ErrComponentExpired = errors.New("component expired")
+
+// RegisterComponents routes clicks on components with custom IDs in the namespace to the handler. Users without
+// the permission are told so, like for a command.
+func RegisterComponents(namespace string, permission core.PermissionLevel, handler ComponentHandler) {
+	Dispatcher.registerComponents(namespace, permission, handler)
+}
+
+func (d *MessageDispatcher) registerComponents(namespace string, permission core.PermissionLevel, handler ComponentHandler) {
+	if d.components == nil {
+		d.components = map[string]*componentRoute{}
+	}
+	if _, exists := d.components[namespace]; exists {
+		core.LogWarnF("Component namespace %s is already registered, replacing it.", namespace)
+	}
+	d.components[namespace] = &componentRoute{handler: handler,
+		spec: &MessageCommand{Command: namespace, Permission: permission}}
+	core.LogInfoF("Registered component namespace: %s", namespace)
+}
+
+// ComponentID returns the custom ID of a component handled by the namespace. The data is passed back in the click,
+// the whole ID must fit in 100 characters. A ttl above zero makes the component expire.
+func ComponentID(namespace, action, data string, ttl time.Duration) string {
+	if strings.HasPrefix(data, stateMarker) {
+		data = stateMarker + data
+	}
+	return componentID(namespace, action, data, ttl)
+}
+
+// StatefulComponentID stores state for a component in the database until the ttl passes, and returns a custom ID
+// referring to it. Use it for data that doesn't fit in the custom ID.
+func StatefulComponentID(namespace, action, state string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", errors.New("stored component state needs a ttl")
+	}
+	key := make([]byte, 8)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(key)
+	err := database.CreateComponentState(database.ComponentState{Id: id, Namespace: namespace, Data: state,
+		ExpiresAt: time.Now().Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	return componentID(namespace, action, stateMarker+id, ttl), nil
+}
+
+func componentID(namespace, action, data string, ttl time.Duration) string {
+	var expiry string
+	if ttl > 0 {
+		expiry = strconv.FormatInt(time.Now().Add(ttl).Unix(), 36)
+	}
+	id := strings.Join([]string{namespace, action, expiry, data}, componentSep)
+	if len(id) > componentIdLimit {
+		core.LogWarnF("Component ID %s is longer than %d characters, use stored state instead", id, componentIdLimit)
+	}
+	return id
+}
+
+// parseComponentID parses a custom ID, loading the stored state it refers to. Returns ErrComponentExpired
+// if the component has expired.
+func parseComponentID(customId string, now time.Time) (*ComponentClick, error) {
+	parts := strings.SplitN(customId, componentSep, 4)
+	if len(parts) != 4 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid component ID %q", customId)
+	}
+	click := &ComponentClick{Namespace: parts[0], Action: parts[1], Data: parts[3]}
+	if parts[2] != "" {
+		expiry, err := strconv.ParseInt(parts[2], 36, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry in component ID %q", customId)
+		}
+		if now.Unix() >= expiry {
+			return click, ErrComponentExpired
+		}
+	}
+	switch {
+	case strings.HasPrefix(click.Data, stateMarker+stateMarker):
+		click.Data = click.Data[1:]
+	case strings.HasPrefix(click.Data, stateMarker):
+		state := database.FetchComponentState(click.Data[1:], now)
+		if state == nil || state.Namespace != click.Namespace {
+			return click, ErrComponentExpired
+		}
+		click.Data = state.Data
+	}
+	return click, nil
+}
+
+// isComponentClick returns true if the message was created from a click on a component
+func (m Message) isComponentClick() bool {
+	return m.interaction != nil && m.interaction.component
+}
+
+// Update replaces the message of the clicked component with the reply. Only works for component clicks.
+func (m Message) Update(reply Reply) error {
+	if m.interaction == nil {
+		return errors.New("only a component interaction can update its message")
+	}
+	if reply.Error {
+		m.MarkFailed()
+	}
+	err := m.interaction.Update(reply)
+	if err != nil {
+		core.LogErrorF("Failed to update the message of %s for %s: %s", m.Command, authorName(&m), err)
+	}
+	return err
+}
+
+// dispatchComponent routes a component click to the handler of its namespace. Clicks go through the middleware
+// like commands, named after the namespace: they're rate limited, audited, counted in the usage statistics and
+// checked against the permission of the namespace.
+func (d *MessageDispatcher) dispatchComponent(ctx context.Context, s discord.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	reply := newInteractionReply(s, i.Interaction, true)
+	reply.component = true
+	defer reply.finish()
+
+	ctx, cancel := context.WithTimeout(ctx, core.Settings.HandlerTimeout())
+	defer cancel()
+	m := &Message{
+		ctx:         ctx,
+		Message:     interactionMessage(i),
+		Session:     s,
+		Prefix:      database.FetchGuildSettings(i.GuildID).CommandPrefix,
+		IsPM:        i.GuildID == "",
+		interaction: reply,
+	}
+
+	click, err := parseComponentID(data.CustomID, time.Now())
+	if errors.Is(err, ErrComponentExpired) {
+		reply.send(m.T("dispatch.component_expired"), true)
+		return
+	}
+	if err != nil {
+		core.LogWarnF("Received a component with %s", err)
+		return
+	}
+	route := d.components[click.Namespace]
+	if route == nil {
+		core.LogWarnF("Received a component for unknown namespace %s", click.Namespace)
+		reply.send(m.T("dispatch.component_unknown"), true)
+		return
+	}
+	click.Values = data.Values
+	m.Command = click.Namespace
+	m.Args = []string{click.Action}
+	m.spec = route.spec
+
+	core.LogDebugF("Component %s from %s", data.CustomID, authorName(m))
+	d.runMiddleware(m, func() bool {
+		route.handler(m, click)
+		return true
+	})
+}
@@ -0,0 +1,134 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"GoBot/core"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseComponentID(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		id     string
+		action string
+		data   string
+	}{
+		{ComponentID("carriers", "refresh", "", 0), "refresh", ""},
+		{ComponentID("alerts", "delete", "12", time.Hour), "delete", "12"},
+		{ComponentID("notes", "show", "a:b", 0), "show", "a:b"},
+		{ComponentID("notes", "show", "$5", 0), "show", "$5"},
+	}
+	for _, tt := range tests {
+		click, err := parseComponentID(tt.id, now)
+		if err != nil || click.Action != tt.action || click.Data != tt.data {
+			t.Errorf("parseComponentID(%q) = %+v, %v, want action %q and data %q", tt.id, click, err, tt.action, tt.data)
+		}
+	}
+
+	expired := ComponentID("alerts", "delete", "12", time.Minute)
+	if _, err := parseComponentID(expired, now.Add(2*time.Minute)); !errors.Is(err, ErrComponentExpired) {
+		t.Errorf("Expected %q to have expired, got %v", expired, err)
+	}
+	for _, id := range []string{"", "carriers", "carriers:refresh", ":x::", "a:b:!:c"} {
+		if _, err := parseComponentID(id, now); err == nil || errors.Is(err, ErrComponentExpired) {
+			t.Errorf("Expected %q to be invalid, got %v", id, err)
+		}
+	}
+}
+
+func TestDispatchComponent(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	clicks := 0
+	d.registerComponents("counter", core.PermissionUser, func(m *Message, click *ComponentClick) {
+		switch click.Action {
+		case "add":
+			clicks++
+			m.Update(Reply{Content: "Clicked", Components: testComponents()})
+		case "deny":
+			m.ReplyError("Not yours.")
+		}
+	})
+	sent, _ := fake.ChannelMessageSendComplex("10", &discordgo.MessageSend{Content: "Click me",
+		Components: testComponents()})
+
+	click := func(customId string) {
+		interaction := &discordgo.Interaction{
+			ID:        customId,
+			Type:      discordgo.InteractionMessageComponent,
+			ChannelID: "10",
+			GuildID:   "20",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "1"}},
+			Message:   sent,
+			Data:      discordgo.MessageComponentInteractionData{CustomID: customId},
+		}
+		d.HandleInteraction(context.Background(), fake, &discordgo.InteractionCreate{Interaction: interaction})
+	}
+
+	click(ComponentID("counter", "add", "", 0))
+	messages := fake.SentTo("10")
+	if clicks != 1 || len(messages) != 1 || messages[0].Content != "Clicked" || len(messages[0].Components) != 1 {
+		t.Fatalf("Expected the message to be updated, got %v", messages)
+	}
+
+	click(ComponentID("counter", "ignore", "", 0))
+	click(ComponentID("counter", "deny", "", 0))
+	messages = fake.SentTo("10")
+	if len(messages) != 2 || messages[1].Content != "**Error:** Not yours." || messages[1].Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("Expected only an ephemeral error reply, got %v", messages)
+	}
+
+	click(ComponentID("counter", "add", "", time.Nanosecond))
+	if clicks != 1 {
+		t.Error("Expected an expired component not to reach the handler")
+	}
+}
+
+func TestDispatchComponent_Middleware(t *testing.T) {
+	// The denied click counts against the limit too
+	core.Settings.SetTestRateLimits(core.RateLimitConfig{User: core.RateLimit{Commands: 2, Seconds: 60}})
+	defer core.Settings.SetTestRateLimits(core.RateLimitConfig{})
+	d, fake := newEchoDispatcher()
+	d.addMiddleware("ratelimit", d.rateLimitMiddleware)
+	d.addMiddleware("permission", permissionMiddleware)
+	clicks := 0
+	d.registerComponents("admin", core.PermissionAdmin, func(m *Message, click *ComponentClick) { clicks++ })
+	d.registerComponents("refresh", core.PermissionUser, func(m *Message, click *ComponentClick) { clicks++ })
+	sent, _ := fake.ChannelMessageSendComplex("10", &discordgo.MessageSend{Content: "Click me"})
+
+	click := func(id, customId string) {
+		interaction := &discordgo.Interaction{
+			ID:        id,
+			Type:      discordgo.InteractionMessageComponent,
+			ChannelID: "10",
+			GuildID:   "20",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "clicker"}},
+			Message:   sent,
+			Data:      discordgo.MessageComponentInteractionData{CustomID: customId},
+		}
+		d.HandleInteraction(context.Background(), fake, &discordgo.InteractionCreate{Interaction: interaction})
+	}
+
+	click("c1", ComponentID("admin", "apply", "", 0))
+	messages := fake.SentTo("10")
+	if clicks != 0 || len(messages) != 2 || !strings.Contains(messages[1].Content, "permission") {
+		t.Fatalf("Expected the click to be denied, got %d clicks and %v", clicks, messages)
+	}
+
+	click("c2", ComponentID("refresh", "", "", 0))
+	click("c3", ComponentID("refresh", "", "", 0))
+	messages = fake.SentTo("10")
+	if clicks != 1 || len(messages) != 3 || !strings.Contains(messages[2].Content, "too quickly") {
+		t.Errorf("Expected the second refresh to be throttled, got %d clicks and %v", clicks, messages)
+	}
+}
+
+// testComponents returns a row with a button
+func testComponents() []discordgo.MessageComponent {
+	button := discordgo.Button{Label: "Add", CustomID: ComponentID("counter", "add", "", 0)}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{button}}}
+}
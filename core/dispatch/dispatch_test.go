@@ -0,0 +1,169 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+const testBotId = "900"
+
+type echoHandler struct {
+	NoOpMessageHandler
+}
+
+func (*echoHandler) HandleCommand(m *Message) bool {
+	switch m.Command {
+	case "echo":
+		m.ReplyToChannel("You said: %s", strings.Join(m.Args, " "))
+	case "whisper":
+		m.ReplyToSender("Psst: %s", strings.Join(m.Args, " "))
+	case "long":
+		m.ReplyToChannel("%s", strings.Repeat("line of text\n", 300))
+	default:
+		return false
+	}
+	return true
+}
+
+// blockingHandler waits for release before replying
+type blockingHandler struct {
+	NoOpMessageHandler
+	started, release chan struct{}
+}
+
+func (h *blockingHandler) HandleCommand(m *Message) bool {
+	close(h.started)
+	<-h.release
+	m.ReplyToChannel("Done")
+	return true
+}
+
+func newEchoDispatcher() (*MessageDispatcher, *discord.Fake) {
+	d := newTestDispatcher()
+	handler := &echoHandler{}
+	for _, command := range []string{"echo", "whisper", "long"} {
+		d.addHandlerForCommand(MessageCommand{Command: command}, &d.commandHandlers, handler)
+	}
+	fake := discord.NewFake(testBotId)
+	fake.AddChannel(&discordgo.Channel{ID: "10", GuildID: "20", Type: discordgo.ChannelTypeGuildText})
+	fake.AddChannel(&discordgo.Channel{ID: discord.DMChannelID("1"), Type: discordgo.ChannelTypeDM})
+	return d, fake
+}
+
+func testMessage(id, channelId, content string) *discordgo.Message {
+	return &discordgo.Message{ID: id, ChannelID: channelId, Content: content, Author: &discordgo.User{ID: "1", Username: "cmdr"}}
+}
+
+func TestDispatch_DirectMessage(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("d1", discord.DMChannelID("1"), "echo hello"))
+
+	sent := fake.SentTo(discord.DMChannelID("1"))
+	if len(sent) != 1 || sent[0].Content != "You said: hello" {
+		t.Errorf("Expected the echo in the DM, got %v", sent)
+	}
+}
+
+func TestDispatch_IgnoresOtherMessages(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("g1", "10", "echo hello"))
+	bot := testMessage("g2", "10", "<@"+testBotId+"> echo hello")
+	bot.Author.ID = testBotId
+	d.Dispatch(context.Background(), fake, bot)
+
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Errorf("Expected no replies to messages without a prefix or from the bot, got %v", sent)
+	}
+}
+
+func TestDispatchEdit(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("e1", "10", "<@"+testBotId+"> echo one"))
+	d.DispatchEdit(context.Background(), fake, testMessage("e1", "10", "<@"+testBotId+"> echo two"))
+
+	sent := fake.SentTo("10")
+	if len(sent) != 1 || sent[0].Content != "You said: two" || sent[0].EditedTimestamp == nil {
+		t.Fatalf("Expected the reply to be edited, got %v", sent)
+	}
+
+	d.DispatchEdit(context.Background(), fake, testMessage("e1", "10", "never mind"))
+	if sent = fake.SentTo("10"); len(sent) != 0 || len(fake.Deleted()) != 1 {
+		t.Errorf("Expected the reply to be deleted when the message is no longer a command, got %v", sent)
+	}
+}
+
+func TestDispatchEdit_UnknownCommand(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("e2", "10", "<@"+testBotId+"> echo one"))
+	d.DispatchEdit(context.Background(), fake, testMessage("e2", "10", "<@"+testBotId+"> nosuchcommand"))
+	if sent := fake.SentTo("10"); len(sent) != 0 || len(fake.Deleted()) != 1 {
+		t.Errorf("Expected the reply to be deleted when the message is edited into an unknown command, got %v", sent)
+	}
+
+	d.DispatchEdit(context.Background(), fake, testMessage("e3", "10", "<@"+testBotId+"> echo three"))
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Errorf("Expected edits of messages that weren't commands to be ignored, got %v", sent)
+	}
+}
+
+func TestDispatch_LongReply(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("l1", discord.DMChannelID("1"), "long"))
+
+	sent := fake.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("Expected the reply split in 2 messages, got %d", len(sent))
+	}
+	for _, message := range sent {
+		if len(message.Content) > messageLimit {
+			t.Errorf("Expected messages within %d characters, got %d", messageLimit, len(message.Content))
+		}
+	}
+}
+
+func TestDispatch_PrivateReplyFallback(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.Dispatch(context.Background(), fake, testMessage("p1", "10", "<@"+testBotId+"> whisper secret"))
+	if sent := fake.SentTo(discord.DMChannelID("1")); len(sent) != 1 || sent[0].Content != "Psst: secret" {
+		t.Errorf("Expected the private reply in a DM, got %v", sent)
+	}
+
+	fake.DisableDMs("1")
+	d.Dispatch(context.Background(), fake, testMessage("p2", "10", "<@"+testBotId+"> whisper again"))
+	sent := fake.SentTo("10")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "<@1> I couldn't send you a DM") {
+		t.Errorf("Expected the private reply in the channel when DMs fail, got %v", sent)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	handler := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	d.addHandlerForCommand(MessageCommand{Command: "slow"}, &d.commandHandlers, handler)
+	dm := discord.DMChannelID("1")
+
+	go d.Dispatch(context.Background(), fake, testMessage("s1", dm, "slow"))
+	<-handler.started
+
+	deadline, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(deadline); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected shutdown to give up while the handler runs, got %v", err)
+	}
+
+	d.Dispatch(context.Background(), fake, testMessage("s2", dm, "echo hello"))
+	close(handler.release)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected shutdown to finish once the handler is done, got %v", err)
+	}
+	sent := fake.SentTo(dm)
+	if len(sent) != 1 || sent[0].Content != "Done" {
+		t.Errorf("Expected only the reply of the command in flight, got %v", sent)
+	}
+}
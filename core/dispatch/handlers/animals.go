@@ -8,7 +8,6 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
@@ -24,24 +23,24 @@ func init() {
 	randomHelp := "Show image of random animal. Supports cat, dog, corgi, and kitten. Space between *random* and *animal* is optional."
 	dispatch.Register(&animals{},
 		[]dispatch.MessageCommand{
-			{"random", randomHelp},
+			{Command: "random", Help: randomHelp, Args: []dispatch.Arg{{Name: "animal", Type: dispatch.ArgRest, Optional: true}}},
 		}, []dispatch.MessageCommand{
-			{"random", ""},
+			{Command: "random"},
 		}, false)
 }
 
 func (*animals) HandlePrefix(prefix, suffix string, m *dispatch.Message) bool {
 	switch suffix {
 	case "whale", "pikachu":
-		go handleRandomImage(m, suffix)
+		handleRandomImage(m, suffix)
 	case "kitten":
 		m.ReplyToChannel("http://www.randomkittengenerator.com/cats/rotator.php/%d.jpg", time.Now().Nanosecond())
 	case "corgi":
-		go handleRandomCorgi(m)
+		handleRandomCorgi(m)
 	case "cat", "dog", "bird", "panda", "fox", "kangaroo", "raccoon":
-		go handleRandomAnimal(m, suffix, true)
+		handleRandomAnimal(m, suffix, true)
 	case "redpanda":
-		go handleRandomAnimal(m, "red_panda", false)
+		handleRandomAnimal(m, "red_panda", false)
 	default:
 		m.ReplyToChannel("%s is an unknown animal.", suffix)
 		return false
@@ -50,24 +49,20 @@ func (*animals) HandlePrefix(prefix, suffix string, m *dispatch.Message) bool {
 }
 
 func (a *animals) HandleCommand(m *dispatch.Message) bool {
-	switch len(m.Args) {
-	case 0:
+	if !m.Params.Has("animal") {
 		m.ReplyToChannel("I know of the following random images: cat, dog, corgi, kitten, bird, panda, fox, kangaroo, raccoon, red panda, whale and pikachu.")
 		return true
-	case 1:
-		return a.HandlePrefix("random", strings.ToLower(m.Args[0]), m)
-	case 2:
-		return a.HandlePrefix("random", strings.ToLower(m.Args[0]+m.Args[1]), m)
-	default:
-		return false // Only handle empty random
 	}
+	// "red panda" and "redpanda" are the same animal
+	animal := strings.Join(strings.Fields(strings.ToLower(m.Params.String("animal"))), "")
+	return a.HandlePrefix("random", animal, m)
 }
 
 func handleRandomImage(m *dispatch.Message, image string) {
 	type imageModel struct {
 		Url string `json:"link"`
 	}
-	res, err := http.Get(fmt.Sprintf("https://some-random-api.com/img/%s", image))
+	res, err := core.HttpGet(m.Context(), fmt.Sprintf("https://some-random-api.com/img/%s", image))
 	if err != nil {
 		m.ReplyToChannel("Unfortunately, I failed to find a random %s for you today. :-(", image)
 		core.LogError("Failed to get meow: ", err)
@@ -93,7 +88,7 @@ func handleRandomDog(m *dispatch.Message, breed string) {
 	if breed != "" {
 		url = fmt.Sprintf("https://dog.ceo/api/breed/%s/images/random", breed)
 	}
-	res, err := http.Get(url)
+	res, err := core.HttpGet(m.Context(), url)
 	if err != nil {
 		m.ReplyToChannel("Unfortunately, I failed to find any random cats for you today. :-(")
 		core.LogError("Failed to get meow: ", err)
@@ -120,7 +115,7 @@ func handleRandomAnimal(m *dispatch.Message, breed string, showFacts bool) {
 		Fact string `json:"fact"`
 	}
 	url := fmt.Sprintf("https://some-random-api.com/animal/%s", breed)
-	res, err := http.Get(url)
+	res, err := core.HttpGet(m.Context(), url)
 	if err != nil {
 		m.ReplyToChannel("Unfortunately, I failed to find a random %s for you today. :-(", breed)
 		core.LogError("Failed to get animal: ", err)
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"GoBot/core"
+	"GoBot/core/database"
 	"GoBot/core/dispatch"
 	"GoBot/core/services"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 type carriers struct {
@@ -19,6 +25,28 @@ const (
 	CarrierClear  = "carrierclear"
 	CarrierLoc    = "carrierloc"
 	CarriersList  = "carriers"
+
+	Followers         = "followers"
+	FollowerInfo      = "followerinfo"
+	CarrierInfo       = "carrierinfo"
+	CarrierAlert      = "carrieralert"
+	CarrierAlerts     = "carrieralerts"
+	CarrierAlertClear = "carrieralertclear"
+
+	// Namespace and actions of the carrier buttons and select menus
+	carrierComponents  = "carriers"
+	refreshCarriers    = "refresh"
+	pickCarrier        = "info"
+	deleteCarrierAlert = "deletealert"
+	alertButtonsTTL    = 15 * time.Minute
+	alertButtonsPerRow = 5
+	alertsPerPage      = 10 // Two rows of delete buttons, leaving room for the page buttons
+	followersPerPage   = 8
+)
+
+var (
+	followerPages = &dispatch.Paginator{Name: Followers, Size: followersPerPage, Source: followersPage}
+	alertPages    = &dispatch.Paginator{Name: CarrierAlerts, Size: alertsPerPage, Source: carrierAlertsPage}
 )
 
 func (*carriers) CommandGroup() string {
@@ -26,16 +54,42 @@ func (*carriers) CommandGroup() string {
 }
 
 func init() {
+	carrierArg := dispatch.Arg{Name: "carrier", Type: dispatch.ArgStationId, Help: "Carrier station ID", Autocomplete: carrierAutocomplete}
 	dispatch.Register(&carriers{},
 		[]dispatch.MessageCommand{
-			{CarrierJump, "Set carrier jump time. Arguments: *<station-id> <time>* (e.g., '20th January, 18:30 UTC')"},
-			{CarrierDest, "Set carrier destination. Arguments: *<station-id> <system name>*"},
-			{CarrierStatus, "Set carrier status. Arguments: *<station-id> <status text>*"},
-			{CarrierClear, "Clear carrier field. Arguments: *<station-id> <jump|dest|status|all>*"},
-			{CarrierLoc, "Set carrier location manually. Arguments: *<station-id> <system name>*"},
-			{CarriersList, "List all fleet carriers with current status."},
+			{Command: CarrierJump, Permission: core.PermissionCarrierManager, Help: "Set carrier jump time (e.g., '20th January, 18:30 UTC' or unix timestamp).", Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{carrierArg, {Name: "time", Type: dispatch.ArgRest, Help: "Jump time (e.g., '20th January, 18:30 UTC' or unix timestamp)"}},
+				Examples: []string{"W7H-6DZ 20th January, 18:30 UTC", "W7H-6DZ 1737397800"}},
+			{Command: CarrierDest, Permission: core.PermissionCarrierManager, Help: "Set carrier destination.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{carrierArg, {Name: "system", Type: dispatch.ArgSystemName, Help: "Destination system name"}}},
+			{Command: CarrierStatus, Permission: core.PermissionCarrierManager, Help: "Set carrier status.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{carrierArg, {Name: "status", Type: dispatch.ArgRest, Help: "Status message"}}},
+			{Command: CarrierClear, Permission: core.PermissionCarrierManager, Help: "Clear carrier field.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{carrierArg, {Name: "field", Type: dispatch.ArgString, Help: "Field to clear", Choices: []string{"jump", "dest", "status", "all"}}}},
+			{Command: CarrierLoc, Permission: core.PermissionCarrierManager, Help: "Set carrier location manually.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{carrierArg, {Name: "system", Type: dispatch.ArgSystemName, Help: "Current system name"}}},
+			{Command: CarriersList, Help: "List all fleet carriers with current status.", Slash: true, Ephemeral: true},
+			{Command: CarrierInfo, Help: "Get detailed info and stats about a fleet carrier.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{carrierArg}},
+			{Command: Followers, Permission: core.PermissionCarrierManager, Help: "List carriers following our fleet.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "sort", Type: dispatch.ArgString, Optional: true, Help: "Sort by field", Choices: []string{"recent", "times", "distance"}}}},
+			{Command: FollowerInfo, Permission: core.PermissionCarrierManager, Help: "Get detailed info about a follower carrier.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "carrier", Type: dispatch.ArgStationId, Help: "Carrier station ID (e.g., ABC-123)"}}},
+			{Command: CarrierAlert, Help: "Get a DM when any fleet carrier jumps near a system.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{
+					{Name: "system", Type: dispatch.ArgString, Help: "Target system name (quote names with spaces)"},
+					{Name: "distance", Type: dispatch.ArgNumber, Help: "Alert distance in light years"},
+					{Name: "carrier", Type: dispatch.ArgStationId, Optional: true, Help: "Specific carrier to watch (omit for all carriers)", Autocomplete: carrierAutocomplete},
+				},
+				Examples: []string{"Sol 500", `"Beagle Point" 1000 W7H-6DZ`}},
+			{Command: CarrierAlerts, Help: "List your active carrier proximity alerts.", Slash: true, Ephemeral: true},
+			{Command: CarrierAlertClear, Help: "Remove a carrier proximity alert (or all).", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "id", Type: dispatch.ArgInteger, Optional: true, Help: "Alert ID to remove (omit to clear all)"}}},
 		},
 		nil, false)
+	dispatch.RegisterComponents(carrierComponents, core.PermissionUser, handleCarrierComponent)
+	dispatch.RegisterPaginator(followerPages)
+	dispatch.RegisterPaginator(alertPages)
 }
 
 func (c *carriers) HandleCommand(m *dispatch.Message) bool {
@@ -45,40 +99,33 @@ func (c *carriers) HandleCommand(m *dispatch.Message) bool {
 		return true
 	case CarrierJump, CarrierDest, CarrierStatus, CarrierClear, CarrierLoc:
 		return handleCarrierManagement(m)
+	case CarrierInfo:
+		m.Respond(carrierInfoReply(m, m.Params.String("carrier")))
+		return true
+	case Followers, FollowerInfo:
+		handleFollowers(m)
+		return true
+	case CarrierAlert:
+		handleCarrierAlert(m)
+		return true
+	case CarrierAlerts:
+		handleCarrierAlerts(m)
+		return true
+	case CarrierAlertClear:
+		handleCarrierAlertClear(m)
+		return true
 	default:
 		return false
 	}
 }
 
-// canManageCarriers checks if user has permission to manage carriers
-func canManageCarriers(m *dispatch.Message) bool {
-	// Secure channel always allowed
-	if m.ChannelID == SecureChannnel {
-		return true
-	}
-	// Check if user is a carrier owner
-	return core.Settings.IsCarrierOwner(m.Author.ID)
-}
-
 func handleCarrierManagement(m *dispatch.Message) bool {
-	if !canManageCarriers(m) {
-		m.ReplyToChannel("You don't have permission to manage carriers.")
-		return true
-	}
-
-	if len(m.Args) < 1 {
-		m.ReplyToChannel("**Error:** Missing station ID. Usage: `%s%s <station-id> ...`",
-			core.Settings.CommandPrefix(), m.Command)
-		return true
-	}
-
-	stationId := strings.ToUpper(m.Args[0])
+	stationId := m.Params.String("carrier")
 
 	// Validate station ID exists
 	if core.Settings.GetCarrierByStationId(stationId) == nil {
 		validIds := services.GetCarrierStationIds()
-		m.ReplyToChannel("**Error:** Carrier `%s` not found. Valid carriers: %s",
-			stationId, strings.Join(validIds, ", "))
+		m.ReplyError(m.T("carriers.not_found"), stationId, strings.Join(validIds, ", "))
 		return true
 	}
 
@@ -98,109 +145,260 @@ func handleCarrierManagement(m *dispatch.Message) bool {
 }
 
 func handleSetJumpTime(m *dispatch.Message, stationId string) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Missing time. Usage: `%s%s %s <time>`\nExamples: `20th January, 18:30 UTC` or unix timestamp",
-			core.Settings.CommandPrefix(), CarrierJump, stationId)
-		return
-	}
-
-	// Join all args after station ID to support spaces in time format
-	timeInput := strings.Join(m.Args[1:], " ")
-	timestamp, err := services.ParseJumpTime(timeInput)
+	timestamp, err := services.ParseJumpTime(m.Params.String("time"))
 	if err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+		m.ReplyError("%s", m.T("carriers.invalid_time"))
 		return
 	}
 
 	if err := services.SetCarrierJumpTime(stationId, timestamp); err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+		replyCarrierError(m, stationId, err)
 		return
 	}
 
-	m.ReplyToChannel("Jump time for **%s** set to <t:%d:F> (<t:%d:R>)", stationId, timestamp, timestamp)
-	services.PostCarrierFlightLog(stationId, []string{"jump time updated"})
+	m.ReplyToChannel(m.T("carriers.jump_set"), stationId, timestamp)
+	services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{"jump time updated"})
 }
 
 func handleSetDestination(m *dispatch.Message, stationId string) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Missing destination. Usage: `%s%s %s <system name>`",
-			core.Settings.CommandPrefix(), CarrierDest, stationId)
-		return
-	}
-
-	destination := strings.Join(m.Args[1:], " ")
+	destination := m.Params.String("system")
 	if err := services.SetCarrierDestination(stationId, destination); err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+		replyCarrierError(m, stationId, err)
 		return
 	}
 
-	m.ReplyToChannel("Destination for **%s** set to **%s**", stationId, destination)
-	services.PostCarrierFlightLog(stationId, []string{"destination: " + destination})
+	m.ReplyToChannel(m.T("carriers.destination_set"), stationId, destination)
+	services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{"destination: " + destination})
 }
 
 func handleSetStatus(m *dispatch.Message, stationId string) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Missing status. Usage: `%s%s %s <status text>`",
-			core.Settings.CommandPrefix(), CarrierStatus, stationId)
-		return
-	}
-
-	status := strings.Join(m.Args[1:], " ")
+	status := m.Params.String("status")
 	if err := services.SetCarrierStatus(stationId, status); err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+		replyCarrierError(m, stationId, err)
 		return
 	}
 
-	m.ReplyToChannel("Status for **%s** set to: %s", stationId, status)
-	services.PostCarrierFlightLog(stationId, []string{"status: " + status})
+	m.ReplyToChannel(m.T("carriers.status_set"), stationId, status)
+	services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{"status: " + status})
 }
 
 func handleClearField(m *dispatch.Message, stationId string) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Missing field. Usage: `%s%s %s <jump|dest|status|all>`",
-			core.Settings.CommandPrefix(), CarrierClear, stationId)
-		return
-	}
-
-	field := strings.ToLower(m.Args[1])
+	field := m.Params.String("field")
 	if err := services.ClearCarrierField(stationId, field); err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+		replyCarrierError(m, stationId, err)
 		return
 	}
 
 	if field == "all" {
-		m.ReplyToChannel("All fields cleared for **%s**", stationId)
-		services.PostCarrierFlightLog(stationId, []string{"all fields cleared"})
+		m.ReplyToChannel(m.T("carriers.cleared_all"), stationId)
+		services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{"all fields cleared"})
 	} else {
-		m.ReplyToChannel("Field `%s` cleared for **%s**", field, stationId)
-		services.PostCarrierFlightLog(stationId, []string{field + " cleared"})
+		m.ReplyToChannel(m.T("carriers.cleared"), field, stationId)
+		services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{field + " cleared"})
 	}
 }
 
 func handleSetLocation(m *dispatch.Message, stationId string) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Missing system. Usage: `%s%s %s <system name>`",
-			core.Settings.CommandPrefix(), CarrierLoc, stationId)
+	system := m.Params.String("system")
+	if err := services.SetCarrierLocation(stationId, system); err != nil {
+		replyCarrierError(m, stationId, err)
 		return
 	}
 
-	system := strings.Join(m.Args[1:], " ")
-	if err := services.SetCarrierLocation(stationId, system); err != nil {
-		m.ReplyToChannel("**Error:** %s", err)
+	m.ReplyToChannel(m.T("carriers.location_set"), stationId, system)
+	services.PostCarrierFlightLog(m.Context(), m.GuildID, stationId, []string{"location: " + system})
+}
+
+// replyCarrierError tells the user a change to a carrier failed. The error is only logged, as it isn't translated.
+func replyCarrierError(m *dispatch.Message, stationId string, err error) {
+	core.LogErrorF("Failed to update carrier %s for %s: %s", stationId, m.Author.Username, err)
+	m.ReplyError(m.T("carriers.update_failed"), stationId)
+}
+
+func handleCarriersList(m *dispatch.Message) {
+	// Reply in channel if bot channel, otherwise DM
+	reply := carrierListReply(m)
+	reply.Private = !m.GuildSettings().IsBotChannel(m.ChannelID)
+	m.Respond(reply)
+}
+
+func carrierListReply(m *dispatch.Message) dispatch.Reply {
+	refresh := discordgo.Button{Label: m.T("carriers.refresh"), Style: discordgo.SecondaryButton,
+		CustomID: dispatch.ComponentID(carrierComponents, refreshCarriers, "", 0)}
+	return dispatch.Reply{
+		Content:    services.FormatCarrierList(m.Context(), m.Locale()),
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{refresh}}},
+	}
+}
+
+// carrierInfoReply shows a carrier, with a menu to pick another one
+func carrierInfoReply(m *dispatch.Message, stationId string) dispatch.Reply {
+	picker := discordgo.SelectMenu{CustomID: dispatch.ComponentID(carrierComponents, pickCarrier, "", 0), Placeholder: m.T("carriers.pick_carrier")}
+	for _, c := range core.Settings.Carriers() {
+		if len(picker.Options) == 25 { // Discord limit
+			break
+		}
+		picker.Options = append(picker.Options, discordgo.SelectMenuOption{Label: c.Name, Value: c.StationId,
+			Description: c.StationId, Default: c.StationId == stationId})
+	}
+	reply := dispatch.Reply{Content: services.FormatCarrierInfo(m.Context(), m.Locale(), stationId)}
+	if len(picker.Options) > 0 {
+		reply.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{picker}}}
+	}
+	return reply
+}
+
+// handleCarrierComponent handles the carrier list refresh button, the carrier info menu and the alert delete buttons
+func handleCarrierComponent(m *dispatch.Message, click *dispatch.ComponentClick) {
+	switch click.Action {
+	case refreshCarriers:
+		m.Update(carrierListReply(m))
+	case pickCarrier:
+		if len(click.Values) == 1 {
+			m.Update(carrierInfoReply(m, click.Values[0]))
+		}
+	case deleteCarrierAlert:
+		// Data is the alert ID and the page it's on
+		id, page, _ := strings.Cut(click.Data, ":")
+		alertID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return
+		}
+		if !database.DeleteProximityAlert(alertID, m.Author.ID) {
+			m.ReplyError(m.T("carriers.alert_not_found"), alertID)
+			return
+		}
+		number, _ := strconv.Atoi(page)
+		m.Update(alertPages.Render(m, "", number))
+	}
+}
+
+// carrierAutocomplete offers the configured carriers matching what the user has typed
+func carrierAutocomplete(typed string) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, c := range core.Settings.Carriers() {
+		name := c.Name + " (" + c.StationId + ")"
+		if typed == "" || strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: c.StationId})
+		}
+	}
+	return choices
+}
+
+func handleFollowers(m *dispatch.Message) {
+	if m.Command == FollowerInfo {
+		follower := services.GetFollowerInfo(m.Params.String("carrier"))
+		m.ReplyToChannel("%s", services.FormatFollowerInfo(follower))
 		return
 	}
 
-	m.ReplyToChannel("Location for **%s** set to **%s**", stationId, system)
-	services.PostCarrierFlightLog(stationId, []string{"location: " + system})
+	sortBy := m.Params.String("sort")
+	if sortBy == "" {
+		sortBy = "recent"
+	}
+	followerPages.Respond(m, sortBy, false)
 }
 
-func handleCarriersList(m *dispatch.Message) {
-	output := services.FormatCarrierList()
+// followersPage lists a page of the followers, sorted by the page arguments
+func followersPage(m *dispatch.Message, page dispatch.Page) (dispatch.Reply, int) {
+	followers, total := services.GetRecentFollowers(page.Args, page.Offset(), page.Size)
+	return dispatch.Reply{Content: services.FormatFollowerList(followers, page.Args)}, total
+}
 
-	// Reply in channel if bot channel, otherwise DM
-	if core.Settings.IsBotChannel(m.ChannelID) {
-		m.ReplyToChannel("%s", output)
+func handleCarrierAlert(m *dispatch.Message) {
+	systemName := m.Params.String("system")
+	distance := m.Params.Number("distance")
+	carrierID := m.Params.String("carrier")
+
+	if distance <= 0 {
+		m.ReplyError("%s", m.T("carriers.alert_distance"))
+		return
+	}
+
+	// Validate system exists in EDSM
+	coords, err := services.GetSystemCoords(m.Context(), systemName)
+	if err != nil || coords == nil {
+		m.ReplyError(m.T("carriers.alert_system_not_found"), systemName)
+		return
+	}
+
+	alertID, err := database.CreateProximityAlert(m.Author.ID, systemName, distance, carrierID)
+	if err != nil {
+		core.LogErrorF("Failed to create a proximity alert for %s: %s", m.Author.Username, err)
+		m.ReplyError("%s", m.T("carriers.alert_failed"))
+		return
+	}
+
+	carrierDesc := m.T("carriers.alert_any_carrier")
+	if carrierID != "" {
+		if cfg := core.Settings.GetCarrierByStationId(carrierID); cfg != nil {
+			carrierDesc = fmt.Sprintf("%s (%s)", cfg.Name, carrierID)
+		} else {
+			carrierDesc = carrierID
+		}
+	}
+	m.ReplyToChannel(m.T("carriers.alert_created"), alertID, carrierDesc, distance, systemName)
+}
+
+func handleCarrierAlerts(m *dispatch.Message) {
+	alertPages.Respond(m, "", true)
+}
+
+// carrierAlertsPage lists a page of the alerts of the author, with a button to delete each
+func carrierAlertsPage(m *dispatch.Message, page dispatch.Page) (dispatch.Reply, int) {
+	total := database.CountProximityAlertsByUser(m.Author.ID)
+	alerts := database.FetchProximityAlertsPage(m.Author.ID, page.Offset(), page.Size)
+	if len(alerts) == 0 {
+		return dispatch.Reply{Content: m.T("carriers.alerts_none")}, total
+	}
+	var rows []discordgo.MessageComponent
+	var buttons []discordgo.MessageComponent
+	for _, a := range alerts {
+		data := fmt.Sprintf("%d:%d", a.ID, page.Number)
+		buttons = append(buttons, discordgo.Button{Label: m.T("carriers.alert_delete", a.ID), Style: discordgo.DangerButton,
+			CustomID: dispatch.ComponentID(carrierComponents, deleteCarrierAlert, data, alertButtonsTTL)})
+		if len(buttons) == alertButtonsPerRow {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+			buttons = nil
+		}
+	}
+	if len(buttons) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+
+	var sb strings.Builder
+	sb.WriteString(m.T("carriers.alerts_title") + "\n")
+	for _, a := range alerts {
+		created := time.Unix(a.CreatedAt, 0).UTC().Format("2006-01-02 15:04 UTC")
+		carrierFilter := m.T("carriers.alert_all_carriers")
+		if a.CarrierID != "" {
+			if cfg := core.Settings.GetCarrierByStationId(a.CarrierID); cfg != nil {
+				carrierFilter = m.T("carriers.alert_carrier", cfg.Name)
+			} else {
+				carrierFilter = m.T("carriers.alert_carrier", a.CarrierID)
+			}
+		}
+		sb.WriteString("• " + m.T("carriers.alert_line", a.ID, a.SystemName, a.DistanceLY, carrierFilter, created) + "\n")
+	}
+	return dispatch.Reply{Content: sb.String(), Components: rows}, total
+}
+
+func handleCarrierAlertClear(m *dispatch.Message) {
+	if m.Params.Has("id") {
+		alertID := m.Params.Int("id")
+		if database.DeleteProximityAlert(alertID, m.Author.ID) {
+			m.ReplyToChannel(m.T("carriers.alert_removed"), alertID)
+		} else {
+			m.ReplyError(m.T("carriers.alert_not_found"), alertID)
+		}
+		return
+	}
+
+	count := database.DeleteAllProximityAlerts(m.Author.ID)
+	if count == 0 {
+		m.ReplyToChannel("%s", m.T("carriers.alerts_none_to_clear"))
 	} else {
-		m.ReplyToSender("%s", output)
+		m.ReplyToChannel(m.T("carriers.alerts_cleared"), count)
 	}
 }
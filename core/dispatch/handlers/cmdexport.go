@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type commandExport struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	ExportCommands = "exportcmds"
+	ImportCommands = "importcmds"
+
+	CommandExportFile = "custom-commands.json"
+	maxImportSize     = 1 << 20
+
+	// Namespace and actions of the buttons under an import plan
+	importComponents = "cmdimport"
+	applyImport      = "apply"
+	cancelImport     = "cancel"
+	importButtonsTTL = 15 * time.Minute
+)
+
+func (*commandExport) CommandGroup() string {
+	return "Custom Command Management"
+}
+
+func init() {
+	dispatch.Register(&commandExport{},
+		[]dispatch.MessageCommand{
+			{Command: ExportCommands, Permission: core.PermissionAdmin, Help: "Export all custom commands and categories as a JSON file."},
+			{Command: ImportCommands, Permission: core.PermissionAdmin,
+				Help: "Import custom commands from an attached JSON export. Shows what would change before applying it.",
+				Args: []dispatch.Arg{{Name: "conflicts", Type: dispatch.ArgString, Optional: true, Choices: services.ImportPolicies,
+					Help: "What to do with existing commands that differ: skip them (default), overwrite them, or rename the imported ones"}},
+				Examples: []string{"", "overwrite", "rename"}},
+		},
+		nil, false)
+	dispatch.RegisterComponents(importComponents, core.PermissionAdmin, handleImportComponent)
+}
+
+func (*commandExport) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case ExportCommands:
+		exportCommands(m)
+	case ImportCommands:
+		importCommands(m)
+	default:
+		return false
+	}
+	return true
+}
+
+func exportCommands(m *dispatch.Message) {
+	export := services.ExportCustomCommands()
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		core.LogErrorF("Failed to export custom commands: %s", err)
+		m.ReplyToChannel("Internal error. Unable to export the custom commands.")
+		return
+	}
+	m.ReplyFileToChannel(CommandExportFile, bytes.NewReader(data), "Exported %d commands and %d categories.",
+		len(export.Commands), len(export.Categories))
+}
+
+func importCommands(m *dispatch.Message) {
+	policy := services.ImportSkip
+	if m.Params.Has("conflicts") {
+		policy = services.ImportPolicy(m.Params.String("conflicts"))
+	}
+	if len(m.Attachments) != 1 {
+		m.ReplyError("Attach the JSON file from `%s%s` to the message.", m.Prefix, ExportCommands)
+		return
+	}
+	data, err := downloadAttachment(m, m.Attachments[0], maxImportSize)
+	if err != nil {
+		m.ReplyError("Unable to read %s: %s.", m.Attachments[0].Filename, err)
+		return
+	}
+	export, err := services.ParseCommandExport(data)
+	if err != nil {
+		m.ReplyError("Unable to import %s: %s.", m.Attachments[0].Filename, err)
+		return
+	}
+
+	plan := services.PlanImport(export, policy, BuiltinConflict)
+	reply := dispatch.Reply{Content: services.FormatImportPlan(plan)}
+	if changes := plan.Counts(); changes[services.ImportAdd]+changes[services.ImportUpdate]+changes[services.ImportRenamed] == 0 {
+		reply.Content += "\nNothing to import."
+		m.Respond(reply)
+		return
+	}
+	// The export is kept with the button, and planned again when applied in case the commands changed since
+	apply, err := dispatch.StatefulComponentID(importComponents, applyImport, string(policy)+"\n"+string(data), importButtonsTTL)
+	if err != nil {
+		core.LogErrorF("Failed to store the import for %s: %s", m.Author.Username, err)
+		m.ReplyToChannel("Internal error. Unable to prepare the import.")
+		return
+	}
+	reply.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Import", Style: discordgo.PrimaryButton, CustomID: apply},
+		discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton,
+			CustomID: dispatch.ComponentID(importComponents, cancelImport, "", importButtonsTTL)},
+	}}}
+	m.Respond(reply)
+}
+
+// downloadAttachment reads an attached file, failing if it is larger than limit bytes
+func downloadAttachment(m *dispatch.Message, attachment *discordgo.MessageAttachment, limit int) ([]byte, error) {
+	if attachment.Size > limit {
+		return nil, fmt.Errorf("the file is larger than %d KB", limit/1024)
+	}
+	resp, err := core.HttpGet(m.Context(), attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+}
+
+// handleImportComponent applies or cancels an import when the buttons under its plan are clicked
+func handleImportComponent(m *dispatch.Message, click *dispatch.ComponentClick) {
+	switch click.Action {
+	case cancelImport:
+		m.Update(dispatch.Reply{Content: "Import cancelled."})
+	case applyImport:
+		policy, data, _ := strings.Cut(click.Data, "\n")
+		export, err := services.ParseCommandExport([]byte(data))
+		if err != nil {
+			core.LogErrorF("Stored import of %s no longer parses: %s", m.Author.Username, err)
+			m.Update(dispatch.Reply{Content: "Internal error. Unable to read the stored import."})
+			return
+		}
+		plan := services.PlanImport(export, services.ImportPolicy(policy), BuiltinConflict)
+		applied, err := plan.Apply(m.Author.ID, m.Author.Username)
+		if err != nil {
+			core.LogErrorF("Import by %s failed: %s", m.Author.Username, err)
+			m.Update(dispatch.Reply{Content: m.T("dispatch.error", "Import failed, nothing was changed."), Error: true})
+			return
+		}
+		core.LogInfoF("%s imported %d custom commands.", m.Author.Username, applied)
+		m.Update(dispatch.Reply{Content: fmt.Sprintf("%s\nImported %d commands.", services.FormatImportPlan(plan), applied)})
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+)
+
+type commandHistory struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	CommandHistory = "cmdhistory"
+	CommandDiff    = "cmddiff"
+	RevertCommand  = "cmdrevert"
+
+	revisionsPerPage      = 10
+	revisionPreviewLength = 60
+)
+
+var revisionPages = &dispatch.Paginator{Name: CommandHistory, Size: revisionsPerPage, Source: revisionsPage}
+
+func (*commandHistory) CommandGroup() string {
+	return "Custom Command Management"
+}
+
+func init() {
+	commandArg := dispatch.Arg{Name: "command", Type: dispatch.ArgString}
+	dispatch.Register(&commandHistory{},
+		[]dispatch.MessageCommand{
+			{Command: CommandHistory, Permission: core.PermissionAdmin, Help: "List the revisions of a custom command, including deleted ones.",
+				Args: []dispatch.Arg{commandArg}, Examples: []string{"faq"}},
+			{Command: CommandDiff, Permission: core.PermissionAdmin, Help: "Compare two revisions of a custom command.",
+				Args: []dispatch.Arg{commandArg, {Name: "from", Type: dispatch.ArgInteger, Help: "Older revision"},
+					{Name: "to", Type: dispatch.ArgInteger, Optional: true, Help: "Newer revision, the latest if omitted"}},
+				Examples: []string{"faq 3", "faq 3 5"}},
+			{Command: RevertCommand, Permission: core.PermissionAdmin, Help: "Restore a custom command to a revision, also if it was deleted.",
+				Args: []dispatch.Arg{commandArg, {Name: "revision", Type: dispatch.ArgInteger}}, Examples: []string{"faq 3"}},
+		},
+		nil, false)
+	dispatch.RegisterPaginator(revisionPages)
+}
+
+func (*commandHistory) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case CommandHistory:
+		cmd := m.Params.String("command")
+		if database.CountCommandRevisions(cmd) == 0 {
+			m.ReplyError("Command **%s** has no revisions.", cmd)
+			return true
+		}
+		revisionPages.Respond(m, cmd, false)
+	case CommandDiff:
+		diffCommand(m)
+	case RevertCommand:
+		revertCommand(m)
+	default:
+		return false
+	}
+	return true
+}
+
+// revisionsPage lists a page of the revisions of the command in the page arguments, newest first
+func revisionsPage(m *dispatch.Message, page dispatch.Page) (dispatch.Reply, int) {
+	cmd := page.Args
+	var lines []string
+	lines = append(lines, fmt.Sprintf("**Revisions of %s%s:**", m.Prefix, cmd))
+	for _, rev := range database.FetchCommandRevisions(cmd, page.Offset(), page.Size) {
+		lines = append(lines, revisionLine(&rev))
+	}
+	return dispatch.Reply{Content: strings.Join(lines, "\n")}, database.CountCommandRevisions(cmd)
+}
+
+func revisionLine(rev *database.CommandRevision) string {
+	line := fmt.Sprintf("`%d` %s", rev.Revision, rev.Action)
+	if rev.RevertedFrom != nil {
+		line += fmt.Sprintf(" to `%d`", *rev.RevertedFrom)
+	}
+	if rev.UserName != "" {
+		line += " by **" + rev.UserName + "**"
+	}
+	line += fmt.Sprintf(" <t:%d:R>", rev.CreatedAt)
+	if rev.Value != nil {
+		preview := strings.Join(strings.Fields(*rev.Value), " ")
+		if len([]rune(preview)) > revisionPreviewLength {
+			preview = string([]rune(preview)[:revisionPreviewLength]) + "…"
+		}
+		line += ": " + strings.ReplaceAll(preview, "`", "'")
+	}
+	return line
+}
+
+func diffCommand(m *dispatch.Message) {
+	cmd := m.Params.String("command")
+	from := database.FetchCommandRevision(cmd, m.Params.Int("from"))
+	to := database.FetchLatestCommandRevision(cmd)
+	if m.Params.Has("to") {
+		to = database.FetchCommandRevision(cmd, m.Params.Int("to"))
+	}
+	if from == nil || to == nil {
+		m.ReplyError("No such revision of **%s**. Use `%s%s %s` to list them.", cmd, m.Prefix, CommandHistory, cmd)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s%s** revision `%d` → `%d`\n", m.Prefix, cmd, from.Revision, to.Revision))
+	changes := 0
+	field := func(name, old, new string) {
+		if old != new {
+			sb.WriteString(fmt.Sprintf("**%s:** %s → %s\n", name, old, new))
+			changes++
+		}
+	}
+	field("Help", revisionText(from.Help), revisionText(to.Help))
+	field("Long help", revisionText(from.Longhelp), revisionText(to.Longhelp))
+	field("Category", revisionText(from.Category), revisionText(to.Category))
+	field("Sent in DM", yesNo(from.PMEnabled), yesNo(to.PMEnabled))
+	field("Cooldown", describeCooldown(&from.CommandRules), describeCooldown(&to.CommandRules))
+	field("Channels", describeChannels(&from.CommandRules), describeChannels(&to.CommandRules))
+	field("Roles", describeRoles(&from.CommandRules), describeRoles(&to.CommandRules))
+	field("Explains denied uses", yesNo(from.ExplainDenied), yesNo(to.ExplainDenied))
+	if stringOrEmpty(from.Value) != stringOrEmpty(to.Value) {
+		lines := services.DiffLines(stringOrEmpty(from.Value), stringOrEmpty(to.Value))
+		// A zero width space keeps code fences in the text from closing the block
+		sb.WriteString("```diff\n" + strings.ReplaceAll(strings.Join(lines, "\n"), "```", "`​``") + "\n```")
+		changes++
+	}
+	if stringOrEmpty(from.Response) != stringOrEmpty(to.Response) {
+		lines := services.DiffLines(indentedJSON(from.Response), indentedJSON(to.Response))
+		sb.WriteString("\n**Response:**\n```diff\n" + strings.ReplaceAll(strings.Join(lines, "\n"), "```", "`​``") + "\n```")
+		changes++
+	}
+	if changes == 0 {
+		sb.WriteString("No differences.")
+	}
+	m.ReplyToChannel("%s", sb.String())
+}
+
+func revertCommand(m *dispatch.Message) {
+	cmd := m.Params.String("command")
+	rev := database.FetchCommandRevision(cmd, m.Params.Int("revision"))
+	if rev == nil {
+		m.ReplyError("No such revision of **%s**. Use `%s%s %s` to list them.", cmd, m.Prefix, CommandHistory, cmd)
+		return
+	}
+	if !database.HasCommandAlias(cmd) {
+		// Restoring a deleted command, something else may have taken the name since
+		if builtin := BuiltinConflict(cmd); builtin != "" {
+			m.ReplyError("Command **%s** would be handled by %s, it can't be restored.", cmd, builtin)
+			return
+		}
+		if database.HasCommandGroup(cmd) {
+			m.ReplyError("Cannot restore command **%s** since there's now a category with that name.", cmd)
+			return
+		}
+	}
+	if err := database.RevertCommand(rev, m.Author.ID, m.Author.Username); err != nil {
+		core.LogErrorF("Failed to revert command %s to revision %d: %s", cmd, rev.Revision, err)
+		m.ReplyToChannel("Internal error. Unable to restore command alias.")
+		return
+	}
+	core.LogInfoF("%s reverted command alias %s to revision %d.", m.Author.Username, cmd, rev.Revision)
+	m.ReplyToChannel("Command **%s** restored to revision `%d`.", cmd, rev.Revision)
+}
+
+// revisionText quotes a field of a revision for display
+func revisionText(s *string) string {
+	if s == nil || *s == "" {
+		return "*none*"
+	}
+	return "`" + strings.ReplaceAll(*s, "`", "'") + "`"
+}
+
+// indentedJSON formats the JSON of a response over several lines, so changes to it can be diffed
+func indentedJSON(s *string) string {
+	var out bytes.Buffer
+	if json.Indent(&out, []byte(stringOrEmpty(s)), "", "  ") != nil {
+		return stringOrEmpty(s)
+	}
+	return out.String()
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type commandResponses struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	RemoveReply   = "rmreply"
+	AttachFiles   = "attach"
+	DetachFile    = "detach"
+	ShowCommand   = "showcmd"
+	removedEmbed  = "none" // Text of editcmd --embed that removes the embed
+	maxAttachSize = 8 << 20
+)
+
+func (*commandResponses) CommandGroup() string {
+	return "Custom Command Management"
+}
+
+func init() {
+	commandArg := dispatch.Arg{Name: "command", Type: dispatch.ArgString}
+	dispatch.Register(&commandResponses{},
+		[]dispatch.MessageCommand{
+			{Command: RemoveReply, Permission: core.PermissionAdmin, Help: "Remove one of the messages a custom command sends after the first.",
+				Args:     []dispatch.Arg{commandArg, {Name: "message", Type: dispatch.ArgInteger, Help: "Number of the message, as listed by showcmd"}},
+				Examples: []string{"faq 2"}},
+			{Command: AttachFiles, Permission: core.PermissionAdmin, Help: "Send the files attached to this message with a custom command.",
+				Args: []dispatch.Arg{commandArg}, Examples: []string{"faq"}},
+			{Command: DetachFile, Permission: core.PermissionAdmin, Help: "Stop sending a file with a custom command.",
+				Args: []dispatch.Arg{commandArg, {Name: "file", Type: dispatch.ArgRest}}, Examples: []string{"faq carrier.png"}},
+			{Command: ShowCommand, Permission: core.PermissionAdmin, Help: "Show the text, embed, messages and files of a custom command, as they're edited.",
+				Args: []dispatch.Arg{commandArg}, Examples: []string{"faq"}},
+		},
+		nil, false)
+}
+
+func (*commandResponses) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case RemoveReply:
+		number := int(m.Params.Int("message"))
+		updateResponse(m, m.Params.String("command"), func(r *services.CommandResponse) (string, error) {
+			if number < 2 || number-2 >= len(r.Messages) {
+				return "", fmt.Errorf("there's no message %d, the command sends %d", number, len(r.Messages)+1)
+			}
+			r.Messages = slices.Delete(r.Messages, number-2, number-1)
+			return fmt.Sprintf("Removed message %d", number), nil
+		})
+	case AttachFiles:
+		attachFiles(m)
+	case DetachFile:
+		file := m.Params.String("file")
+		updateResponse(m, m.Params.String("command"), func(r *services.CommandResponse) (string, error) {
+			i := slices.Index(r.Files, file)
+			if i < 0 {
+				return "", fmt.Errorf("the command doesn't send %s", file)
+			}
+			r.Files = slices.Delete(r.Files, i, i+1)
+			return "Detached " + file, nil
+		})
+	case ShowCommand:
+		showCommand(m)
+	default:
+		return false
+	}
+	return true
+}
+
+// addEmbedCommand creates a command that replies with the embed in the text of addcmd --embed
+func addEmbedCommand(m *dispatch.Message, cmd string) {
+	embed, err := services.ParseEmbedSpec(m.Params.String("text"))
+	if err != nil {
+		m.ReplyError("Invalid embed: %s.", err)
+		return
+	}
+	response := &services.CommandResponse{Embed: embed}
+	if err := database.SaveCommandAlias(database.CommandAlias{Command: cmd, Response: response.Encode()}); err != nil {
+		core.LogErrorF("Failed to create command %s: %s", cmd, err)
+		m.ReplyToChannel("Internal error. Unable to create command alias.")
+		return
+	}
+	recordRevision(m, cmd, database.RevisionCreate)
+	core.LogInfoF("%s added command alias %s with an embed.", m.Author.Username, cmd)
+	m.ReplyToChannel("Command alias for **%s** created successfully.", cmd)
+}
+
+// editResponse replaces the embed of a command for editcmd --embed, or adds a message for editcmd --append
+func editResponse(m *dispatch.Message, cmd string) {
+	text := m.Params.String("text")
+	if m.Params.Flag("embed") && m.Params.Flag("append") {
+		m.ReplyError("Use either --embed or --append.")
+		return
+	}
+	updateResponse(m, cmd, func(r *services.CommandResponse) (string, error) {
+		if m.Params.Flag("append") {
+			r.Messages = append(r.Messages, text)
+			return fmt.Sprintf("Added message %d", len(r.Messages)+1), nil
+		}
+		if strings.EqualFold(text, removedEmbed) {
+			if r.Embed == nil {
+				return "", fmt.Errorf("the command has no embed")
+			}
+			r.Embed = nil
+			return "Removed the embed", nil
+		}
+		embed, err := services.ParseEmbedSpec(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid embed: %w", err)
+		}
+		r.Embed = embed
+		return "Updated the embed", nil
+	})
+}
+
+// updateResponse changes the response of a command with update, which returns what it did, and saves it if
+// it's still valid
+func updateResponse(m *dispatch.Message, cmd string, update func(r *services.CommandResponse) (string, error)) {
+	alias := database.FetchCommandAlias(cmd)
+	if alias == nil {
+		m.ReplyError("No command **%s** found.", cmd)
+		return
+	}
+	response, err := services.ParseCommandResponse(alias.Response)
+	if err != nil {
+		core.LogErrorF("Replacing the response of command %s: %s", cmd, err)
+	}
+	if response == nil {
+		response = &services.CommandResponse{}
+	}
+	done, err := update(response)
+	if err == nil {
+		err = response.Validate()
+	}
+	if err != nil {
+		m.ReplyError("Unable to change **%s**: %s.", cmd, err)
+		return
+	}
+	if alias.Value == "" && response.IsEmpty() {
+		m.ReplyError("The command would have nothing to send. Remove it with `%s%s` instead.", m.Prefix, RemoveCommand)
+		return
+	}
+	if !database.UpdateCommandAlias(database.CommandField, cmd, database.ResponseField, response.Encode()) {
+		m.ReplyToChannel("Internal error. Unable to update command alias.")
+		return
+	}
+	recordRevision(m, cmd, database.RevisionEdit)
+	core.LogInfoF("%s updated the response of command alias %s: %s.", m.Author.Username, cmd, done)
+	m.ReplyToChannel("%s of **%s**.", done, cmd)
+}
+
+// attachFiles stores the files attached to the message and sends them with the command
+func attachFiles(m *dispatch.Message) {
+	cmd := m.Params.String("command")
+	if len(m.Attachments) == 0 {
+		m.ReplyError("Attach the files to send with **%s** to the message.", cmd)
+		return
+	}
+	if !database.HasCommandAlias(cmd) {
+		m.ReplyError("No command **%s** found.", cmd)
+		return
+	}
+	var names []string
+	for _, attachment := range m.Attachments {
+		data, err := downloadAttachment(m, attachment, maxAttachSize)
+		if err != nil {
+			m.ReplyError("Unable to read %s: %s.", attachment.Filename, err)
+			return
+		}
+		name, err := services.SaveCommandFile(attachment.Filename, data)
+		if err != nil {
+			core.LogErrorF("Failed to store %s for command %s: %s", attachment.Filename, cmd, err)
+			m.ReplyError("Unable to store %s.", attachment.Filename)
+			return
+		}
+		names = append(names, name)
+	}
+	updateResponse(m, cmd, func(r *services.CommandResponse) (string, error) {
+		for _, name := range names {
+			if !slices.Contains(r.Files, name) {
+				r.Files = append(r.Files, name)
+			}
+		}
+		return "Attached " + strings.Join(names, ", "), nil
+	})
+}
+
+// showCommand lists what a command sends, in the form it's edited in
+func showCommand(m *dispatch.Message) {
+	cmd := database.FetchCommandAlias(m.Params.String("command"))
+	if cmd == nil {
+		m.ReplyError("No command **%s** found.", m.Params.String("command"))
+		return
+	}
+	response, err := services.ParseCommandResponse(cmd.Response)
+	if err != nil {
+		core.LogErrorF("Showing command %s without its response: %s", cmd.Command, err)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s%s**\n", m.Prefix, cmd.Command))
+	if cmd.Value != "" {
+		sb.WriteString("**Text:**" + codeBlock(cmd.Value))
+	}
+	if response != nil {
+		if response.Embed != nil {
+			sb.WriteString("**Embed:**" + codeBlock(services.FormatEmbedSpec(response.Embed)))
+		}
+		if len(response.Files) > 0 {
+			sb.WriteString("**Files:** " + strings.Join(response.Files, ", ") + "\n")
+		}
+		for i, message := range response.Messages {
+			sb.WriteString(fmt.Sprintf("**Message %d:**%s", i+2, codeBlock(message)))
+		}
+	}
+	m.ReplyToChannel("%s", strings.TrimSpace(sb.String()))
+}
+
+// codeBlock quotes text in a code block. A zero width space keeps code fences in the text from closing it.
+func codeBlock(text string) string {
+	return "\n```\n" + strings.ReplaceAll(text, "```", "`​``") + "\n```\n"
+}
+
+// aliasReplies builds the replies to a command: its text with the embed and files, followed by its other messages
+func aliasReplies(cmd *database.CommandAlias, m *dispatch.Message) []dispatch.Reply {
+	first := dispatch.Reply{Content: aliasText(cmd, m)}
+	response, err := services.ParseCommandResponse(cmd.Response)
+	if err != nil {
+		core.LogErrorF("Sending command %s without its response: %s", cmd.Command, err)
+	}
+	if response == nil {
+		return []dispatch.Reply{first}
+	}
+	render := func(text string) string { return renderAliasText(cmd, m, text) }
+	if response.Embed != nil {
+		first.Embeds = []*discordgo.MessageEmbed{response.Embed.Render(render)}
+	}
+	for _, name := range response.Files {
+		data, err := os.ReadFile(services.CommandFilePath(name))
+		if err != nil {
+			core.LogErrorF("Sending command %s without file %s: %s", cmd.Command, name, err)
+			continue
+		}
+		first.Files = append(first.Files, &discordgo.File{Name: name, Reader: bytes.NewReader(data)})
+	}
+	var replies []dispatch.Reply
+	if first.Content != "" || len(first.Embeds) > 0 || len(first.Files) > 0 {
+		replies = append(replies, first)
+	}
+	for _, message := range response.Messages {
+		replies = append(replies, dispatch.Reply{Content: render(message)})
+	}
+	return replies
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+)
+
+type commandRules struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	SetCooldown  = "setcooldown"
+	SetChannels  = "setchannels"
+	SetRoles     = "setroles"
+	SetExplain   = "setexplain"
+	CommandRules = "cmdrules"
+
+	defaultCooldown = "default" // Use the guild cooldown again
+	anyChannel      = "any"     // Clear the channel restriction
+)
+
+var roleValuePattern = regexp.MustCompile(`^(?:<@&)?([0-9]+)>?$`)
+
+func (*commandRules) CommandGroup() string {
+	return "Custom Command Management"
+}
+
+func init() {
+	commandArg := dispatch.Arg{Name: "command", Type: dispatch.ArgString}
+	dispatch.Register(&commandRules{},
+		[]dispatch.MessageCommand{
+			{Command: SetCooldown, Permission: core.PermissionAdmin, Help: "Set the cooldown of a custom command, instead of the server cooldown.",
+				Args: []dispatch.Arg{commandArg,
+					{Name: "seconds", Type: dispatch.ArgString, Help: "Seconds between uses, 0 for none, or `default` for the server cooldown"},
+					{Name: "per", Type: dispatch.ArgString, Optional: true, Choices: []string{database.CooldownPerChannel, database.CooldownPerUser},
+						Help: "Whether the cooldown is per channel (default) or per user"}},
+				Examples: []string{"faq 60", "faq 300 user", "faq default"}},
+			{Command: SetChannels, Permission: core.PermissionAdmin, Help: "Limit the channels a custom command can be used in.",
+				Args: []dispatch.Arg{commandArg,
+					{Name: "mode", Type: dispatch.ArgString, Choices: []string{database.ChannelsAllow, database.ChannelsDeny, anyChannel},
+						Help: "Only allow the channels, allow everywhere but the channels, or allow any channel"},
+					{Name: "channels", Type: dispatch.ArgRest, Optional: true}},
+				Examples: []string{"faq allow #help #bot-spam", "faq deny #general", "faq any"}},
+			{Command: SetRoles, Permission: core.PermissionAdmin, Help: "Require one of the Discord roles to use a custom command, or remove the requirement.",
+				Args:     []dispatch.Arg{commandArg, {Name: "roles", Type: dispatch.ArgRest, Optional: true}},
+				Examples: []string{"faq @Member @Trusted", "faq"}},
+			{Command: SetExplain, Permission: core.PermissionAdmin,
+				Help: "Toggle whether a custom command replies with the reason when it can't be used, or is silently ignored.",
+				Args: []dispatch.Arg{commandArg}},
+			{Command: CommandRules, Permission: core.PermissionAdmin, Help: "Show the cooldown, channels and roles of a custom command.",
+				Args: []dispatch.Arg{commandArg}},
+		},
+		nil, false)
+}
+
+func (*commandRules) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case SetCooldown, SetChannels, SetRoles, SetExplain, CommandRules:
+	default:
+		return false
+	}
+	cmd := database.FetchCommandAlias(m.Params.String("command"))
+	if cmd == nil {
+		m.ReplyError("No command **%s** found.", m.Params.String("command"))
+		return true
+	}
+	switch m.Command {
+	case SetCooldown:
+		setCooldown(m, cmd)
+	case SetChannels:
+		setChannels(m, cmd)
+	case SetRoles:
+		setRoles(m, cmd)
+	case SetExplain:
+		toggleExplain(m, cmd)
+	case CommandRules:
+		m.ReplyToChannel("**%s%s**\n%s", m.Prefix, cmd.Command, strings.Join(describeRules(&cmd.CommandRules), "\n"))
+	}
+	return true
+}
+
+func setCooldown(m *dispatch.Message, cmd *database.CommandAlias) {
+	var seconds *int
+	if value := m.Params.String("seconds"); !strings.EqualFold(value, defaultCooldown) {
+		s, err := strconv.Atoi(value)
+		if err != nil || s < 0 {
+			m.ReplyError("The cooldown must be a number of seconds or `%s`, got `%s`.", defaultCooldown, value)
+			return
+		}
+		seconds = &s
+	}
+	scope := database.CooldownPerChannel
+	if m.Params.Has("per") {
+		scope = m.Params.String("per")
+	}
+	if !database.SetCommandCooldown(cmd.Command, seconds, scope) {
+		m.ReplyToChannel("Internal error. Unable to update command alias.")
+		return
+	}
+	cmd.Cooldown, cmd.CooldownScope = seconds, scope
+	recordRevision(m, cmd.Command, database.RevisionRules)
+	core.LogInfoF("%s set the cooldown of command %s to %s.", m.Author.Username, cmd.Command, describeCooldown(&cmd.CommandRules))
+	m.ReplyToChannel("Cooldown of **%s** set to %s.", cmd.Command, describeCooldown(&cmd.CommandRules))
+}
+
+func setChannels(m *dispatch.Message, cmd *database.CommandAlias) {
+	mode := m.Params.String("mode")
+	var channelIds []string
+	for _, channel := range strings.FieldsFunc(m.Params.String("channels"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		match := channelValuePattern.FindStringSubmatch(channel)
+		if match == nil {
+			m.ReplyError("`%s` isn't a channel.", channel)
+			return
+		}
+		channelIds = append(channelIds, match[1])
+	}
+	if mode == anyChannel {
+		mode, channelIds = "", nil
+	} else if len(channelIds) == 0 {
+		m.ReplyError("List the channels to %s.", mode)
+		return
+	}
+	if !database.SetCommandChannels(cmd.Command, mode, channelIds) {
+		m.ReplyToChannel("Internal error. Unable to update command alias.")
+		return
+	}
+	cmd.ChannelMode, cmd.Channels = mode, strings.Join(channelIds, ",")
+	recordRevision(m, cmd.Command, database.RevisionRules)
+	core.LogInfoF("%s set the channels of command %s to %s.", m.Author.Username, cmd.Command, describeChannels(&cmd.CommandRules))
+	m.ReplyToChannel("**%s** can now be used %s.", cmd.Command, describeChannels(&cmd.CommandRules))
+}
+
+func setRoles(m *dispatch.Message, cmd *database.CommandAlias) {
+	var roleIds []string
+	for _, role := range strings.Fields(m.Params.String("roles")) {
+		match := roleValuePattern.FindStringSubmatch(role)
+		if match == nil {
+			m.ReplyError("`%s` isn't a role.", role)
+			return
+		}
+		roleIds = append(roleIds, match[1])
+	}
+	if !database.UpdateCommandAlias(database.CommandField, cmd.Command, database.RolesField, strings.Join(roleIds, ",")) {
+		m.ReplyToChannel("Internal error. Unable to update command alias.")
+		return
+	}
+	cmd.Roles = strings.Join(roleIds, ",")
+	recordRevision(m, cmd.Command, database.RevisionRules)
+	core.LogInfoF("%s set the roles of command %s to %s.", m.Author.Username, cmd.Command, describeRoles(&cmd.CommandRules))
+	m.ReplyToChannel("**%s** can now be used by %s.", cmd.Command, describeRoles(&cmd.CommandRules))
+}
+
+func toggleExplain(m *dispatch.Message, cmd *database.CommandAlias) {
+	explain := !cmd.ExplainDenied
+	if !database.UpdateCommandAlias(database.CommandField, cmd.Command, database.ExplainField, explain) {
+		m.ReplyToChannel("Internal error. Unable to update command alias.")
+		return
+	}
+	recordRevision(m, cmd.Command, database.RevisionRules)
+	core.LogInfoF("%s set explaining denied uses of command %s to %t.", m.Author.Username, cmd.Command, explain)
+	if explain {
+		m.ReplyToChannel("**%s** will now say why when it can't be used.", cmd.Command)
+	} else {
+		m.ReplyToChannel("**%s** will now be silently ignored when it can't be used.", cmd.Command)
+	}
+}
+
+// describeRules lists the cooldown, channels and roles of a command
+func describeRules(cmd *database.CommandRules) []string {
+	return []string{
+		"**Cooldown:** " + describeCooldown(cmd),
+		"**Channels:** " + describeChannels(cmd),
+		"**Roles:** " + describeRoles(cmd),
+		"**Explains denied uses:** " + yesNo(cmd.ExplainDenied),
+	}
+}
+
+func describeCooldown(cmd *database.CommandRules) string {
+	switch {
+	case cmd.Cooldown == nil:
+		return "the server cooldown"
+	case *cmd.Cooldown == 0:
+		return "none"
+	default:
+		return fmt.Sprintf("%d seconds per %s", *cmd.Cooldown, cmd.CooldownScope)
+	}
+}
+
+func describeChannels(cmd *database.CommandRules) string {
+	channels := mentionIds("<#%s>", cmd.ChannelIds())
+	switch cmd.ChannelMode {
+	case database.ChannelsAllow:
+		return "only in " + channels
+	case database.ChannelsDeny:
+		return "everywhere but " + channels
+	default:
+		return "in any channel"
+	}
+}
+
+func describeRoles(cmd *database.CommandRules) string {
+	if cmd.Roles == "" {
+		return "everyone"
+	}
+	return "members with " + mentionIds("<@&%s>", cmd.RoleIds())
+}
+
+func mentionIds(format string, ids []string) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = fmt.Sprintf(format, id)
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// commandDenied returns why the author can't use a command where the message was sent, or "" if they can.
+// Channel and role restrictions only allow DMs when they can be met there, i.e. for deny lists.
+func commandDenied(cmd *database.CommandAlias, m *dispatch.Message) string {
+	switch cmd.ChannelMode {
+	case database.ChannelsAllow:
+		if !slices.Contains(cmd.ChannelIds(), m.ChannelID) {
+			return fmt.Sprintf("**%s%s** can only be used in %s.", m.Prefix, cmd.Command, mentionIds("<#%s>", cmd.ChannelIds()))
+		}
+	case database.ChannelsDeny:
+		if slices.Contains(cmd.ChannelIds(), m.ChannelID) {
+			return fmt.Sprintf("**%s%s** can't be used in this channel.", m.Prefix, cmd.Command)
+		}
+	}
+	if cmd.Roles != "" && !m.HasAnyRole(cmd.RoleIds()) {
+		if m.GuildID == "" {
+			return fmt.Sprintf("**%s%s** can only be used in the server.", m.Prefix, cmd.Command)
+		}
+		return fmt.Sprintf("**%s%s** is only for members with %s.", m.Prefix, cmd.Command, mentionIds("<@&%s>", cmd.RoleIds()))
+	}
+	return ""
+}
+
+// commandCooldown returns how long a command with a cooldown of its own is still on cooldown for the channel or
+// author of the message, starting it over if it isn't. Commands using the server cooldown are left to the dispatcher.
+func commandCooldown(cmd *database.CommandAlias, m *dispatch.Message) time.Duration {
+	if cmd.Cooldown == nil {
+		return 0
+	}
+	key := m.ChannelID
+	if cmd.CooldownScope == database.CooldownPerUser {
+		key = "user:" + m.Author.ID
+	}
+	return dispatch.CooldownRemaining(cmd.Command, key, *cmd.Cooldown)
+}
+
+// allowCommandAlias checks the restrictions and cooldown of a command, replying with the reason it can't be used
+// if the command explains that
+func allowCommandAlias(cmd *database.CommandAlias, m *dispatch.Message) bool {
+	if reason := commandDenied(cmd, m); reason != "" {
+		m.MarkFailed()
+		if cmd.ExplainDenied {
+			m.ReplyToChannel("%s", reason)
+		}
+		return false
+	}
+	if remaining := commandCooldown(cmd, m); remaining > 0 {
+		if cmd.ExplainDenied {
+			m.ReplyToChannel("**%s%s** is on cooldown, try again in %d seconds.", m.Prefix, cmd.Command,
+				int(math.Ceil(remaining.Seconds())))
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+	"GoBot/core/i18n"
+)
+
+type config struct {
+	dispatch.NoOpMessageHandler
+}
+
+const ConfigCommand = "config"
+
+var channelValuePattern = regexp.MustCompile(`^(?:<#)?([0-9]+)>?$`)
+
+func (*config) CommandGroup() string {
+	return "Bot Configuration"
+}
+
+func init() {
+	var keys []string
+	for _, key := range database.GuildSettingKeys {
+		keys = append(keys, string(key))
+	}
+	dispatch.Register(&config{},
+		[]dispatch.MessageCommand{
+			{Command: ConfigCommand, Permission: core.PermissionAdmin, Help: "Show or change the bot settings for this server.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{
+					{Name: "action", Type: dispatch.ArgString, Help: "What to do", Choices: []string{"get", "set", "reset"}},
+					{Name: "setting", Type: dispatch.ArgString, Optional: true, Help: "Setting name", Choices: keys},
+					{Name: "value", Type: dispatch.ArgRest, Optional: true, Help: "New value. Channels can be given as #mentions, use 'none' to clear"},
+				},
+				Examples: []string{"get", "set prefix !", "set botchannels #bot-spam #bot-test", "set suggestions silent #general", "set locale de", "reset cooldown"}},
+		},
+		nil, false)
+}
+
+func (*config) HandleCommand(m *dispatch.Message) bool {
+	if m.Command != ConfigCommand {
+		return false
+	}
+	if m.GuildID == "" {
+		m.ReplyError("Settings can only be changed in a server.")
+		return true
+	}
+
+	key := database.GuildSettingKey(m.Params.String("setting"))
+	switch m.Params.String("action") {
+	case "get":
+		showGuildSettings(m, key)
+	case "set":
+		setGuildSetting(m, key)
+	case "reset":
+		if key == "" {
+			m.ReplyError("Which setting should be reset?")
+			return true
+		}
+		// Suggestions can be reset for a single channel
+		if key == database.GuildSuggestions && m.Params.Has("value") {
+			match := channelValuePattern.FindStringSubmatch(m.Params.String("value"))
+			if match == nil {
+				m.ReplyError("`%s` isn't a channel", m.Params.String("value"))
+				return true
+			}
+			key = database.ChannelSettingKey(key, match[1])
+		}
+		if !database.ResetGuildSetting(m.GuildID, key) {
+			m.ReplyToChannel("`%s` is already using the default value.", key)
+			return true
+		}
+		m.ReplyToChannel("`%s` reset to the default.", key)
+	}
+	return true
+}
+
+func showGuildSettings(m *dispatch.Message, key database.GuildSettingKey) {
+	settings := m.GuildSettings()
+	keys := database.GuildSettingKeys
+	if key != "" {
+		keys = []database.GuildSettingKey{key}
+	}
+	var output []string
+	for _, k := range keys {
+		source := "default"
+		if settings.IsOverridden(k) {
+			source = "server"
+		}
+		output = append(output, fmt.Sprintf("**%s**: %s *(%s)*", k, formatGuildSetting(settings, k), source))
+	}
+	m.ReplyToChannel("%s", strings.Join(output, "\n"))
+}
+
+func setGuildSetting(m *dispatch.Message, key database.GuildSettingKey) {
+	if key == "" || !m.Params.Has("value") {
+		m.ReplyError("Usage: `%s%s set <setting> <value>`", m.Prefix, ConfigCommand)
+		return
+	}
+	storedKey, value, err := normalizeGuildSetting(key, m.Params.String("value"))
+	if err != nil {
+		m.ReplyError("%s", err)
+		return
+	}
+	if !database.SetGuildSetting(m.GuildID, storedKey, value) {
+		m.ReplyError("Failed to save `%s`.", key)
+		return
+	}
+	m.ReplyToChannel("`%s` set to %s", key, formatGuildSetting(m.GuildSettings(), key))
+}
+
+// normalizeSuggestionMode validates "<mode> [#channel]", a channel sets the mode for just that channel
+func normalizeSuggestionMode(value string) (database.GuildSettingKey, string, error) {
+	key := database.GuildSuggestions
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return key, "", fmt.Errorf("usage: `suggestions <%s|%s|%s> [#channel]`", core.SuggestAlways, core.SuggestSilent, core.SuggestBotChannels)
+	}
+	mode := strings.ToLower(fields[0])
+	if mode != core.SuggestAlways && mode != core.SuggestSilent && mode != core.SuggestBotChannels {
+		return key, "", fmt.Errorf("suggestions must be one of: %s, %s, %s", core.SuggestAlways, core.SuggestSilent, core.SuggestBotChannels)
+	}
+	if len(fields) == 2 {
+		match := channelValuePattern.FindStringSubmatch(fields[1])
+		if match == nil {
+			return key, "", fmt.Errorf("`%s` isn't a channel", fields[1])
+		}
+		key = database.ChannelSettingKey(key, match[1])
+	}
+	return key, mode, nil
+}
+
+// normalizeGuildSetting validates a setting value and converts it to the stored key and format
+func normalizeGuildSetting(key database.GuildSettingKey, value string) (database.GuildSettingKey, string, error) {
+	value = strings.TrimSpace(value)
+	if key == database.GuildSuggestions {
+		return normalizeSuggestionMode(value)
+	}
+	switch key {
+	case database.GuildPrefix:
+		if value == "" || strings.ContainsAny(value, " \t\n") {
+			return key, "", fmt.Errorf("the prefix can't be empty or contain spaces")
+		}
+		return key, value, nil
+	case database.GuildCooldown:
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return key, "", fmt.Errorf("the cooldown must be a whole number of seconds")
+		}
+		return key, strconv.Itoa(seconds), nil
+	case database.GuildLocale:
+		locale, ok := i18n.Parse(value)
+		if !ok {
+			return key, "", fmt.Errorf("unknown language `%s`, use one of: %s", value, availableLanguages())
+		}
+		return key, locale, nil
+	case database.GuildBotChannels, database.GuildCarrierUpdateChannel, database.GuildFlightLogChannel:
+		if strings.EqualFold(value, "none") {
+			return key, "", nil
+		}
+		var channelIds []string
+		for _, channel := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			match := channelValuePattern.FindStringSubmatch(channel)
+			if match == nil {
+				return key, "", fmt.Errorf("`%s` isn't a channel", channel)
+			}
+			channelIds = append(channelIds, match[1])
+		}
+		if key != database.GuildBotChannels && len(channelIds) != 1 {
+			return key, "", fmt.Errorf("`%s` takes a single channel", key)
+		}
+		return key, strings.Join(channelIds, ","), nil
+	default:
+		return key, "", fmt.Errorf("unknown setting `%s`", key)
+	}
+}
+
+func formatGuildSetting(settings *database.GuildSettings, key database.GuildSettingKey) string {
+	switch key {
+	case database.GuildPrefix:
+		return "`" + settings.CommandPrefix + "`"
+	case database.GuildCooldown:
+		return fmt.Sprintf("%d seconds", settings.CustomCommandCooldown)
+	case database.GuildLocale:
+		return fmt.Sprintf("`%s` (%s)", settings.Locale, i18n.Name(settings.Locale))
+	case database.GuildSuggestions:
+		output := "`" + settings.SuggestionMode + "`"
+		for _, channelId := range slices.Sorted(maps.Keys(settings.ChannelSuggestionModes)) {
+			output += fmt.Sprintf(", <#%s>: `%s`", channelId, settings.ChannelSuggestionModes[channelId])
+		}
+		return output
+	default:
+		var channels []string
+		for _, id := range strings.Split(settings.Value(key), ",") {
+			if id != "" {
+				channels = append(channels, "<#"+id+">")
+			}
+		}
+		if len(channels) == 0 {
+			return "none"
+		}
+		return strings.Join(channels, ", ")
+	}
+}
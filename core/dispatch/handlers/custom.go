@@ -2,21 +2,17 @@ package handlers
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"GoBot/core"
 	"GoBot/core/database"
 	"GoBot/core/dispatch"
+	"GoBot/core/services"
 	"github.com/thoas/go-funk"
 )
 
-var (
-	commandCooldowns   = make(map[string]time.Time)
-	commandCooldownsMu sync.Mutex
-)
-
 type custom struct {
 	dispatch.NoOpMessageHandler
 }
@@ -32,7 +28,7 @@ const (
 	DeleteCategory     = "delcat"
 	ListCommands       = "listcmds"
 
-	SecureChannnel = "546085681219239936"
+	commandsPerPage = 15 // Categories and uncategorised commands on a page of listcmds
 )
 
 func (*custom) CommandGroup() string {
@@ -40,26 +36,37 @@ func (*custom) CommandGroup() string {
 }
 
 func init() {
+	commandArg := dispatch.Arg{Name: "command", Type: dispatch.ArgString}
+	textArg := dispatch.Arg{Name: "text", Type: dispatch.ArgRest,
+		Help: "Text to reply with. Placeholders such as {user}, {mention}, {args}, {arg1}, {random:a|b}, {time} or {carrier:ID.location} are filled in."}
+	embedFlag := dispatch.Flag{Name: "embed", Short: "e",
+		Help: "The text is an embed, one `title:`, `url:`, `color:`, `image:`, `thumbnail:`, `footer:`, `field: Name | Value` or `inline: Name | Value` per line, the other lines are the description. Use `none` to remove it."}
 	dispatch.Register(&custom{},
 		[]dispatch.MessageCommand{
-			{AddCommand, "Add new command. Arguments: *<command> <text>*"},
-			{RemoveCommand, "Remove existing command. Arguments: *<command>*"},
-			{EditCommand, "Replace text for existing command. Arguments: *<command> <new text>*"},
-			{SetHelpText, "Set (or remove) a help string for an existing command or category. Arguments: *<command or category> [help text]*"},
-			{AddToCategory, "Add an existing command to a category. Category will be created if it doesn't exist. Arguments: *<category> <command>*"},
-			{RemoveFromCategory, "Remove a command from a category. Arguments: *<command>*"},
-			{DeleteCategory, "Delete an existing category. Commands in the category will not be removed. Arguments: *<category>*"},
-			{ListCommands, "List existing custom commands and categories."},
-			{SetIsDm, "Toggle whether or not the output from this command is sent in a DM or not."},
+			{Command: AddCommand, Permission: core.PermissionAdmin, Help: "Add new command.", Args: []dispatch.Arg{commandArg, textArg},
+				Flags: []dispatch.Flag{embedFlag},
+				Examples: []string{"hello Hello {user}!", "where {carrier:W7H-6DZ.name} is in {carrier:W7H-6DZ.location}, departing {carrier:W7H-6DZ.departure}.",
+					"--embed faq title: Carrier FAQ"}},
+			{Command: RemoveCommand, Permission: core.PermissionAdmin, Help: "Remove existing command.", Args: []dispatch.Arg{commandArg}},
+			{Command: EditCommand, Permission: core.PermissionAdmin, Help: "Replace text for existing command, or its embed, or add another message to send after it.",
+				Args: []dispatch.Arg{commandArg, textArg},
+				Flags: []dispatch.Flag{embedFlag, {Name: "append", Short: "a", Help: "Send the text as another message after the others"}},
+				Examples: []string{"hello Hi {user}!", "--embed faq title: Carrier FAQ", "--embed faq none", "--append faq See also {channel} pins."}},
+			{Command: SetHelpText, Permission: core.PermissionAdmin, Help: "Set (or remove) a help string for an existing command or category.",
+				Args: []dispatch.Arg{{Name: "command or category", Type: dispatch.ArgString}, {Name: "help text", Type: dispatch.ArgRest, Optional: true}}},
+			{Command: AddToCategory, Permission: core.PermissionAdmin, Help: "Add an existing command to a category. Category will be created if it doesn't exist.",
+				Args: []dispatch.Arg{{Name: "category", Type: dispatch.ArgString}, commandArg}},
+			{Command: RemoveFromCategory, Permission: core.PermissionAdmin, Help: "Remove a command from a category.", Args: []dispatch.Arg{commandArg}},
+			{Command: DeleteCategory, Permission: core.PermissionAdmin, Help: "Delete an existing category. Commands in the category will not be removed.",
+				Args: []dispatch.Arg{{Name: "category", Type: dispatch.ArgString}}},
+			{Command: ListCommands, Help: "List existing custom commands and categories."},
+			{Command: SetIsDm, Permission: core.PermissionAdmin, Help: "Toggle whether or not the output from this command is sent in a DM or not.", Args: []dispatch.Arg{commandArg}},
 		},
 		nil, true)
+	dispatch.RegisterPaginator(commandPages)
 }
 
 func (c *custom) SecureHandleCommand(m *dispatch.Message) bool {
-	if m.ChannelID != SecureChannnel {
-		m.ReplyToChannel("Sorry, but no.")
-		return true
-	}
 	switch m.Command {
 	case AddCommand:
 		addCommand(m)
@@ -101,76 +108,62 @@ func (c *custom) HandleCommand(m *dispatch.Message) bool {
 	return true
 }
 
-func getCommandText(m *dispatch.Message) *string {
-	if m.RawArgs == nil {
-		m.ReplyToChannel("Missing command alias text.")
-		return nil
-	}
-	text := strings.Join(m.RawArgs[1:], " ")
-	return &text
-}
-
 func deleteCategory(m *dispatch.Message) {
-	if len(m.Args) != 1 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <category>")
-		return
-	}
-	catName := m.Args[0]
+	catName := m.Params.String("category")
 	cat := database.FetchCommandGroup(catName)
 	if cat == nil {
-		m.ReplyToChannel("**Error:** No category named **%s** found.", catName)
+		m.ReplyError("No category named **%s** found.", catName)
 		return
 	}
 
+	commands := cat.FetchCommands()
 	database.UpdateCommandAlias(database.GroupIdField, cat.Id, database.GroupIdField, nil)
+	for _, cmd := range commands {
+		recordRevision(m, cmd.Command, database.RevisionCategory)
+	}
 	if !database.RemoveCommandGroup(catName) {
-		m.ReplyToChannel("**Error:** Failed to remove command group %s.", catName)
+		m.ReplyError("Failed to remove command group %s.", catName)
 		return
 	}
 	m.ReplyToChannel("Removed command group %s.", catName)
 }
 
 func addToCategory(m *dispatch.Message) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <category> <command>")
-		return
-	}
+	category, cmd := m.Params.String("category"), m.Params.String("command")
 
-	if !database.HasCommandAlias(m.Args[1]) {
-		m.ReplyToChannel("Command **%s** doesn't exist.", m.Args[1])
+	if !database.HasCommandAlias(cmd) {
+		m.ReplyToChannel("Command **%s** doesn't exist.", cmd)
 		return
 	}
 
-	if database.HasCommandAlias(m.Args[0]) || dispatch.Dispatcher.HasCommand(m.Args[0]) {
-		m.ReplyToChannel("Error: Cannot add category **%s** since there's already a command with that name.", m.Args[0])
+	if database.HasCommandAlias(category) || dispatch.Dispatcher.HasCommand(category) {
+		m.ReplyToChannel("Error: Cannot add category **%s** since there's already a command with that name.", category)
 		return
 	}
 
-	categoryObj := database.FetchOrCreateCommandGroup(m.Args[0])
+	categoryObj := database.FetchOrCreateCommandGroup(category)
 	if categoryObj == nil {
 		// Make new category.
-		m.ReplyToChannel("Internal Error: Unable to load or create category **%s**.", m.Args[0])
+		m.ReplyToChannel("Internal Error: Unable to load or create category **%s**.", category)
 		return
 	}
 	commands := categoryObj.FetchCommands()
 	for _, cmdObj := range commands {
-		if cmdObj.Command == m.Args[1] {
-			m.ReplyToChannel("Command **%s** already in category **%s**.", m.Args[1], m.Args[0])
+		if cmdObj.Command == cmd {
+			m.ReplyToChannel("Command **%s** already in category **%s**.", cmd, category)
 			return
 		}
 	}
-	if !database.UpdateCommandAlias(database.CommandField, m.Args[1], database.GroupIdField, categoryObj.Id) {
-		m.ReplyToChannel("Internal Error: Failed to add command **%s** to category **%s**.", m.Args[1], m.Args[0])
+	if !database.UpdateCommandAlias(database.CommandField, cmd, database.GroupIdField, categoryObj.Id) {
+		m.ReplyToChannel("Internal Error: Failed to add command **%s** to category **%s**.", cmd, category)
+		return
 	}
-	m.ReplyToChannel("Command **%s** added to category **%s**.", m.Args[1], m.Args[0])
+	recordRevision(m, cmd, database.RevisionCategory)
+	m.ReplyToChannel("Command **%s** added to category **%s**.", cmd, category)
 }
 
 func removeFromCategory(m *dispatch.Message) {
-	if len(m.Args) != 1 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command>")
-		return
-	}
-	cmdName := m.Args[0]
+	cmdName := m.Params.String("command")
 	cmd := database.FetchCommandAlias(cmdName)
 	if cmd == nil {
 		m.ReplyToChannel("No command named **%s** found.", cmdName)
@@ -184,56 +177,75 @@ func removeFromCategory(m *dispatch.Message) {
 		m.ReplyToChannel("Failed to remove category from command **%s**.", cmdName)
 		return
 	}
+	recordRevision(m, cmdName, database.RevisionCategory)
 	m.ReplyToChannel("**%s** removed from category successfully.", cmdName)
 }
 
 func addCommand(m *dispatch.Message) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command> <new text>")
-		return
-	}
-	cmd := m.Args[0]
-	if dispatch.Dispatcher.HasCommand(cmd) {
-		m.ReplyToChannel("**Error:** Command **%s** is a predefined command. Pick another name.", cmd)
+	cmd := m.Params.String("command")
+	if builtin := BuiltinConflict(cmd); builtin != "" {
+		m.ReplyError("Command **%s** would be handled by %s. Pick another name.", cmd, builtin)
 		return
 	}
 	if database.HasCommandAlias(cmd) {
-		m.ReplyToChannel("**Error:** Command **%s** already exists. Use `%s%s` instead.", cmd,
-			core.Settings.CommandPrefix(), EditCommand)
+		m.ReplyError("Command **%s** already exists. Use `%s%s` instead.", cmd,
+			m.Prefix, EditCommand)
 		return
 	}
 	if database.HasCommandGroup(cmd) {
-		m.ReplyToChannel("**Error:** Cannot add command **%s** since there's already a category with that name.", cmd)
+		m.ReplyError("Cannot add command **%s** since there's already a category with that name.", cmd)
+		return
+	}
+	if m.Params.Flag("embed") {
+		addEmbedCommand(m, cmd)
+		return
+	}
+	if !validTemplate(m) {
 		return
 	}
 
-	if commandText := getCommandText(m); commandText != nil {
-		ok := database.CreateCommandAlias(cmd, *commandText)
-		if ok {
-			core.LogInfoF("%s added command alias %s.", m.Author.Username, cmd)
-			m.ReplyToChannel("Command alias for **%s** created successfully.", cmd)
-			return
-		}
+	if database.CreateCommandAlias(cmd, m.Params.String("text")) {
+		recordRevision(m, cmd, database.RevisionCreate)
+		core.LogInfoF("%s added command alias %s.", m.Author.Username, cmd)
+		m.ReplyToChannel("Command alias for **%s** created successfully.", cmd)
+		return
 	}
 	core.LogDebug("Command was not created.")
 	m.ReplyToChannel("Internal error. Unable to create command alias.")
 }
 
-func setHelpText(m *dispatch.Message) {
-	var cmd string
-	var helpText *string
+// validTemplate checks the placeholders in the text of a command, replying with the problem if they're invalid
+func validTemplate(m *dispatch.Message) bool {
+	if _, err := services.ParseTemplate(m.Params.String("text")); err != nil {
+		m.ReplyError("Invalid placeholder in the command text: %s.", err)
+		return false
+	}
+	return true
+}
 
-	switch len(m.Args) {
-	case 0:
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command> [optional new help text]")
+// BuiltinConflict describes the built-in command or prefix that handles a command name, or returns "" if there is none
+func BuiltinConflict(cmd string) string {
+	name, isPrefix, found := dispatch.Dispatcher.BuiltinRoute(cmd)
+	switch {
+	case !found:
+		return ""
+	case isPrefix:
+		return fmt.Sprintf("the built-in prefix **%s**", name)
 	default:
-		helpText = getCommandText(m)
-		fallthrough
-	case 1:
-		cmd = m.Args[0]
+		return "the built-in command of the same name"
+	}
+}
+
+func setHelpText(m *dispatch.Message) {
+	cmd := m.Params.String("command or category")
+	var helpText *string
+	if m.Params.Has("help text") {
+		text := m.Params.String("help text")
+		helpText = &text
 	}
 	if database.HasCommandAlias(cmd) {
 		if database.UpdateCommandAlias(database.CommandField, cmd, database.HelpField, helpText) {
+			recordRevision(m, cmd, database.RevisionHelp)
 			core.LogInfoF("%s updated help text for command %s.", m.Author.Username, cmd)
 			m.ReplyToChannel("Help text for command %s was updated.", cmd)
 		} else {
@@ -249,22 +261,18 @@ func setHelpText(m *dispatch.Message) {
 			m.ReplyToChannel("Internal error. Unable to update command group.")
 		}
 	} else {
-		m.ReplyToChannel("**Error:** No command or command group **%s** found.", cmd)
+		m.ReplyError("No command or command group **%s** found.", cmd)
 	}
 }
 
 func toggleIsDm(m *dispatch.Message) {
-	if len(m.Args) == 0 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command>")
-		return
-	}
-
-	cmd := m.Args[0]
+	cmd := m.Params.String("command")
 
 	cmdAlias := database.FetchCommandAlias(cmd)
 	if cmdAlias != nil {
 		newDm := !cmdAlias.PMEnabled
 		if database.UpdateCommandAlias(database.CommandField, cmd, database.PMEnabledField, newDm) {
+			recordRevision(m, cmd, database.RevisionDM)
 			messageType := "channel"
 			if newDm {
 				messageType = "direct message"
@@ -276,107 +284,120 @@ func toggleIsDm(m *dispatch.Message) {
 			m.ReplyToChannel("Internal error. Unable to toggle sending via DM.")
 		}
 	} else {
-		m.ReplyToChannel("**Error:** No command **%s** found.", cmd)
+		m.ReplyError("No command **%s** found.", cmd)
 	}
 }
 
 func editCommand(m *dispatch.Message) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command> <new text>")
+	cmd := m.Params.String("command")
+	if !database.HasCommandAlias(cmd) {
+		m.ReplyError("Command **%s** doesn't exist. Use `%s%s` instead.", cmd,
+			m.Prefix, AddCommand)
 		return
 	}
-	cmd := m.Args[0]
-	if !database.HasCommandAlias(cmd) {
-		m.ReplyToChannel("**Error:** Command **%s** doesn't exist. Use `%s%s` instead.", cmd,
-			core.Settings.CommandPrefix(), AddCommand)
+	if builtin := BuiltinConflict(cmd); builtin != "" {
+		m.ReplyError("Command **%s** is handled by %s. Remove it with `%s%s` and pick another name.", cmd, builtin,
+			m.Prefix, RemoveCommand)
 		return
 	}
-	if commandText := getCommandText(m); commandText != nil {
-		ok := database.UpdateCommandAlias(database.CommandField, cmd, database.ValueField, commandText)
-		if ok {
-			core.LogInfoF("%s updated command alias %s.", m.Author.Username, cmd)
-			m.ReplyToChannel("Command alias for **%s** updated successfully.", cmd)
-			return
-		}
+	if m.Params.Flag("embed") || m.Params.Flag("append") {
+		editResponse(m, cmd)
+		return
+	}
+	if !validTemplate(m) {
+		return
+	}
+	if database.UpdateCommandAlias(database.CommandField, cmd, database.ValueField, m.Params.String("text")) {
+		recordRevision(m, cmd, database.RevisionEdit)
+		core.LogInfoF("%s updated command alias %s.", m.Author.Username, cmd)
+		m.ReplyToChannel("Command alias for **%s** updated successfully.", cmd)
+		return
 	}
 	core.LogDebug("Command was not updated.")
 	m.ReplyToChannel("Internal error. Unable to update command alias.")
 }
 
 func removeCommand(m *dispatch.Message) {
-	if len(m.Args) == 0 {
-		m.ReplyToChannel("**Error:** Invalid syntax. Expected: <command>.")
+	cmd := m.Params.String("command")
+
+	if !database.HasCommandAlias(cmd) {
+		m.ReplyError("Command **%s** doesn't exist.", cmd)
+		return
+	}
+	// The delete revision keeps the command as it was, so it can be restored with cmdrevert
+	if !recordRevision(m, cmd, database.RevisionDelete) {
+		m.ReplyToChannel("Internal error. Unable to keep a revision of **%s**, so it wasn't removed.", cmd)
 		return
 	}
-	cmd := m.Args[0]
-
 	if !database.RemoveCommandAlias(cmd) {
-		m.ReplyToChannel("**Error:** Command **%s** doesn't exist.", cmd)
+		m.ReplyToChannel("Internal error. Unable to remove command alias.")
 		return
 	}
 	core.LogInfoF("%s removed command alias %s.", m.Author.Username, cmd)
+	if rev := database.FetchLatestCommandRevision(cmd); rev != nil {
+		m.ReplyToChannel("Command %s removed. Restore it with `%s%s %s %d`.", cmd, m.Prefix, RevertCommand, cmd, rev.Revision)
+		return
+	}
 	m.ReplyToChannel("Command %s removed.", cmd)
 }
 
-func listCommands(m *dispatch.Message) {
-	var output []string
-	prefix := core.Settings.CommandPrefix()
-	if groups := database.FetchCommandGroups(); len(groups) > 0 {
-		funk.ForEach(groups, func(group database.CommandGroup) {
-			cmdString := "No commands in category."
-			if cmds := group.FetchCommands(); cmds != nil {
-				cmdString = strings.Join(funk.Map(cmds, func(cmd database.CommandAlias) string { return cmd.Command }).([]string), ", ")
-			}
-			m.ReplyToSender("%s", fmt.Sprintf("**%s%s:**\n\t%s\n", prefix, group.Command, cmdString))
-		})
-	} else {
-		output = append(output, "**Categories:** \n\tNone found")
-	}
-	if fetchedCommands := database.FetchStandaloneCommands(); len(fetchedCommands) > 0 {
-		output = append(output, fmt.Sprint("\n**Uncategorised Commands:**\n\t",
-			strings.Join(funk.Map(fetchedCommands, func(cmd database.CommandAlias) string {
-				return cmd.Command
-			}).([]string), ", ")))
-	} else {
-		output = append(output, "\n**Uncategorised Commands:**\n\tNone found")
-	}
-
-	outputString := strings.Join(output, "\n")
-	m.ReplyToSender("%s", outputString)
+// recordRevision stores the command as a new revision, made by the author of the message
+func recordRevision(m *dispatch.Message, cmd string, action database.RevisionAction) bool {
+	return database.RecordCommandRevision(cmd, action, m.Author.ID, m.Author.Username)
 }
 
-// isOnCooldown checks if a command+channel combo is on cooldown and updates the last used time if not
-func isOnCooldown(command, channelID string) bool {
-	cooldownSeconds := core.Settings.CustomCommandCooldown()
-	if cooldownSeconds <= 0 {
-		return false
-	}
+// commandPages lists the categories with their commands, followed by the uncategorised commands
+var commandPages = &dispatch.Paginator{Name: ListCommands, Size: commandsPerPage, Source: commandsPage}
 
-	// Create a key combining command and channel
-	key := command + ":" + channelID
+func listCommands(m *dispatch.Message) {
+	commandPages.Respond(m, "", true)
+}
 
-	commandCooldownsMu.Lock()
-	defer commandCooldownsMu.Unlock()
+// commandsPage lists a page of the categories and uncategorised commands, in that order
+func commandsPage(m *dispatch.Message, page dispatch.Page) (dispatch.Reply, int) {
+	groupCount := database.CountCommandGroups()
+	total := groupCount + database.CountStandaloneCommands()
 
-	now := time.Now()
-	if lastUsed, exists := commandCooldowns[key]; exists {
-		if now.Sub(lastUsed) < time.Duration(cooldownSeconds)*time.Second {
-			return true
+	var output []string
+	prefix := m.Prefix
+	if page.Offset() < groupCount {
+		if groups := database.FetchCommandGroupsPage(page.Offset(), page.Size); len(groups) > 0 {
+			funk.ForEach(groups, func(group database.CommandGroup) {
+				cmdString := "No commands in category."
+				if cmds := group.FetchCommands(); cmds != nil {
+					cmdString = strings.Join(funk.Map(cmds, func(cmd database.CommandAlias) string { return cmd.Command }).([]string), ", ")
+				}
+				output = append(output, fmt.Sprintf("**%s%s:**\n\t%s", prefix, group.Command, cmdString))
+			})
 		}
+	} else if groupCount == 0 && page.Number == 0 {
+		output = append(output, "**Categories:** \n\tNone found")
 	}
-	commandCooldowns[key] = now
-	return false
+
+	offset := page.Offset() - groupCount
+	limit := page.Size
+	if offset < 0 {
+		limit += offset
+		offset = 0
+	}
+	if limit > 0 {
+		if fetchedCommands := database.FetchStandaloneCommandsPage(offset, limit); len(fetchedCommands) > 0 {
+			output = append(output, fmt.Sprint("\n**Uncategorised Commands:**\n\t",
+				strings.Join(funk.Map(fetchedCommands, func(cmd database.CommandAlias) string {
+					return cmd.Command
+				}).([]string), ", ")))
+		} else if total == groupCount {
+			output = append(output, "\n**Uncategorised Commands:**\n\tNone found")
+		}
+	}
+	return dispatch.Reply{Content: strings.TrimSpace(strings.Join(output, "\n"))}, total
 }
 
 func (*custom) HandleAnything(m *dispatch.Message) bool {
 	if cmd := database.FetchCommandAlias(m.Command); cmd != nil {
-		// Skip cooldown check for DMs and bot channels
-		if !m.IsPM && !core.Settings.IsBotChannel(m.ChannelID) {
-			if isOnCooldown(m.Command, m.ChannelID) {
-				return true // Silently ignore if on cooldown
-			}
+		if allowCommandAlias(cmd, m) {
+			HandleCommandAlias(cmd, m)
 		}
-		HandleCommandAlias(cmd, m)
 		return true
 	}
 
@@ -395,7 +416,7 @@ func HandleCommandGroup(grp *database.CommandGroup, m *dispatch.Message) {
 	}
 	if sortedCommands := grp.FetchCommands(); sortedCommands != nil {
 		for _, command := range sortedCommands {
-			var cmdline = fmt.Sprintf("\t**%s%s**", core.Settings.CommandPrefix(), command.Command)
+			var cmdline = fmt.Sprintf("\t**%s%s**", m.Prefix, command.Command)
 			if command.Help != nil && len(*command.Help) > 0 {
 				cmdline = fmt.Sprint(cmdline, ": ", *command.Help)
 			}
@@ -405,10 +426,15 @@ func HandleCommandGroup(grp *database.CommandGroup, m *dispatch.Message) {
 	m.ReplyToChannel("%s", strings.Join(output, "\n"))
 }
 
+// wantsAliasHelp checks for --help, or the traditional "<command> help" form of asking for alias help
+func wantsAliasHelp(m *dispatch.Message) bool {
+	return m.Params.Flag(dispatch.HelpFlag) || (len(m.Args) == 1 && strings.EqualFold(m.Args[0], "help"))
+}
+
 func HandleCommandAlias(cmd *database.CommandAlias, m *dispatch.Message) {
-	if m.Flags.IsSet(dispatch.Help) {
+	if wantsAliasHelp(m) {
 		if cmd.Help != nil && len(*cmd.Help) > 0 {
-			var helpMessage = fmt.Sprintf("**%s%s**: %s", core.Settings.CommandPrefix(),
+			var helpMessage = fmt.Sprintf("**%s%s**: %s", m.Prefix,
 				cmd.Command, *cmd.Help)
 			if cmd.Longhelp != nil && len(*cmd.Longhelp) > 0 {
 				longHelpMessage := fmt.Sprint(helpMessage, "\n\n", *cmd.Longhelp)
@@ -425,12 +451,53 @@ func HandleCommandAlias(cmd *database.CommandAlias, m *dispatch.Message) {
 			}
 			m.ReplyToChannel("%s", helpMessage)
 		} else {
-			m.ReplyToChannel("**%s%s**: No help available.", core.Settings.CommandPrefix(), cmd.Command)
+			m.ReplyToChannel("**%s%s**: No help available.", m.Prefix, cmd.Command)
 		}
-	} else if cmd.PMEnabled {
-		m.ReplyToSender("%s", cmd.Value)
 	} else {
-		m.ReplyToChannel("%s", cmd.Value)
+		for _, reply := range aliasReplies(cmd, m) {
+			reply.Private = cmd.PMEnabled
+			if m.Respond(reply) != nil {
+				break
+			}
+		}
+	}
+}
 
+// aliasText fills in the placeholders of a command. Commands saved before templates may not parse,
+// those are sent as they are.
+func aliasText(cmd *database.CommandAlias, m *dispatch.Message) string {
+	return renderAliasText(cmd, m, cmd.Value)
+}
+
+// renderAliasText fills in the placeholders of a text of a command, such as its embed or further messages
+func renderAliasText(cmd *database.CommandAlias, m *dispatch.Message, text string) string {
+	template, err := services.ParseTemplate(text)
+	if err != nil {
+		core.LogDebugF("Command %s isn't a valid template, sending it as is: %s", cmd.Command, err)
+		return text
+	}
+	user := m.Author.Username
+	if m.Author.GlobalName != "" {
+		user = m.Author.GlobalName
 	}
+	if m.Member != nil && m.Member.Nick != "" {
+		user = m.Member.Nick
+	}
+	// The text around the placeholders is written by admins and may mention everyone, but what users fill in mustn't
+	args := make([]string, len(m.Args))
+	for i, arg := range m.Args {
+		args[i] = neutralizeMentions(arg)
+	}
+	return template.Render(services.TemplateContext{User: neutralizeMentions(user), UserId: m.Author.ID, ChannelId: m.ChannelID,
+		Args: args, Now: time.Now()})
+}
+
+// massMentionPattern matches the mentions that notify many members at once: everyone, here and roles
+var massMentionPattern = regexp.MustCompile(`@(?:everyone|here)|<@&`)
+
+// neutralizeMentions keeps text from pinging everyone or a role, with a zero width space after the @
+func neutralizeMentions(text string) string {
+	return massMentionPattern.ReplaceAllStringFunc(text, func(mention string) string {
+		return strings.Replace(mention, "@", "@\u200b", 1)
+	})
 }
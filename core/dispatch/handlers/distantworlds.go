@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -36,11 +37,12 @@ func init() {
 	dwehandler := distantWorlds{}
 	dispatch.Register(&dwehandler,
 		[]dispatch.MessageCommand{
-			{WaypointCmd, "Get information about waypoints. Syntax: *wp <waypoint #>* or *wp#*." },
-			{ReloadCmd, ""},
+			{Command: WaypointCmd, Help: "Get information about waypoints. Also available as *wp#*.",
+				Args: []dispatch.Arg{{Name: "waypoint #", Type: dispatch.ArgNumber}}},
+			{Command: ReloadCmd},
 		},
 		[]dispatch.MessageCommand{
-			{WaypointCmd, ""},
+			{Command: WaypointCmd},
 		},
 		false)
 }
@@ -53,8 +55,12 @@ func (*distantWorlds) SettingsLoaded() {
 func (*distantWorlds) HandlePrefix(prefix, suffix string, m *dispatch.Message) bool {
 	switch prefix {
 	case WaypointCmd:
-		m.Args = []string {suffix}
-		handleWaypoint(m)
+		wp, err := strconv.Atoi(suffix)
+		if err != nil {
+			m.ReplyToChannel("Invalid waypoint [%s], expected a number.", suffix)
+			return true
+		}
+		handleWaypoint(m, wp)
 	default:
 		return false
 	}
@@ -64,7 +70,12 @@ func (*distantWorlds) HandlePrefix(prefix, suffix string, m *dispatch.Message) b
 func (c *distantWorlds) HandleCommand(m *dispatch.Message) bool {
 	switch m.Command {
 	case WaypointCmd:
-		handleWaypoint(m)
+		wp := m.Params.Number("waypoint #")
+		if wp != math.Trunc(wp) {
+			m.ReplyToChannel("Invalid waypoint [%s], expected a number.", m.Params.String("waypoint #"))
+			return true
+		}
+		handleWaypoint(m, int(wp))
 	case ReloadCmd:
 		reloadWaypoints(m);
 	default:
@@ -101,26 +112,9 @@ func reloadWaypoints(m *dispatch.Message) {
 }
 
 
-func handleWaypoint(m *dispatch.Message) {
-	var wp int
-	switch len(m.Args) {
-	case 0:
-		m.ReplyToChannel("Missing waypoint.")
-		return
-	case 1:
-		var err error
-		wp, err = strconv.Atoi(m.Args[0])
-		if err != nil {
-			m.ReplyToChannel("Invalid waypoint [%s], expected a number.", m.Args[0])
-			return
-		}
-		if wp <= 0 || wp > len(waypoints) {
-			m.ReplyToChannel("Waypoint %d is invalid, or not yet announced (there are currently %d known waypoints, with 15 expected)", wp, len(waypoints))
-			return
-		}
-		break
-	default:
-		m.ReplyToChannel("Too many arguments, expected waypoint.")
+func handleWaypoint(m *dispatch.Message, wp int) {
+	if wp <= 0 || wp > len(waypoints) {
+		m.ReplyToChannel("Waypoint %d is invalid, or not yet announced (there are currently %d known waypoints, with 15 expected)", wp, len(waypoints))
 		return
 	}
 	waypoint := waypoints[wp - 1]
@@ -1,10 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
-	"net/http"
 	"strconv"
 	"strings"
 
@@ -45,20 +45,23 @@ type SystemModel struct {
 func init() {
 	dispatch.Register(&edsm{},
 		[]dispatch.MessageCommand{
-			{"loc", "Try to get a commanders location from EDSM. Syntax: loc <commander name>"},
-			{"dist", fmt.Sprint("Calculate distance between two locations. Syntax: dist <location> -> <location>. ",
+			{Command: "loc", Help: "Try to get a commanders location from EDSM.",
+				Args: []dispatch.Arg{{Name: "commander name", Type: dispatch.ArgRest}}},
+			{Command: "dist", Help: fmt.Sprint("Calculate distance between two locations, separated by `->`. ",
 				"Locations can be: system names, commander names (EDSM), DW3 carrier names/callsigns, or X Y Z coordinates. ",
-				"Use `carrier` to find the closest DW3 carrier (e.g. `dist NeoTron -> carrier`).")},
+				"Use `carrier` to find the closest DW3 carrier (e.g. `dist NeoTron -> carrier`)."),
+				Args:     []dispatch.Arg{{Name: "location -> location", Type: dispatch.ArgRest}},
+				Examples: []string{"Sol -> Colonia", "NeoTron -> carrier", "0 0 0 -> Beagle Point"}},
 		}, nil, false)
 }
 
 func (s *edsm) HandleCommand(m *dispatch.Message) bool {
 	switch m.Command {
 	case "loc":
-		handleLocationLookup(strings.Join(m.Args, " "), m)
+		handleLocationLookup(m.Params.String("commander name"), m)
 	case "dist":
-		systems := funk.Map(strings.Split(strings.Join(m.Args, " "), "->"), func(arg string) string {
-			return strings.Trim(arg, " \t\n")
+		systems := funk.Map(strings.Split(m.Params.String("location -> location"), "->"), func(arg string) string {
+			return strings.Join(strings.Fields(arg), " ")
 		}).([]string)
 		handleDistance(systems, m)
 	default:
@@ -69,9 +72,9 @@ func (s *edsm) HandleCommand(m *dispatch.Message) bool {
 
 func fetchCommanderLocation(commander string, m *dispatch.Message) *CommanderPositionModel {
 	u, err := core.MakeURL("https://www.edsm.net/api-logs-v1/get-position/", []core.URLParams{
-		{"commanderName", commander},
+		{Key: "commanderName", Val: commander},
 	})
-	res, err := http.Get(u.String())
+	res, err := core.HttpGet(m.Context(), u.String())
 	if err != nil {
 		core.LogError("Failed to query ESDM for commander location: ", err)
 		m.ReplyToChannel("Failed to complete request.")
@@ -170,7 +173,7 @@ func handleClosestCarrier(location string, m *dispatch.Message) {
 	// Check if it's a carrier
 	if coords == nil {
 		if carrierSystem, carrierName, found := getCarrierSystem(location); found {
-			sysResult := lookupSystemCoords(carrierSystem)
+			sysResult := lookupSystemCoords(m.Context(), carrierSystem)
 			if sysResult.HasCoords {
 				coords = sysResult.System.Coords
 				locationName = fmt.Sprintf("%s `(in %s)`", carrierName, carrierSystem)
@@ -180,7 +183,7 @@ func handleClosestCarrier(location string, m *dispatch.Message) {
 
 	// Check if it's a system name
 	if coords == nil {
-		sysResult := lookupSystemCoords(location)
+		sysResult := lookupSystemCoords(m.Context(), location)
 		if sysResult.Error != nil {
 			m.ReplyToChannel("Failed to complete EDSM request.")
 			return
@@ -263,7 +266,7 @@ func handleDistance(s []string, m *dispatch.Message) {
 		"jaques station": "Colonia",
 	}
 	if len(s) != 2 || s[0] == "" || s[1] == "" {
-		m.ReplyToChannel("Invalid syntax. Expected: `%sdist <location> -> <location>` where location can be a system name, commander name, DW3 carrier name/callsign, or `carrier` to find the closest DW3 carrier.", core.Settings.CommandPrefix())
+		m.ReplyToChannel("Invalid syntax. Expected: `%sdist <location> -> <location>` where location can be a system name, commander name, DW3 carrier name/callsign, or `carrier` to find the closest DW3 carrier.", m.Prefix)
 		return
 	}
 
@@ -341,7 +344,7 @@ func handleDistance(s []string, m *dispatch.Message) {
 		}
 
 		// Look up system coordinates by name
-		sysResult := lookupSystemCoords(systemName)
+		sysResult := lookupSystemCoords(m.Context(), systemName)
 		if sysResult.Error != nil {
 			m.ReplyToChannel("Failed to complete EDSM request.")
 			core.LogError("EDSM lookup failed: ", sysResult.Error)
@@ -397,16 +400,16 @@ type SystemLookupResult struct {
 	Error     error
 }
 
-func lookupSystemCoords(systemName string) SystemLookupResult {
+func lookupSystemCoords(ctx context.Context, systemName string) SystemLookupResult {
 	u, err := core.MakeURL("https://www.edsm.net/api-v1/system", []core.URLParams{
-		{"systemName", systemName},
-		{"coords", "1"},
+		{Key: "systemName", Val: systemName},
+		{Key: "coords", Val: "1"},
 	})
 	if err != nil {
 		return SystemLookupResult{Error: err}
 	}
 
-	res, err := http.Get(u.String())
+	res, err := core.HttpGet(ctx, u.String())
 	if err != nil {
 		return SystemLookupResult{Error: err}
 	}
@@ -426,7 +429,7 @@ func lookupSystemCoords(systemName string) SystemLookupResult {
 }
 
 func getSystemCoords(systemName string, m *dispatch.Message) *SystemModel {
-	result := lookupSystemCoords(systemName)
+	result := lookupSystemCoords(m.Context(), systemName)
 	if result.Error != nil {
 		m.ReplyToChannel("Failed to complete EDSM request.")
 		core.LogError("EDSM lookup failed: ", result.Error)
@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strconv"
 	"strings"
 
 	"GoBot/core/dispatch"
@@ -22,12 +21,23 @@ type elitedangerous struct {
 }
 
 func init() {
+	number := func(name, help string, optional bool) dispatch.Arg {
+		return dispatch.Arg{Name: name, Type: dispatch.ArgNumber, Help: help, Optional: optional}
+	}
 	dispatch.Register(&elitedangerous{},
 		[]dispatch.MessageCommand{
-			{"bearing", "Calculate bearing and optional distance between two planetary coordiantes. Args: <lat1> <lon1> <lat2> <lon2> [planet radius in km]"},
-			{"g", "Calculate gravity for a planet. Arguments: <Earth masses> <radius in km>"},
-			{"kly/hr", "Calculate max kly travelled per hour. Arguments: <jump range> [optional: time per jump in seconds (default 45s)] [optional: effiency (default 95)]"},
-			{"route", "Calculate optimal core routing distance. No longer needed, but fun for legacy reasons. Arguments: <jump range> <kly to Sgr A*> [optional: max route length in ly]"},
+			{Command: "bearing", Help: "Calculate bearing and optional distance between two planetary coordiantes.",
+				Args: []dispatch.Arg{number("lat1", "", false), number("lon1", "", false), number("lat2", "", false), number("lon2", "", false),
+					number("radius", "Planet radius in km", true)}},
+			{Command: "g", Help: "Calculate gravity for a planet.", Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{number("mass", "Planet mass in Earth masses", false), number("radius", "Planet radius in km", false)},
+				Examples: []string{"1 6371", "0.05 1800"}},
+			{Command: "kly/hr", Help: "Calculate max kly travelled per hour.",
+				Args: []dispatch.Arg{number("jump range", "Jump range in ly", false), number("time per jump", "Seconds per jump (default 45s)", true),
+					number("efficiency", "Efficiency in percent (default 95)", true)}},
+			{Command: "route", Help: "Calculate optimal core routing distance. No longer needed, but fun for legacy reasons.",
+				Args: []dispatch.Arg{number("jump range", "Jump range in ly", false), number("distance", "kly to Sgr A*", false),
+					number("max plot", "Max route length in ly", true)}},
 		}, nil, false)
 }
 
@@ -40,7 +50,7 @@ func (s *elitedangerous) HandleCommand(m *dispatch.Message) bool {
 	case "bearing":
 		handleBearingAndDistance(m)
 	case "route":
-		go handleRoute(m)
+		handleRoute(m)
 	default:
 		return false
 	}
@@ -52,33 +62,9 @@ func (*elitedangerous) CommandGroup() string {
 }
 
 func handleBearingAndDistance(m *dispatch.Message) {
-	var radius float64
-	var start, end LatLong
-	var err error
-	switch len(m.Args) {
-	case 5:
-		radius, err = strconv.ParseFloat(m.Args[4], 64)
-		if err != nil {
-			m.ReplyToChannel("The radius must be a number.")
-			return
-		}
-		fallthrough
-
-	case 4:
-		lat1, err1 := strconv.ParseFloat(m.Args[0], 64)
-		lon1, err2 := strconv.ParseFloat(m.Args[1], 64)
-		lat2, err3 := strconv.ParseFloat(m.Args[2], 64)
-		lon2, err4 := strconv.ParseFloat(m.Args[3], 64)
-		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
-			m.ReplyToChannel("The coordinates must be a number (lat1 lon1 lat2 lon2 [optional radius in km]).")
-			return
-		}
-		start = NewLatLong(lat1, lon1)
-		end = NewLatLong(lat2, lon2)
-	default:
-		m.ReplyToChannel("Not enough arguments. Expected 4-5 numbers (lat1 lon1 lat2 lon2 [optional radius in km]).")
-		return
-	}
+	radius := m.Params.Number("radius")
+	start := NewLatLong(m.Params.Number("lat1"), m.Params.Number("lon1"))
+	end := NewLatLong(m.Params.Number("lat2"), m.Params.Number("lon2"))
 
 	bearing, distance := calculateBearingAndDistance(start, end, radius)
 	distanceStr := distanceFor(distance)
@@ -86,35 +72,20 @@ func handleBearingAndDistance(m *dispatch.Message) {
 }
 
 func handleKlyPerHour(m *dispatch.Message) {
+	jumpRange := m.Params.Number("jump range")
+	jumpTime := m.Params.NumberOr("time per jump", 45.0)
+	effiency := m.Params.NumberOr("efficiency", 95.0)
 
-	var jumpRange float64
-	var jumpTime = 45.0
-	var effiency = 95.0
-	var err error
-
-	switch len(m.Args) {
-	case 3:
-		effiency, err = strconv.ParseFloat(m.Args[2], 64)
-		if err != nil || effiency <= 0 || effiency >= 100 {
-			m.ReplyToChannel("The efficiency must be a number greater than 0 and smaller than 100.")
-			return
-		}
-		fallthrough
-	case 2:
-		jumpTime, err = strconv.ParseFloat(m.Args[1], 64)
-		if err != nil || jumpTime <= 0 {
-			m.ReplyToChannel("The time per jump must be a number greater than 0.")
-			return
-		}
-		fallthrough
-	case 1:
-		jumpRange, err = strconv.ParseFloat(m.Args[0], 64)
-		if err != nil || jumpRange <= 0 {
-			m.ReplyToChannel("The jump range must be a number greater than 0.")
-			return
-		}
-	default:
-		m.ReplyToChannel("Incorrect arguments. Expected: <jump range> [time per jump in seconds] [efficiency]")
+	if effiency <= 0 || effiency >= 100 {
+		m.ReplyToChannel("The efficiency must be a number greater than 0 and smaller than 100.")
+		return
+	}
+	if jumpTime <= 0 {
+		m.ReplyToChannel("The time per jump must be a number greater than 0.")
+		return
+	}
+	if jumpRange <= 0 {
+		m.ReplyToChannel("The jump range must be a number greater than 0.")
 		return
 	}
 
@@ -144,23 +115,12 @@ var densitySigmaArray = []densitySigma{
 func handleRoute(m *dispatch.Message) {
 	const MaxDistance = 20000.0
 	var maxDistance = MaxDistance
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("Missing arguments. Expected: <JumpRange> <SgrA* distance in kly> [optional max plot in ly]")
-		return
-	}
 
-	jumpRange, errJump := strconv.ParseFloat(m.Args[0], 64)
-	distance, errDist := strconv.ParseFloat(m.Args[0], 64)
-	if errJump != nil || errDist != nil {
-		m.ReplyToChannel("Jump range and distance parameters should be numbers. Expected: <JumpRange> <SgrA* distance in kly> [optional max plot in ly]")
-		return
-	}
+	jumpRange := m.Params.Number("jump range")
+	distance := m.Params.Number("distance")
 
-	if len(m.Args) > 2 {
-		optMaxDistance, err := strconv.ParseFloat(m.Args[2], 64)
-		if err == nil {
-			maxDistance = math.Min(optMaxDistance, maxDistance) // Only reduce it since 1000 ly is maximum routable
-		}
+	if m.Params.Has("max plot") {
+		maxDistance = math.Min(m.Params.Number("max plot"), maxDistance) // Only reduce it since 1000 ly is maximum routable
 	}
 
 	if distance >= 100 {
@@ -193,15 +153,11 @@ func handleRoute(m *dispatch.Message) {
 }
 
 func handleGravity(m *dispatch.Message) {
-	if len(m.Args) < 2 {
-		m.ReplyToChannel("Missing arguments. Expected: <EarthMasses> <Radius in km>")
-		return
-	}
+	planetMass := m.Params.Number("mass")
+	planetRadius := m.Params.Number("radius")
 
-	planetMass, err := strconv.ParseFloat(m.Args[0], 64)
-	planetRadius, err2 := strconv.ParseFloat(m.Args[1], 64)
-	if err != nil || err2 != nil {
-		m.ReplyToChannel("The arguments must be numbers. Expected: <EarthMasses> <Radius in km>")
+	if planetMass <= 0 || planetRadius <= 0 {
+		m.ReplyToChannel("Mass and radius must be greater than 0")
 		return
 	}
 
@@ -17,8 +17,9 @@ type ident struct {
 func init() {
 	dispatch.Register(&ident{},
 		[]dispatch.MessageCommand{
-			{"id", "Return Discord ID for the user, or all @mentioned users"},
-			{"cid", "Return Discord channel id for the current channel."},
+			{Command: "id", Help: "Return Discord ID for the user, or all @mentioned users",
+				Args: []dispatch.Arg{{Name: "@users", Type: dispatch.ArgRest, Optional: true}}},
+			{Command: "cid", Help: "Return Discord channel id for the current channel."},
 		},
 		nil, false)
 }
@@ -30,7 +31,7 @@ func (*ident) HandleCommand(m *dispatch.Message) bool {
 		addAuthor := func(user *discordgo.User) {
 			identities = append(identities, fmt.Sprintf("%v has id %s", user.Username, user.ID))
 		}
-		if len(m.Args) == 0 {
+		if !m.Params.Has("@users") {
 			addAuthor(m.Message.Author)
 		} else {
 			funk.ForEach(m.Message.Mentions, addAuthor)
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+)
+
+type jobs struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	ListJobs  = "jobs"
+	CancelJob = "canceljob"
+)
+
+func (*jobs) CommandGroup() string {
+	return "Bot Configuration"
+}
+
+func init() {
+	dispatch.Register(&jobs{},
+		[]dispatch.MessageCommand{
+			{Command: ListJobs, Permission: core.PermissionAdmin, Help: "List the scheduled jobs.", Slash: true, Ephemeral: true},
+			{Command: CancelJob, Permission: core.PermissionAdmin, Help: "Cancel a scheduled job.", Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{{Name: "id", Type: dispatch.ArgInteger, Help: "Job ID, as shown by the job list"}},
+				Examples: []string{"12"}},
+		},
+		nil, false)
+}
+
+func (*jobs) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case ListJobs:
+		listJobs(m)
+	case CancelJob:
+		cancelJob(m)
+	default:
+		return false
+	}
+	return true
+}
+
+func listJobs(m *dispatch.Message) {
+	var output []string
+	output = append(output, "**Scheduled jobs:**")
+	scheduled := database.FetchScheduledJobs()
+	if len(scheduled) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, job := range scheduled {
+		output = append(output, "\t"+formatJob(&job))
+	}
+	m.ReplyToSender("%s", strings.Join(output, "\n"))
+}
+
+func formatJob(job *database.ScheduledJob) string {
+	when := "once"
+	if job.IsRecurring() {
+		when = fmt.Sprintf("`%s`", job.Schedule)
+	}
+	line := fmt.Sprintf("#%d %s (%s, %s): next run <t:%d:R>", job.Id, job.Type, when, job.Missed, job.RunAt)
+	if description := services.DescribeJob(job); description != "" {
+		line += " - " + description
+	}
+	if job.LastError != nil {
+		line += fmt.Sprintf(" **Last run failed:** %s", *job.LastError)
+	}
+	return line
+}
+
+func cancelJob(m *dispatch.Message) {
+	id := m.Params.Int("id")
+	job := database.FetchScheduledJob(id)
+	if job == nil || !services.CancelJob(id) {
+		m.ReplyError("There is no job #%d.", id)
+		return
+	}
+	m.ReplyToChannel("Cancelled %s job #%d.", job.Type, id)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+	"GoBot/core/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type language struct {
+	dispatch.NoOpMessageHandler
+}
+
+const LanguageCommand = "language"
+
+func init() {
+	dispatch.Register(&language{},
+		[]dispatch.MessageCommand{
+			{Command: LanguageCommand, Help: "Show or choose the language I reply to you in.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "language", Type: dispatch.ArgRest, Optional: true, Autocomplete: languageAutocomplete,
+					Help: "Language code or name, or 'reset' to use the language of Discord and the server"}},
+				Examples: []string{"", "de", "Français", "reset"}},
+		},
+		nil, false)
+}
+
+func (*language) HandleCommand(m *dispatch.Message) bool {
+	if m.Command != LanguageCommand {
+		return false
+	}
+	if m.Author == nil {
+		return true
+	}
+
+	value := strings.TrimSpace(m.Params.String("language"))
+	switch {
+	case value == "":
+		key := "language.current_default"
+		if database.FetchUserLocale(m.Author.ID) != "" {
+			key = "language.current_chosen"
+		}
+		m.ReplyToChannel("%s\n%s", m.T(key, i18n.Name(m.Locale())), m.T("language.available", availableLanguages()))
+	case strings.EqualFold(value, "reset"):
+		database.ResetUserLocale(m.Author.ID)
+		m.ReplyToChannel(m.T("language.reset"), i18n.Name(m.Locale()))
+	default:
+		locale, ok := i18n.Parse(value)
+		if !ok {
+			m.ReplyError(m.T("language.unknown"), value, availableLanguages())
+			return true
+		}
+		if !database.SetUserLocale(m.Author.ID, locale) {
+			m.ReplyError("%s", m.T("language.failed"))
+			return true
+		}
+		m.ReplyToChannel(m.T("language.set"), i18n.Name(locale))
+	}
+	return true
+}
+
+// availableLanguages lists the languages with a catalog, e.g. "`de` (Deutsch), `en-US` (English)"
+func availableLanguages() string {
+	var languages []string
+	for _, locale := range i18n.Locales() {
+		languages = append(languages, fmt.Sprintf("`%s` (%s)", locale, i18n.Name(locale)))
+	}
+	return strings.Join(languages, ", ")
+}
+
+// languageAutocomplete offers the languages with a catalog matching what the user has typed
+func languageAutocomplete(typed string) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, locale := range i18n.Locales() {
+		name := i18n.Name(locale) + " (" + locale + ")"
+		if typed == "" || strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: locale})
+		}
+	}
+	return choices
+}
@@ -17,12 +17,12 @@ type ping struct {
 func init() {
 	dispatch.Register(&ping{},
 		[]dispatch.MessageCommand{
-			{"ping", "Simple command to check that bot is alive"},
-			{"pong", "Simple command to check that bot is alive"},
-			{"pingme", "Send a ping on a private message."},
-			{"uptime", "Get bot uptime information."},
+			{Command: "ping", Help: "Simple command to check that bot is alive"},
+			{Command: "pong", Help: "Simple command to check that bot is alive"},
+			{Command: "pingme", Help: "Send a ping on a private message."},
+			{Command: "uptime", Help: "Get bot uptime information."},
 		},
-		[]dispatch.MessageCommand{{"test", "Simple test prefix command"}},
+		[]dispatch.MessageCommand{{Command: "test", Help: "Simple test prefix command"}},
 		true)
 }
 
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core/dispatch"
+	"GoBot/core/services"
+)
+
+type reminders struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	RemindMe = "remindme"
+)
+
+func (*reminders) CommandGroup() string {
+	return "Reminders"
+}
+
+func init() {
+	dispatch.Register(&reminders{},
+		[]dispatch.MessageCommand{
+			{Command: RemindMe, Help: "Set a reminder, or list and cancel your reminders. Times are a duration, a date and time in UTC, or relative to a carrier departure.",
+				Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "reminder", Type: dispatch.ArgRest,
+					Help: "<when> to <what>, list, or cancel <id>"}},
+				Flags: []dispatch.Flag{{Name: "dm", Help: "Send the reminder by DM instead of in this channel"}},
+				Examples: []string{"in 2h to sell cargo", "--dm 30m before W7H-6DZ departs to undock",
+					"20th January, 18:30 UTC to join the expedition", "list", "cancel 12"}},
+		},
+		nil, false)
+}
+
+func (*reminders) HandleCommand(m *dispatch.Message) bool {
+	if m.Command != RemindMe {
+		return false
+	}
+	text := m.Params.String("reminder")
+	fields := strings.Fields(text)
+	switch {
+	case strings.EqualFold(text, "list"):
+		listReminders(m)
+	case len(fields) == 2 && strings.EqualFold(fields[0], "cancel"):
+		cancelReminder(m, fields[1])
+	default:
+		createReminder(m, text)
+	}
+	return true
+}
+
+func createReminder(m *dispatch.Message, text string) {
+	now := time.Now()
+	when, what, err := services.ParseReminder(text, now)
+	if err != nil {
+		m.ReplyError("Can't set the reminder: %s. Try something like `in 2h to sell cargo`.", err)
+		return
+	}
+	reminder := services.Reminder{UserId: m.Author.ID, ChannelId: m.ChannelID, Text: what}
+	if m.Params.Flag("dm") {
+		reminder.ChannelId = ""
+	}
+	id, err := services.CreateReminder(reminder, when, m.GuildID, now)
+	if err != nil {
+		m.ReplyError("Can't set the reminder: %s.", err)
+		return
+	}
+	where := "here"
+	if reminder.ChannelId == "" {
+		where = "by DM"
+	}
+	m.ReplyToChannel("Reminder #%d set for <t:%d:F> (<t:%d:R>), I'll remind you %s.", id, when.At.Unix(), when.At.Unix(), where)
+}
+
+func listReminders(m *dispatch.Message) {
+	var output []string
+	output = append(output, "**Your reminders:**")
+	jobs := services.UserReminders(m.Author.ID)
+	if len(jobs) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, job := range jobs {
+		reminder, err := services.DecodeReminder(&job)
+		if err != nil {
+			continue
+		}
+		where := "by DM"
+		if reminder.ChannelId != "" {
+			where = fmt.Sprintf("in <#%s>", reminder.ChannelId)
+		}
+		output = append(output, fmt.Sprintf("\t#%d <t:%d:R> %s: %s", job.Id, job.RunAt, where, services.DescribeReminder(reminder)))
+	}
+	m.ReplyToSender("%s", strings.Join(output, "\n"))
+}
+
+func cancelReminder(m *dispatch.Message, idText string) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(idText, "#"), 10, 64)
+	if err != nil {
+		m.ReplyError("`%s` isn't a reminder ID.", idText)
+		return
+	}
+	if !services.CancelReminder(id, m.Author.ID) {
+		m.ReplyError("You have no reminder #%d.", id)
+		return
+	}
+	m.ReplyToChannel("Reminder #%d cancelled.", id)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+)
+
+type roles struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	GrantRole  = "grant"
+	RevokeRole = "revoke"
+	MapRole    = "maprole"
+	UnmapRole  = "unmaprole"
+	ListRoles  = "roles"
+	MyRole     = "myrole"
+)
+
+func (*roles) CommandGroup() string {
+	return "Permissions"
+}
+
+func init() {
+	// Owners are configured in the config file, so they can't be granted
+	grantable := []string{"user", "carriermanager", "admin"}
+	levelArg := dispatch.Arg{Name: "level", Type: dispatch.ArgString, Help: "Permission level", Choices: grantable}
+	dispatch.Register(&roles{},
+		[]dispatch.MessageCommand{
+			{Command: GrantRole, Permission: core.PermissionOwner, Help: "Grant a permission level to a user.", Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{{Name: "user", Type: dispatch.ArgUser, Help: "User to grant the level to"}, levelArg},
+				Examples: []string{"@Commander carriermanager"}},
+			{Command: RevokeRole, Permission: core.PermissionOwner, Help: "Revoke any granted permission level from a user.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "user", Type: dispatch.ArgUser, Help: "User to revoke the level from"}}},
+			{Command: MapRole, Permission: core.PermissionOwner, Help: "Give everyone with a Discord role in this server a permission level.", Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{{Name: "role", Type: dispatch.ArgDiscordRole, Help: "Discord role"}, levelArg},
+				Examples: []string{"@Moderators admin"}},
+			{Command: UnmapRole, Permission: core.PermissionOwner, Help: "Remove the permission level mapping of a Discord role.", Slash: true, Ephemeral: true,
+				Args: []dispatch.Arg{{Name: "role", Type: dispatch.ArgDiscordRole, Help: "Discord role"}}},
+			{Command: ListRoles, Permission: core.PermissionAdmin, Help: "List granted permission levels and Discord role mappings.", Slash: true, Ephemeral: true},
+			{Command: MyRole, Help: "Show your permission level.", Slash: true, Ephemeral: true},
+		},
+		nil, false)
+}
+
+func (*roles) HandleCommand(m *dispatch.Message) bool {
+	switch m.Command {
+	case GrantRole:
+		grantRole(m)
+	case RevokeRole:
+		revokeRole(m)
+	case MapRole:
+		mapRole(m)
+	case UnmapRole:
+		unmapRole(m)
+	case ListRoles:
+		listRoles(m)
+	case MyRole:
+		m.ReplyToChannel("Your permission level is **%s**.", m.Permission())
+	default:
+		return false
+	}
+	return true
+}
+
+func grantRole(m *dispatch.Message) {
+	userId := m.Params.String("user")
+	level, _ := core.ParsePermissionLevel(m.Params.String("level"))
+	if core.Settings.IsOwner(userId) {
+		m.ReplyError("<@%s> is a bot owner.", userId)
+		return
+	}
+	if level == core.PermissionUser {
+		revokeRole(m)
+		return
+	}
+	if !database.SetUserRole(userId, level) {
+		m.ReplyError("Failed to grant **%s** to <@%s>.", level, userId)
+		return
+	}
+	m.ReplyToChannel("Granted **%s** to <@%s>.", level, userId)
+}
+
+func revokeRole(m *dispatch.Message) {
+	userId := m.Params.String("user")
+	if !database.RemoveUserRole(userId) {
+		m.ReplyToChannel("<@%s> has no granted permission level.", userId)
+		return
+	}
+	m.ReplyToChannel("Revoked the permission level of <@%s>.", userId)
+}
+
+func mapRole(m *dispatch.Message) {
+	if m.GuildID == "" {
+		m.ReplyError("Discord roles can only be mapped in a server.")
+		return
+	}
+	roleId := m.Params.String("role")
+	level, _ := core.ParsePermissionLevel(m.Params.String("level"))
+	if level == core.PermissionUser {
+		unmapRole(m)
+		return
+	}
+	if !database.SetGuildRole(m.GuildID, roleId, level) {
+		m.ReplyError("Failed to map <@&%s> to **%s**.", roleId, level)
+		return
+	}
+	m.ReplyToChannel("Members with <@&%s> now have **%s** permissions.", roleId, level)
+}
+
+func unmapRole(m *dispatch.Message) {
+	if m.GuildID == "" {
+		m.ReplyError("Discord roles can only be mapped in a server.")
+		return
+	}
+	roleId := m.Params.String("role")
+	if !database.RemoveGuildRole(m.GuildID, roleId) {
+		m.ReplyToChannel("<@&%s> isn't mapped to a permission level.", roleId)
+		return
+	}
+	m.ReplyToChannel("Removed the permission level mapping for <@&%s>.", roleId)
+}
+
+func listRoles(m *dispatch.Message) {
+	var output []string
+	output = append(output, "**Granted permission levels:**")
+	userRoles := database.FetchUserRoles()
+	if len(userRoles) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, role := range userRoles {
+		output = append(output, fmt.Sprintf("\t<@%s>: %s", role.UserId, role.Role))
+	}
+
+	if m.GuildID != "" {
+		output = append(output, "**Discord role mappings:**")
+		guildRoles := database.FetchGuildRoles(m.GuildID)
+		if len(guildRoles) == 0 {
+			output = append(output, "\tNone.")
+		}
+		for _, role := range guildRoles {
+			output = append(output, fmt.Sprintf("\t<@&%s>: %s", role.RoleId, role.Role))
+		}
+	}
+	m.ReplyToSender("%s", strings.Join(output, "\n"))
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/dispatch"
+)
+
+type usage struct {
+	dispatch.NoOpMessageHandler
+}
+
+const (
+	Usage = "usage"
+
+	usageDefaultDays = 30
+	usageListLength  = 10
+	usageTrendWeeks  = 8
+	usageMinUses     = 5 // Commands with fewer uses aren't listed by failure rate
+)
+
+func (*usage) CommandGroup() string {
+	return "Bot Configuration"
+}
+
+func init() {
+	dispatch.Register(&usage{},
+		[]dispatch.MessageCommand{
+			{Command: Usage, Permission: core.PermissionAdmin, Help: "Show the most used commands, the most active users, failure rates and weekly trends. In a DM, all servers are included.",
+				Slash: true, Ephemeral: true,
+				Args:     []dispatch.Arg{{Name: "days", Type: dispatch.ArgInteger, Optional: true, Help: fmt.Sprintf("Number of days to include (default %d)", usageDefaultDays)}},
+				Examples: []string{"", "7"}},
+		},
+		nil, false)
+}
+
+func (*usage) HandleCommand(m *dispatch.Message) bool {
+	if m.Command != Usage {
+		return false
+	}
+	days := usageDefaultDays
+	if m.Params.Has("days") {
+		days = int(m.Params.Int("days"))
+	}
+	if days <= 0 {
+		m.ReplyError("The number of days must be positive.")
+		return true
+	}
+	m.ReplyToSender("%s", usageReport(m.GuildID, days, time.Now()))
+	return true
+}
+
+// usageReport formats the usage statistics of a guild, or of all guilds if guildId is empty
+func usageReport(guildId string, days int, now time.Time) string {
+	since := now.AddDate(0, 0, -days)
+	var output []string
+
+	output = append(output, fmt.Sprintf("**Top commands, last %d days:**", days))
+	commands := database.FetchTopCommands(guildId, since, usageListLength)
+	if len(commands) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, count := range commands {
+		output = append(output, fmt.Sprintf("\t%s: %d uses, %.0f%% failed, %.0f ms average", count.Name, count.Uses,
+			100*count.FailureRate(), count.AvgLatencyMs))
+	}
+
+	output = append(output, "**Top users:**")
+	users := database.FetchTopUsers(guildId, since, usageListLength)
+	if len(users) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, count := range users {
+		output = append(output, fmt.Sprintf("\t<@%s>: %d commands", count.Name, count.Uses))
+	}
+
+	output = append(output, fmt.Sprintf("**Highest failure rates (at least %d uses):**", usageMinUses))
+	failing := database.FetchFailingCommands(guildId, since, usageMinUses, usageListLength)
+	if len(failing) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, count := range failing {
+		output = append(output, fmt.Sprintf("\t%s: %d of %d failed (%.0f%%)", count.Name, count.Failures, count.Uses,
+			100*count.FailureRate()))
+	}
+
+	output = append(output, fmt.Sprintf("**Weekly trend, last %d weeks:**", usageTrendWeeks))
+	weeks := database.FetchWeeklyUsage(guildId, usageTrendWeeks, now)
+	if len(weeks) == 0 {
+		output = append(output, "\tNone.")
+	}
+	for _, week := range weeks {
+		output = append(output, fmt.Sprintf("\tWeek %s: %d commands by %d users, %d failed", week.Week, week.Uses,
+			week.Users, week.Failures))
+	}
+	return strings.Join(output, "\n")
+}
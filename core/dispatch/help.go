@@ -0,0 +1,335 @@
+package dispatch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/i18n"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	HelpCommand = "help"
+
+	customTopic      = "custom"
+	helpColor        = 0x3498db
+	helpPageLength   = 3500 // Characters per page, below the 4096 character embed description limit
+	helpFieldLength  = 1024 // Embed field value limit
+	helpFieldsOnPage = 20   // Embeds allow at most 25 fields
+)
+
+func init() {
+	Register(&Dispatcher, []MessageCommand{
+		{Command: HelpCommand, Help: "Show what I can do, or detailed help for a command or group.", Slash: true, Ephemeral: true,
+			Args:     []Arg{{Name: "topic", Type: ArgRest, Optional: true, Help: "Command, group or custom command category"}},
+			Flags:    []Flag{{Name: "here", Short: "H", Help: "Show the help in this channel instead of in a DM"}},
+			Examples: []string{"", "carrierjump", "fleet carriers", "carrierjump --here"}},
+	}, nil, false)
+	RegisterPaginator(helpPaginator)
+}
+
+// helpPaginator shows the help a page at a time
+var helpPaginator = &Paginator{Name: HelpCommand, Size: 1, Source: func(m *Message, page Page) (Reply, int) {
+	return Dispatcher.helpPage(m, page)
+}}
+
+// HandleCommand This handle basically deals with help
+func (d *MessageDispatcher) HandleCommand(m *Message) bool {
+	if m.Command != HelpCommand {
+		return false
+	}
+
+	topic := strings.TrimPrefix(strings.TrimSpace(m.Params.String("topic")), m.Prefix)
+	if topic != "" && d.helpTopic(m.Locale(), m.Prefix, strings.ToLower(topic), m.Permission()) == nil {
+		suggestions := formatSuggestions(m.Locale(), m.Prefix, d.Suggest(strings.ToLower(topic), m.Permission()))
+		m.ReplyToChannel(m.T("help.unknown_topic"), topic, suggestions, m.Prefix, HelpCommand)
+		return true
+	}
+	helpPaginator.Respond(m, strings.ToLower(topic), !m.Params.Flag("here"))
+	return true
+}
+
+// helpPage returns a page of the help overview, or of the help for the topic in the page arguments
+func (d *MessageDispatcher) helpPage(m *Message, page Page) (Reply, int) {
+	var pages []*discordgo.MessageEmbed
+	if page.Args == "" {
+		pages = d.helpOverview(m.Locale(), m.Prefix, m.Permission())
+	} else {
+		pages = d.helpTopic(m.Locale(), m.Prefix, page.Args, m.Permission())
+	}
+	if page.Number >= len(pages) {
+		return Reply{Content: m.T("help.nothing_here")}, len(pages)
+	}
+	return Reply{Embeds: []*discordgo.MessageEmbed{pages[page.Number]}}, len(pages)
+}
+
+// helpOverview lists every group with the commands the user can run, followed by the custom command categories
+func (d *MessageDispatcher) helpOverview(locale, prefix string, level core.PermissionLevel) []*discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, group := range d.helpGroups() {
+		var names []string
+		for _, spec := range d.groupCommands(group, level) {
+			names = append(names, "`"+spec.Command+"`")
+		}
+		fields = append(fields, helpFields(locale, groupTitle(locale, group), names, " ")...)
+	}
+
+	var custom []string
+	for _, category := range database.FetchCommandGroups() {
+		custom = append(custom, i18n.T(locale, "help.category_entry", category.Command))
+	}
+	for _, command := range database.FetchStandaloneCommands() {
+		custom = append(custom, "`"+command.Command+"`")
+	}
+	fields = append(fields, helpFields(locale, i18n.T(locale, "help.custom_group"), custom, " ")...)
+
+	description := i18n.T(locale, "help.overview", prefix, HelpCommand)
+	var pages []*discordgo.MessageEmbed
+	for len(fields) > 0 {
+		count := helpFieldsOnPage
+		if count > len(fields) {
+			count = len(fields)
+		}
+		pages = append(pages, &discordgo.MessageEmbed{Title: i18n.T(locale, "help.commands"), Description: description,
+			Color: helpColor, Fields: fields[:count]})
+		fields = fields[count:]
+	}
+	return numberPages(locale, pages)
+}
+
+// helpTopic returns the help for a command, group or custom command category, or nil if there's no such topic
+func (d *MessageDispatcher) helpTopic(locale, prefix, topic string, level core.PermissionLevel) []*discordgo.MessageEmbed {
+	for _, group := range d.helpGroups() {
+		if specs := d.commandHelp[group][topic]; len(specs) > 0 {
+			return []*discordgo.MessageEmbed{commandHelpEmbed(locale, specs[0], prefix, group)}
+		}
+	}
+	if cmd := database.FetchCommandAlias(topic); cmd != nil {
+		return []*discordgo.MessageEmbed{customCommandHelpEmbed(locale, cmd, prefix)}
+	}
+
+	for _, group := range d.helpGroups() {
+		if isGroupTopic(locale, group, topic) {
+			var lines []string
+			for _, spec := range d.groupCommands(group, level) {
+				lines = append(lines, fmt.Sprintf("**%s**: %s", spec.Usage(prefix), spec.LocalHelp(locale)))
+			}
+			return helpPages(locale, groupTitle(locale, group), lines)
+		}
+	}
+
+	customGroup := i18n.T(locale, "help.custom_group")
+	if strings.EqualFold(customGroup, topic) || strings.EqualFold(i18n.T(i18n.DefaultLocale, "help.custom_group"), topic) ||
+		topic == customTopic {
+		var lines []string
+		for _, category := range database.FetchCommandGroups() {
+			lines = append(lines, i18n.T(locale, "help.category_line", category.Command, stringOr(category.Help, "")))
+		}
+		for _, command := range database.FetchStandaloneCommands() {
+			lines = append(lines, customCommandLine(locale, &command, prefix))
+		}
+		return helpPages(locale, customGroup, lines)
+	}
+	if category := database.FetchCommandGroup(topic); category != nil {
+		var lines []string
+		if category.Help != nil {
+			lines = append(lines, *category.Help, "")
+		}
+		for _, command := range category.FetchCommands() {
+			lines = append(lines, customCommandLine(locale, &command, prefix))
+		}
+		return helpPages(locale, category.Command, lines)
+	}
+	return nil
+}
+
+// helpGroups returns the command groups, sorted with the general commands first
+func (d *MessageDispatcher) helpGroups() []string {
+	groups := make([]string, 0, len(d.commandHelp))
+	for group := range d.commandHelp {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// groupCommands returns the documented commands in a group that the user is allowed to run, sorted by name
+func (d *MessageDispatcher) groupCommands(group string, level core.PermissionLevel) []*MessageCommand {
+	var specs []*MessageCommand
+	for _, commandSpecs := range d.commandHelp[group] {
+		for _, spec := range commandSpecs {
+			if spec.Permission <= level {
+				specs = append(specs, spec)
+			}
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Command < specs[j].Command
+	})
+	return specs
+}
+
+func commandHelpEmbed(locale string, spec *MessageCommand, prefix, group string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       spec.Usage(prefix),
+		Description: spec.LocalHelp(locale),
+		Color:       helpColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: groupTitle(locale, group)},
+	}
+
+	var lines []string
+	for _, arg := range spec.Args {
+		line := "`" + arg.Name + "`"
+		if arg.Optional {
+			line += " " + i18n.T(locale, "help.optional")
+		}
+		if help := spec.argHelp(locale, arg); help != "" {
+			line += ": " + help
+		}
+		lines = append(lines, line)
+	}
+	embed.Fields = append(embed.Fields, helpFields(locale, i18n.T(locale, "help.arguments"), lines, "\n")...)
+
+	lines = nil
+	for _, flag := range spec.Flags {
+		line := "`--" + flag.Name + "`"
+		if flag.Short != "" {
+			line += ", `-" + flag.Short + "`"
+		}
+		lines = append(lines, line+": "+spec.flagHelp(locale, flag))
+	}
+	embed.Fields = append(embed.Fields, helpFields(locale, i18n.T(locale, "help.flags"), lines, "\n")...)
+
+	lines = nil
+	for _, example := range spec.Examples {
+		lines = append(lines, "`"+strings.TrimSpace(prefix+spec.Command+" "+example)+"`")
+	}
+	embed.Fields = append(embed.Fields, helpFields(locale, i18n.T(locale, "help.examples"), lines, "\n")...)
+
+	if spec.Permission > core.PermissionUser {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: i18n.T(locale, "help.requires"),
+			Value: spec.Permission.String(), Inline: true})
+	}
+	if spec.Slash {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: i18n.T(locale, "help.slash_command"),
+			Value: "`/" + spec.Command + "`", Inline: true})
+	}
+	return embed
+}
+
+func customCommandHelpEmbed(locale string, cmd *database.CommandAlias, prefix string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       prefix + cmd.Command,
+		Description: stringOr(cmd.Longhelp, stringOr(cmd.Help, i18n.T(locale, "help.no_help"))),
+		Color:       helpColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: i18n.T(locale, "help.custom_group")},
+	}
+	if cmd.GroupId != nil {
+		for _, category := range database.FetchCommandGroups() {
+			if category.Id == int64(*cmd.GroupId) {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: i18n.T(locale, "help.category"),
+					Value: category.Command, Inline: true})
+			}
+		}
+	}
+	if cmd.PMEnabled {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: i18n.T(locale, "help.reply"),
+			Value: i18n.T(locale, "help.sent_in_dm"), Inline: true})
+	}
+	return embed
+}
+
+func customCommandLine(locale string, cmd *database.CommandAlias, prefix string) string {
+	return fmt.Sprintf("**%s%s**: %s", prefix, cmd.Command, stringOr(cmd.Help, i18n.T(locale, "help.no_help")))
+}
+
+// helpFields joins the entries into as many embed fields as needed to stay within the field length limit
+func helpFields(locale, name string, entries []string, separator string) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	value := ""
+	length := 0 // Of value in characters, as Discord counts them
+	for _, entry := range entries {
+		entry = truncateHelp(entry, helpFieldLength)
+		entryLength := utf8.RuneCountInString(entry)
+		if value != "" && length+utf8.RuneCountInString(separator)+entryLength > helpFieldLength {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+			name, value, length = i18n.T(locale, "help.continued", name), "", 0
+		}
+		if value != "" {
+			value += separator
+			length += utf8.RuneCountInString(separator)
+		}
+		value += entry
+		length += entryLength
+	}
+	if value != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+	}
+	return fields
+}
+
+// helpPages splits the lines into embed pages that stay within the description length limit
+func helpPages(locale, title string, lines []string) []*discordgo.MessageEmbed {
+	if len(lines) == 0 {
+		lines = []string{i18n.T(locale, "help.nothing_here")}
+	}
+	var pages []*discordgo.MessageEmbed
+	var page []string
+	length := 0
+	for _, line := range lines {
+		line = truncateHelp(line, helpPageLength)
+		lineLength := utf8.RuneCountInString(line)
+		if len(page) > 0 && length+lineLength+1 > helpPageLength {
+			pages = append(pages, &discordgo.MessageEmbed{Title: title, Description: strings.Join(page, "\n"), Color: helpColor})
+			page, length = nil, 0
+		}
+		page = append(page, line)
+		length += lineLength + 1
+	}
+	pages = append(pages, &discordgo.MessageEmbed{Title: title, Description: strings.Join(page, "\n"), Color: helpColor})
+	return numberPages(locale, pages)
+}
+
+// truncateHelp cuts text longer than limit characters, ending it with "..."
+func truncateHelp(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit-3]) + "..."
+}
+
+// numberPages adds a page number footer when there is more than one page
+func numberPages(locale string, pages []*discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+	if len(pages) < 2 {
+		return pages
+	}
+	for i, page := range pages {
+		page.Footer = &discordgo.MessageEmbedFooter{Text: i18n.T(locale, "help.page", i+1, len(pages))}
+	}
+	return pages
+}
+
+// groupTitle returns the name of a command group in the locale. Groups are translated with group.<name> messages.
+func groupTitle(locale, group string) string {
+	if group == "" {
+		return i18n.T(locale, "help.general_group")
+	}
+	return translated(locale, "group."+group, group)
+}
+
+// isGroupTopic returns true if the help topic names the group, in English or the locale
+func isGroupTopic(locale, group, topic string) bool {
+	return strings.EqualFold(groupTitle(locale, group), topic) || strings.EqualFold(groupTitle(i18n.DefaultLocale, group), topic) ||
+		strings.EqualFold(group, topic)
+}
+
+func stringOr(s *string, def string) string {
+	if s == nil || *s == "" {
+		return def
+	}
+	return *s
+}
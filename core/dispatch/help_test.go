@@ -0,0 +1,117 @@
+package dispatch
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"GoBot/core"
+	"GoBot/core/i18n"
+)
+
+type testHandler struct {
+	NoOpMessageHandler
+	group string
+}
+
+func (h *testHandler) CommandGroup() string {
+	return h.group
+}
+
+func newTestDispatcher() *MessageDispatcher {
+	return &MessageDispatcher{
+		prefixHandlers:  map[string][]MessageHandler{},
+		commandHandlers: map[string][]MessageHandler{},
+		commandSpecs:    map[string]*MessageCommand{},
+		commandHelp:     map[string]map[string][]*MessageCommand{},
+		metrics:         map[string]*CommandMetrics{},
+	}
+}
+
+func TestHelpTopic(t *testing.T) {
+	d := newTestDispatcher()
+	carriers := &testHandler{group: "Fleet Carriers"}
+	d.addHandlerForCommand(MessageCommand{Command: "carriers", Help: "List carriers."}, &d.commandHandlers, carriers)
+	d.addHandlerForCommand(MessageCommand{Command: "carrierjump", Help: "Set jump time.", Permission: core.PermissionCarrierManager,
+		Args: []Arg{{Name: "carrier", Type: ArgStationId}}, Examples: []string{"W7H-6DZ 18:30"}}, &d.commandHandlers, carriers)
+
+	pages := d.helpTopic(i18n.DefaultLocale, "#", "carrierjump", core.PermissionUser)
+	if len(pages) != 1 {
+		t.Fatalf("Expected 1 page for command help, got %d", len(pages))
+	}
+	if pages[0].Title != "#carrierjump <carrier>" {
+		t.Errorf("Expected usage as title, got %q", pages[0].Title)
+	}
+	var fieldNames []string
+	for _, field := range pages[0].Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	if strings.Join(fieldNames, ",") != "Arguments,Examples,Requires" {
+		t.Errorf("Expected Arguments, Examples and Requires fields, got %v", fieldNames)
+	}
+
+	pages = d.helpTopic(i18n.DefaultLocale, "#", "fleet carriers", core.PermissionUser)
+	if len(pages) != 1 {
+		t.Fatalf("Expected 1 page for group help, got %d", len(pages))
+	}
+	if strings.Contains(pages[0].Description, "carrierjump") {
+		t.Error("Expected group help to hide commands the user can't run")
+	}
+	if !strings.Contains(pages[0].Description, "#carriers") {
+		t.Errorf("Expected group help to list #carriers, got %q", pages[0].Description)
+	}
+}
+
+func TestHelpPages(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", 99))
+	}
+	pages := helpPages(i18n.DefaultLocale, "Test", lines)
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(pages))
+	}
+	for _, page := range pages {
+		if len(page.Description) > helpPageLength {
+			t.Errorf("Expected page description within %d characters, got %d", helpPageLength, len(page.Description))
+		}
+	}
+	if pages[2].Footer == nil || pages[2].Footer.Text != "Page 3/3" {
+		t.Error("Expected page number footer on the last page")
+	}
+
+	// Lines are measured in characters, and a line too long for a page is cut
+	pages = helpPages(i18n.DefaultLocale, "Test", []string{strings.Repeat("é", 2000), strings.Repeat("é", 1400), strings.Repeat("é", 5000)})
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(pages))
+	}
+	if description := pages[1].Description; !utf8.ValidString(description) || utf8.RuneCountInString(description) != helpPageLength {
+		t.Errorf("Expected the long line cut to %d characters, got %d", helpPageLength, utf8.RuneCountInString(description))
+	}
+}
+
+func TestHelpFields(t *testing.T) {
+	var entries []string
+	for i := 0; i < 100; i++ {
+		entries = append(entries, "`command`")
+	}
+	fields := helpFields(i18n.DefaultLocale, "Group", entries, " ")
+	if len(fields) != 1 {
+		t.Fatalf("Expected 1 field, got %d", len(fields))
+	}
+	fields = helpFields(i18n.DefaultLocale, "Group", append(entries, entries...), " ")
+	if len(fields) != 2 || fields[1].Name != "Group (cont.)" {
+		t.Errorf("Expected the field to continue in a second field, got %d fields", len(fields))
+	}
+
+	// Long entries are cut at a character, not in the middle of one
+	fields = helpFields(i18n.DefaultLocale, "Group", []string{strings.Repeat("é", 2000)}, " ")
+	if value := fields[0].Value; !utf8.ValidString(value) || len([]rune(value)) != helpFieldLength {
+		t.Errorf("Expected the entry cut to %d valid characters, got %d", helpFieldLength, len([]rune(value)))
+	}
+	// Fields are filled up to the limit in characters rather than bytes
+	fields = helpFields(i18n.DefaultLocale, "Group", []string{strings.Repeat("é", 600), strings.Repeat("é", 400)}, " ")
+	if len(fields) != 1 {
+		t.Errorf("Expected 1001 characters to fit in one field, got %d fields", len(fields))
+	}
+}
@@ -0,0 +1,66 @@
+package dispatch
+
+import (
+	"GoBot/core/i18n"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Translations of commands are catalog messages keyed by the command name. The English help stays in the
+// MessageCommand, so only translations need to be in the catalogs:
+//
+//	command.<command>                   help
+//	command.<command>.name              slash command name
+//	command.<command>.args.<arg>        help of an argument
+//	command.<command>.args.<arg>.name   slash option name of an argument
+//	command.<command>.flags.<flag>      help of a flag
+func commandKey(command string, parts ...string) string {
+	key := "command." + command
+	for _, part := range parts {
+		key += "." + part
+	}
+	return key
+}
+
+// translated returns a catalog message for the locale, or the fallback if no catalog has it
+func translated(locale, key, fallback string) string {
+	if text, ok := i18n.Translate(locale, key); ok {
+		return text
+	}
+	return fallback
+}
+
+// LocalHelp returns the help of the command in the locale
+func (c *MessageCommand) LocalHelp(locale string) string {
+	return translated(locale, commandKey(c.Command), c.Help)
+}
+
+func (c *MessageCommand) argHelp(locale string, arg Arg) string {
+	return translated(locale, commandKey(c.Command, "args", arg.Name), arg.Help)
+}
+
+func (c *MessageCommand) flagHelp(locale string, flag Flag) string {
+	return translated(locale, commandKey(c.Command, "flags", flag.Name), flag.Help)
+}
+
+// localizeApplicationCommand adds the translated names and descriptions of the command and its options
+func (c *MessageCommand) localizeApplicationCommand(cmd *discordgo.ApplicationCommand) {
+	if names := i18n.Localizations(commandKey(c.Command, "name"), slashName); names != nil {
+		cmd.NameLocalizations = &names
+	}
+	if descriptions := i18n.Localizations(commandKey(c.Command), slashDescriptionText); descriptions != nil {
+		cmd.DescriptionLocalizations = &descriptions
+	}
+	for _, option := range cmd.Options {
+		if arg := c.argBySlashName(option.Name); arg != nil {
+			option.NameLocalizations = i18n.Localizations(commandKey(c.Command, "args", arg.Name, "name"), slashName)
+			option.DescriptionLocalizations = i18n.Localizations(commandKey(c.Command, "args", arg.Name), slashDescriptionText)
+		} else if flag := c.flagBySlashName(option.Name); flag != nil {
+			option.DescriptionLocalizations = i18n.Localizations(commandKey(c.Command, "flags", flag.Name), slashDescriptionText)
+		}
+	}
+}
+
+// slashDescriptionText fits a translated description in Discord's limit
+func slashDescriptionText(text string) string {
+	return slashDescription(text, text)
+}
@@ -0,0 +1,106 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"GoBot/core"
+	"GoBot/core/i18n"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestApplicationCommand_Localizations(t *testing.T) {
+	i18n.AddCatalog("de", map[string]string{
+		"command.testlocale":                 "Testet die Übersetzung.",
+		"command.testlocale.name":            "Übersetzung Test",
+		"command.testlocale.args.jump range": "Sprungweite in Lichtjahren",
+	})
+	cmd := MessageCommand{Command: "testlocale", Help: "Test the translation.", Slash: true,
+		Args:  []Arg{{Name: "jump range", Type: ArgNumber, Help: "Jump range in ly"}},
+		Flags: []Flag{{Name: "here", Help: "Reply in the channel"}}}
+
+	appCmd := cmd.applicationCommand()
+	if appCmd.NameLocalizations == nil || (*appCmd.NameLocalizations)[discordgo.German] != "übersetzung_test" {
+		t.Errorf("Expected a valid German name, got %v", appCmd.NameLocalizations)
+	}
+	if appCmd.DescriptionLocalizations == nil || (*appCmd.DescriptionLocalizations)[discordgo.German] != "Testet die Übersetzung." {
+		t.Errorf("Expected a German description, got %v", appCmd.DescriptionLocalizations)
+	}
+	if _, ok := (*appCmd.NameLocalizations)[discordgo.EnglishUS]; ok {
+		t.Error("Expected no localization for the default locale")
+	}
+	if appCmd.Options[0].DescriptionLocalizations[discordgo.German] != "Sprungweite in Lichtjahren" {
+		t.Errorf("Expected a German option description, got %v", appCmd.Options[0].DescriptionLocalizations)
+	}
+	if appCmd.Options[1].DescriptionLocalizations != nil {
+		t.Errorf("Expected no translations for the flag, got %v", appCmd.Options[1].DescriptionLocalizations)
+	}
+
+	if help := cmd.LocalHelp("de"); help != "Testet die Übersetzung." {
+		t.Errorf("Expected the German help, got %q", help)
+	}
+	if help := cmd.LocalHelp("ja"); help != cmd.Help {
+		t.Errorf("Expected the registered help without a translation, got %q", help)
+	}
+}
+
+func TestMessageLocale(t *testing.T) {
+	tests := []struct {
+		interaction discordgo.Locale
+		want        string
+	}{
+		{discordgo.German, "de"},
+		{discordgo.EnglishGB, i18n.DefaultLocale},
+		{discordgo.Japanese, i18n.DefaultLocale}, // No catalog, the guild locale is used
+		{discordgo.Unknown, i18n.DefaultLocale},
+	}
+	for _, tt := range tests {
+		m := Message{Message: &discordgo.Message{Author: &discordgo.User{ID: "1"}},
+			interaction: &interactionReply{interaction: &discordgo.Interaction{Locale: tt.interaction}}}
+		if locale := m.Locale(); locale != tt.want {
+			t.Errorf("Expected locale %s for interaction locale %q, got %s", tt.want, tt.interaction, locale)
+		}
+	}
+
+	m := Message{Message: &discordgo.Message{Author: &discordgo.User{ID: "1"}}}
+	if locale := m.Locale(); locale != i18n.DefaultLocale {
+		t.Errorf("Expected messages to use the guild locale, got %s", locale)
+	}
+}
+
+func TestReplyError_Localized(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	d.registerComponents("deny", core.PermissionUser, func(m *Message, click *ComponentClick) {
+		m.ReplyError("%s", m.T("carriers.alert_failed"))
+	})
+	sent, _ := fake.ChannelMessageSendComplex("10", &discordgo.MessageSend{Content: "Click me"})
+	interaction := &discordgo.Interaction{
+		ID:        "de1",
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: "10",
+		GuildID:   "20",
+		Locale:    discordgo.German,
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "1"}},
+		Message:   sent,
+		Data:      discordgo.MessageComponentInteractionData{CustomID: ComponentID("deny", "", "", 0)},
+	}
+	d.HandleInteraction(context.Background(), fake, &discordgo.InteractionCreate{Interaction: interaction})
+
+	messages := fake.SentTo("10")
+	if len(messages) != 2 || messages[1].Content != "**Fehler:** Alarm konnte nicht angelegt werden." {
+		t.Errorf("Expected the error labelled in German, got %v", messages)
+	}
+}
+
+func TestArgumentError_Localized(t *testing.T) {
+	cmd := MessageCommand{Command: "carrierinfo", Args: []Arg{{Name: "carrier", Type: ArgStationId}}}
+	_, _, err := cmd.Parse("")
+	if err == nil || err.Error() != "Missing argument <carrier>." {
+		t.Fatalf("Expected the error in English by default, got %v", err)
+	}
+	m := Message{Message: &discordgo.Message{Author: &discordgo.User{ID: "1"}},
+		interaction: &interactionReply{interaction: &discordgo.Interaction{Locale: discordgo.German}}}
+	if text := m.argumentError(err); text != "Das Argument <carrier> fehlt." {
+		t.Errorf("Expected the error in German, got %q", text)
+	}
+}
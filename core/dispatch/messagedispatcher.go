@@ -1,13 +1,17 @@
 package dispatch
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"strings"
+	"sync"
+	"unicode"
 
 	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"GoBot/core/i18n"
 	"github.com/bwmarrin/discordgo"
-	"github.com/thoas/go-funk"
 )
 
 // MessageDispatcher This class will parse and dispatch commands to the appropriate Command handler.
@@ -17,23 +21,36 @@ type MessageDispatcher struct {
 	NoOpMessageHandler
 	// allows prefix handling, i.e "randomcat" and "randomdog" could both go to a "random" prefix handler
 	prefixHandlers map[string][]MessageHandler
+	// Registered prefixes in the order they're tried, longest first
+	prefixOrder []string
 	// requires either just the Command, i.e "route" or Command with arguments "route 32 2.3"
 	commandHandlers map[string][]MessageHandler
 	// Anything matching
 	anythingHandlers []MessageHandler
-	// Command help
-	commandHelp map[string]map[string][]string
+	// Declared arguments for each command
+	commandSpecs map[string]*MessageCommand
+	// Command help, by group and command
+	commandHelp map[string]map[string][]*MessageCommand
+	// Run around the handlers, in order
+	middlewares []Middleware
+	// Counters kept by the timing middleware, by command
+	metrics   map[string]*CommandMetrics
+	metricsMu sync.Mutex
+	// Component handlers, by namespace
+	components map[string]*componentRoute
+	// Paged listings, by name
+	paginators map[string]*Paginator
+	// Messages and interactions being handled, closed on shutdown
+	work core.WorkGroup
 }
 
 // Dispatcher Object used for dispatching messages to the handlers.
 var Dispatcher = MessageDispatcher{
 	prefixHandlers:  map[string][]MessageHandler{},
 	commandHandlers: map[string][]MessageHandler{},
-	commandHelp:     map[string]map[string][]string{},
-}
-
-func init() {
-	Register(&Dispatcher, []MessageCommand{{"help", ""}}, nil, false)
+	commandSpecs:    map[string]*MessageCommand{},
+	commandHelp:     map[string]map[string][]*MessageCommand{},
+	metrics:         map[string]*CommandMetrics{},
 }
 
 // Register a new command handler with zero or more commands, prefix handlers and optional wildcard matching
@@ -52,35 +69,10 @@ func Register(handler MessageHandler, commands, prefixes []MessageCommand, wildc
 	}
 }
 
+// HasCommand returns true if the command is handled by a built-in command or prefix
 func (d *MessageDispatcher) HasCommand(cmd string) bool {
-	return d.commandHandlers[cmd] != nil || d.prefixHandlers[cmd] != nil
-}
-
-// HandleCommand This handle basically deals with help
-func (d *MessageDispatcher) HandleCommand(m *Message) bool {
-	go func() {
-		groups := funk.Keys(d.commandHelp).([]string)
-		sort.Strings(groups)
-		for _, group := range groups {
-			var output []string
-			if group != "" {
-				output = append(output, fmt.Sprintf("**%s**", group))
-			} else {
-				output = append(output, "**General Commands**:")
-			}
-			commands := funk.Keys(d.commandHelp[group]).([]string)
-			sort.Strings(commands)
-			for _, command := range commands {
-				output = append(output, strings.Join(d.commandHelp[group][command], "\n"))
-			}
-			//if message.flags.contains(.Here) {
-			//        message.replyToChannel(output.joined(separator: "\n"));
-			//    } else {
-			<-m.ReplyToSender("%s", strings.Join(output, "\n"))
-			//    }
-		}
-	}()
-	return true
+	_, _, found := d.BuiltinRoute(cmd)
+	return found
 }
 
 func SettingsLoaded() {
@@ -98,8 +90,8 @@ func SettingsLoaded() {
 			handler.SettingsLoaded()
 		}
 	}
-	for _, handlers := range Dispatcher.prefixHandlers {
-		for _, handler := range handlers {
+	for _, prefix := range Dispatcher.prefixOrder {
+		for _, handler := range Dispatcher.prefixHandlers[prefix] {
 			if !isCalled(handler) {
 				handler.SettingsLoaded()
 			}
@@ -114,68 +106,154 @@ func SettingsLoaded() {
 	}
 }
 
-func Dispatch(session *discordgo.Session, message *discordgo.Message) {
-	Dispatcher.Dispatch(session, message)
+func Dispatch(ctx context.Context, session discord.Session, message *discordgo.Message) {
+	Dispatcher.Dispatch(ctx, session, message)
+}
+
+func DispatchEdit(ctx context.Context, session discord.Session, message *discordgo.Message) {
+	Dispatcher.DispatchEdit(ctx, session, message)
+}
+
+// Shutdown stops dispatching new messages and interactions, and waits for the ones being handled
+// until ctx is done
+func Shutdown(ctx context.Context) error {
+	return Dispatcher.Shutdown(ctx)
 }
 
 // Dispatch Parse and dispatch the message.
-func (d *MessageDispatcher) Dispatch(session *discordgo.Session, message *discordgo.Message) {
-	// Short-circuit if author of the message is the bot itself to avoid loops
-	if message.Author == nil || message.Author.ID == session.State.User.ID {
+func (d *MessageDispatcher) Dispatch(ctx context.Context, session discord.Session, message *discordgo.Message) {
+	if !d.work.Start() {
 		return
 	}
+	defer d.work.Done()
+	d.dispatch(ctx, session, message, false, nil)
+}
+
+// Shutdown stops dispatching new messages and interactions, and waits for the ones being handled
+// until ctx is done
+func (d *MessageDispatcher) Shutdown(ctx context.Context) error {
+	return d.work.Close(ctx)
+}
+
+// DispatchEdit handles an edited message. If it was a command replied to within the edit window, the replies
+// are updated instead of sending new ones, or deleted if the message no longer is a command any handler takes.
+func (d *MessageDispatcher) DispatchEdit(ctx context.Context, session discord.Session, message *discordgo.Message) {
+	if !d.work.Start() {
+		return
+	}
+	defer d.work.Done()
+	// Updates that only add link previews can come without author and content
+	if message.Author == nil || message.Content == "" {
+		return
+	}
+	previous, changed := replies.edited(message.ID, message.Content)
+	if !changed {
+		return
+	}
+	if !d.dispatch(ctx, session, message, true, previous) {
+		deleteReplies(session, previous)
+	}
+}
+
+// dispatch parses and dispatches the message, editing the previous replies of an edited command. The handlers get a
+// context that is cancelled after the handler timeout. Returns true if a handler or middleware took the message.
+// Edits that no handler takes aren't answered as unknown commands, and their previous replies are left to the caller.
+func (d *MessageDispatcher) dispatch(ctx context.Context, session discord.Session, message *discordgo.Message, edit bool, previous []trackedReply) bool {
+	// Short-circuit if author of the message is the bot itself to avoid loops
+	if message.Author == nil || message.Author.ID == session.BotUserID() {
+		return false
+	}
 
 	core.LogTrace("Got message: ", message.Content)
 
 	// This handles @BotName command trimming
-	trimmed := strings.TrimPrefix(message.Content, fmt.Sprintf("<@%s> ", session.State.User.ID))
+	trimmed := strings.TrimPrefix(message.Content, fmt.Sprintf("<@%s> ", session.BotUserID()))
 	// If directly addressed, it will respond to unknown commands in a PM.
 	isDirectAddressed := trimmed != message.Content
-	// And this will trim the configured command prefix, which is optional if @Bot syntax is used
-	trimmed = strings.TrimPrefix(trimmed, core.Settings.CommandPrefix())
+	// And this will trim the command prefix configured for the guild, which is optional if @Bot syntax is used
+	prefix := database.FetchGuildSettings(message.GuildID).CommandPrefix
+	trimmed = strings.TrimPrefix(trimmed, prefix)
 	// And finally, if we didn't trim anything, check to see if it was a DM.
 	var isDM bool
 	if trimmed == message.Content {
 		var err error
 		isDM, err = comesFromDM(session, message)
 		if !isDM || err != nil {
-			return
+			return false
 		}
 	}
 
-	// Split the Command into parameters, and clean them up.
-	rawArgs := strings.Split(trimmed, " ")
-	args := funk.FilterString(rawArgs, func(str string) bool {
-		return strings.Trim(str, "\t\r") != ""
-	})
+	// Split off the Command, the remainder is parsed according to the command declaration.
+	command, rawArgs := splitCommand(trimmed)
 
 	// Just a bunch of whitespaces
-	if len(args) == 0 {
-		return
+	if command == "" {
+		return false
 	}
 
-	core.LogDebug("Parsed parameters:", args)
+	core.LogDebugF("Parsed command %s with arguments: %s", command, rawArgs)
 
-	command := strings.ToLower(args[0])
-	args = args[1:]
+	ctx, cancel := context.WithTimeout(ctx, core.Settings.HandlerTimeout())
+	defer cancel()
 	cmdMessage := &Message{
-		message, session, command, args,
-		rawArgs[1:], parseCommandFlags(args), isDM,
+		ctx:     ctx,
+		Message: message,
+		Session: session,
+		Command: command,
+		Prefix:  prefix,
+		IsPM:    isDM,
+		spec:    d.commandSpecs[command],
+		replies: &replySet{tracker: replies, messageId: message.ID, previous: previous},
+	}
+	replies.start(message.ID, message.Content)
+	handled := d.runMiddleware(cmdMessage, func() bool {
+		return d.runHandlers(cmdMessage, rawArgs)
+	})
+	if !handled {
+		if edit {
+			return false
+		}
+		d.replyUnknownCommand(cmdMessage, isDirectAddressed)
 	}
+	cmdMessage.replies.finish(session)
+	return handled
+}
+
+// runHandlers parses the arguments and offers the message to the command, prefix and wildcard handlers in turn
+func (d *MessageDispatcher) runHandlers(cmdMessage *Message, rawArgs string) bool {
+	command := cmdMessage.Command
 	if commandHandlers := d.commandHandlers[command]; len(commandHandlers) > 0 {
+		spec := d.commandSpecs[command]
+		params, words, err := spec.Parse(rawArgs)
+		if err != nil {
+			cmdMessage.ReplyError("%s\n%s", cmdMessage.argumentError(err), cmdMessage.T("dispatch.usage", spec.Usage(cmdMessage.Prefix)))
+			return true
+		}
+		if params.Flag(HelpFlag) {
+			cmdMessage.ReplyToChannel("%s", d.commandUsageHelp(spec, cmdMessage.Locale(), cmdMessage.Prefix))
+			return true
+		}
+		cmdMessage.Params, cmdMessage.Args = params, words
+
 		core.LogDebugF("Found %d Command handlers for %s.", len(commandHandlers), command)
 		for _, handler := range commandHandlers {
 			if handler.HandleCommand(cmdMessage) {
 				core.LogDebug("   => handled.")
-				return
+				return true
 			}
 		}
 	}
 
-	for prefix, handlers := range d.prefixHandlers {
-		if !strings.HasPrefix(command, prefix) {
-			continue
-		}
+	// Prefix and wildcard handlers get free-form words and the universal flags.
+	freeForm := MessageCommand{Command: command}
+	params, words, err := freeForm.Parse(rawArgs)
+	if err != nil {
+		words = strings.Fields(rawArgs)
+	}
+	cmdMessage.Params, cmdMessage.Args = params, words
+
+	for _, prefix := range d.matchingPrefixes(command) {
+		handlers := d.prefixHandlers[prefix]
 		suffix := strings.TrimPrefix(command, prefix)
 		core.LogDebugF("Found %d prefix handlers for %s.", len(handlers), prefix)
 		for _, handler := range handlers {
@@ -183,7 +261,7 @@ func (d *MessageDispatcher) Dispatch(session *discordgo.Session, message *discor
 				if core.IsLogDebug() {
 					core.LogDebugF("   => handled by %s.", toName(handler))
 				}
-				return
+				return true
 			}
 		}
 	}
@@ -194,27 +272,68 @@ func (d *MessageDispatcher) Dispatch(session *discordgo.Session, message *discor
 		}
 		if handler.HandleAnything(cmdMessage) {
 			core.LogDebug("    => handled")
-			return
+			return true
 		}
 	}
+	return false
+}
+
+// splitCommand returns the lower-cased command word and the raw, unparsed remainder of the line.
+func splitCommand(line string) (string, string) {
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	end := strings.IndexFunc(line, unicode.IsSpace)
+	if end < 0 {
+		return strings.ToLower(line), ""
+	}
+	return strings.ToLower(line[:end]), line[end:]
+}
 
-	if isDirectAddressed {
-		cmdMessage.ReplyToSender("I'm not sure what you meant. You can use the %shelp command for a list of what I can do.", core.Settings.CommandPrefix())
+// commandUsageHelp formats the usage, help text, arguments and flags of a command.
+func (d *MessageDispatcher) commandUsageHelp(spec *MessageCommand, locale, prefix string) string {
+	output := []string{i18n.T(locale, "dispatch.usage", spec.Usage(prefix))}
+	if help := spec.LocalHelp(locale); help != "" {
+		output = append(output, help)
 	}
+	for _, arg := range spec.Args {
+		if help := spec.argHelp(locale, arg); help != "" {
+			output = append(output, fmt.Sprintf("\t*%s*: %s", arg.Name, help))
+		}
+	}
+	for _, flag := range spec.Flags {
+		name := "--" + flag.Name
+		if flag.Short != "" {
+			name += ", -" + flag.Short
+		}
+		output = append(output, fmt.Sprintf("\t*%s*: %s", name, spec.flagHelp(locale, flag)))
+	}
+	return strings.Join(output, "\n")
 }
 
 // Helper method to register a Command for a handler.
 func (d *MessageDispatcher) addHandlerForCommand(command MessageCommand, dict *map[string][]MessageHandler, handler MessageHandler) {
 	commandStr := strings.ToLower(command.Command)
+	command.Command = commandStr
 
-	helpString, group := command.Help, handler.CommandGroup()
-	if len(helpString) > 0 {
+	for i, arg := range command.Args {
+		if i < len(command.Args)-1 && (arg.Type == ArgRest || (arg.Optional && !command.Args[i+1].Optional)) {
+			core.LogWarnF("Command %s: argument <%s> must be the last one, or only followed by optional arguments.", commandStr, arg.Name)
+		}
+	}
+
+	group := handler.CommandGroup()
+	if len(command.Help) > 0 {
 		if d.commandHelp[group] == nil {
-			d.commandHelp[group] = map[string][]string{commandStr: {}}
-		} else if d.commandHelp[group][commandStr] == nil {
-			d.commandHelp[group][commandStr] = []string{}
+			d.commandHelp[group] = map[string][]*MessageCommand{}
 		}
-		d.commandHelp[group][commandStr] = append(d.commandHelp[group][commandStr], fmt.Sprint("\t**", core.Settings.CommandPrefix(), commandStr, "**: ", helpString, ""))
+		d.commandHelp[group][commandStr] = append(d.commandHelp[group][commandStr], &command)
+	}
+
+	isPrefix := dict == &d.prefixHandlers
+	d.logConflicts(commandStr, isPrefix, handler)
+	if isPrefix {
+		d.addPrefix(commandStr)
+	} else if _, exists := d.commandSpecs[commandStr]; !exists {
+		d.commandSpecs[commandStr] = &command
 	}
 
 	(*dict)[commandStr] = append((*dict)[commandStr], handler)
@@ -223,28 +342,11 @@ func (d *MessageDispatcher) addHandlerForCommand(command MessageCommand, dict *m
 	}
 }
 
-func comesFromDM(s *discordgo.Session, m *discordgo.Message) (bool, error) {
-	channel, err := s.State.Channel(m.ChannelID)
+func comesFromDM(s discord.Session, m *discordgo.Message) (bool, error) {
+	channel, err := s.Channel(m.ChannelID)
 	if err != nil {
-		if channel, err = s.Channel(m.ChannelID); err != nil {
-			return false, err
-		}
+		return false, err
 	}
 
 	return channel.Type == discordgo.ChannelTypeDM, nil
 }
-
-func parseCommandFlags(args []string) Flags {
-	flags := None
-
-	if funk.Contains(args, "-v") || funk.Contains(args, "--verbose") || funk.Contains(args, "verbose") {
-		flags |= Verbose
-	}
-	if funk.Contains(args, "-h") || funk.Contains(args, "help") {
-		flags |= Help
-	}
-	if funk.Contains(args, "here") || funk.Contains(args, "--here") {
-		flags |= Here
-	}
-	return flags
-}
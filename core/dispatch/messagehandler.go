@@ -1,63 +1,163 @@
 package dispatch
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"GoBot/core/i18n"
 	"github.com/bwmarrin/discordgo"
 )
 
-type Flags int
-
-const (
-	None    Flags = 0
-	Verbose Flags = 1 << iota
-	Help
-	Here
-)
-
-func (flags Flags) IsSet(check Flags) bool {
-	return (flags & check) == check
-}
-
 // MessageCommand is used when registering a handler.
 type MessageCommand struct {
-	Command string // Command name or prefix
-	Help    string // Help string
+	Command    string               // Command name or prefix
+	Help       string               // Help string
+	Args       []Arg                // Declared positional arguments. Commands without any accept free-form words in Message.Args
+	Flags      []Flag               // Declared flags, given as --name or -short
+	Slash      bool                 // Also register the command as a slash command
+	Ephemeral  bool                 // Slash command replies are only visible to the user
+	Permission core.PermissionLevel // Required permission level, enforced by the dispatcher
+	Examples   []string             // Example arguments, shown in the detailed help
 }
 
 // Message Container for a message, session and parsed arguments.
 type Message struct {
 	*discordgo.Message
-	*discordgo.Session
-	Command       string
-	Args, RawArgs []string
-	Flags         Flags
-	IsPM          bool
+	Session discord.Session
+	Command string
+	Args    []string // Free-form words, for handlers that don't declare arguments
+	Params  Params   // Parsed declared arguments and flags
+	Prefix  string   // Command prefix of the guild the message was sent in
+	IsPM    bool
+
+	// Set when the message was created from a slash command interaction
+	interaction *interactionReply
+	// Declaration of the command, nil for prefix, wildcard and custom commands
+	spec *MessageCommand
+	// Channel replies to the message, kept to update them when the message is edited
+	replies *replySet
+	// Whether the command failed, for the usage statistics
+	outcome *commandOutcome
+	// Cancelled when the handler timeout passes or the bot shuts down
+	ctx context.Context
 }
 
 type Test interface {
 	cat() string
 }
 
-// ReplyToChannel Utility method to send quick reply back to the channel
-func (m Message) ReplyToChannel(format string, v ...interface{}) {
-	m.ChannelMessageSend(m.ChannelID, fmt.Sprintf(format, v...))
+// GuildSettings returns the settings of the guild the message was sent in
+func (m Message) GuildSettings() *database.GuildSettings {
+	return database.FetchGuildSettings(m.GuildID)
 }
 
-// ReplyToSender Utility method to send a reply to the author of the message
-func (m Message) ReplyToSender(format string, v ...interface{}) chan struct{} {
-	sendDone := make(chan struct{})
-	go func() {
-		ch, err := m.UserChannelCreate(m.Author.ID)
-		if err != nil {
-			core.LogError("Failed to open private channel: ", err)
+// Locale returns the language to reply in: the one the author has chosen, then the language of their Discord client
+// for slash commands and components, then the one of the guild
+func (m Message) Locale() string {
+	if locale := database.FetchUserLocale(authorId(&m)); locale != "" {
+		return locale
+	}
+	if m.interaction != nil {
+		if locale, ok := i18n.Resolve(string(m.interaction.interaction.Locale)); ok {
+			return locale
 		}
-		m.ChannelMessageSend(ch.ID, fmt.Sprintf(format, v...))
-		sendDone <- struct{}{}
-	}()
-	return sendDone
+	}
+	return m.GuildSettings().Locale
+}
+
+// T returns a message from the catalog in the language of the reply, formatted with the arguments
+func (m Message) T(key string, v ...interface{}) string {
+	return i18n.T(m.Locale(), key, v...)
+}
+
+// argumentError returns the text of an error parsing the arguments, in the language of the reply
+func (m Message) argumentError(err error) string {
+	var argErr *ArgumentError
+	if errors.As(err, &argErr) {
+		return argErr.Localize(m.Locale())
+	}
+	return err.Error()
+}
+
+// Context returns the context of the command, cancelled when the handler timeout passes or the bot shuts down.
+// Handlers pass it on to requests that may be slow.
+func (m Message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// IsSlashCommand returns true if the message was created from a slash command interaction
+func (m Message) IsSlashCommand() bool {
+	return m.interaction != nil
+}
+
+// Spec returns the declaration of the command, or nil if it isn't a declared command
+func (m Message) Spec() *MessageCommand {
+	return m.spec
+}
+
+// Responder returns the responder used for replies to the message
+func (m Message) Responder() Responder {
+	if m.interaction != nil {
+		return m.interaction
+	}
+	return &channelResponder{session: m.Session, channelId: m.ChannelID, authorId: authorId(&m), locale: m.Locale(),
+		replies: m.replies}
+}
+
+// Respond sends a reply to the message. Errors are logged as well as returned.
+// Error replies, and replies that can't be sent, mark the command as failed.
+func (m Message) Respond(reply Reply) error {
+	if reply.Error {
+		m.MarkFailed()
+	}
+	err := m.Responder().Respond(reply)
+	if err != nil {
+		m.MarkFailed()
+		core.LogErrorF("Failed to reply to %s from %s: %s", m.Command, authorName(&m), err)
+	}
+	return err
+}
+
+// ReplyToChannel Utility method to send quick reply back to the channel
+func (m Message) ReplyToChannel(format string, v ...interface{}) error {
+	return m.Respond(Reply{Content: fmt.Sprintf(format, v...)})
+}
+
+// ReplyError Utility method to send an error back to the channel, marking the command as failed.
+// The error is labelled in the language of the reply.
+func (m Message) ReplyError(format string, v ...interface{}) error {
+	return m.Respond(Reply{Content: m.T("dispatch.error", fmt.Sprintf(format, v...)), Error: true})
+}
+
+// ReplyToSender Utility method to send a reply to the author of the message.
+// For slash commands this is an ephemeral reply.
+func (m Message) ReplyToSender(format string, v ...interface{}) error {
+	return m.Respond(Reply{Content: fmt.Sprintf(format, v...), Private: true})
+}
+
+// ReplyEmbedToChannel Utility method to send an embed back to the channel
+func (m Message) ReplyEmbedToChannel(embed *discordgo.MessageEmbed) error {
+	return m.Respond(Reply{Embeds: []*discordgo.MessageEmbed{embed}})
+}
+
+// ReplyEmbedToSender Utility method to send an embed to the author of the message.
+// For slash commands this is an ephemeral reply.
+func (m Message) ReplyEmbedToSender(embed *discordgo.MessageEmbed) error {
+	return m.Respond(Reply{Embeds: []*discordgo.MessageEmbed{embed}, Private: true})
+}
+
+// ReplyFileToChannel Utility method to send a file attachment back to the channel, with an optional message
+func (m Message) ReplyFileToChannel(name string, data io.Reader, format string, v ...interface{}) error {
+	return m.Respond(Reply{Content: fmt.Sprintf(format, v...), Files: []*discordgo.File{{Name: name, Reader: data}}})
 }
 
 // MessageHandler Interface used for message handlers
@@ -72,8 +172,6 @@ type MessageHandler interface {
 	CommandGroup() string
 	// SettingsLoaded Called when settings file are loaded
 	SettingsLoaded()
-	// SlashCommands Returns any slash command registrations for this handler
-	//	SlashCommands() []*discordgo.ApplicationCommand
 }
 
 // NoOpMessageHandler Each message handler can process one or more commands / message responses
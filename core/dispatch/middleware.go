@@ -0,0 +1,246 @@
+package dispatch
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// Next runs the rest of the middleware chain and finally the handlers. Returns true if the message was handled.
+type Next func() bool
+
+// MiddlewareFunc runs around the handlers of a command. It can call next to continue, or short-circuit by
+// replying and returning true without calling it. Returning false means the command wasn't handled.
+type MiddlewareFunc func(m *Message, next Next) bool
+
+// Middleware is a named step in the dispatch chain
+type Middleware struct {
+	Name   string
+	Handle MiddlewareFunc
+}
+
+// CommandMetrics holds the counters kept by the timing middleware for a command
+type CommandMetrics struct {
+	Calls     int
+	Unhandled int
+	Total     time.Duration
+	Max       time.Duration
+}
+
+// Average returns the average time spent handling the command
+func (c CommandMetrics) Average() time.Duration {
+	if c.Calls == 0 {
+		return 0
+	}
+	return c.Total / time.Duration(c.Calls)
+}
+
+const slowCommandThreshold = 5 * time.Second
+
+var (
+	commandCooldowns   = make(map[string]time.Time)
+	commandCooldownsMu sync.Mutex
+)
+
+// The built-in middlewares, outermost first. Middlewares registered later run inside these.
+func init() {
+	RegisterMiddleware("usage", usageMiddleware)
+	RegisterMiddleware("recover", recoverMiddleware)
+	RegisterMiddleware("timing", Dispatcher.timingMiddleware)
+	RegisterMiddleware("audit", auditMiddleware)
+	RegisterMiddleware("ratelimit", Dispatcher.rateLimitMiddleware)
+	RegisterMiddleware("cooldown", Dispatcher.cooldownMiddleware)
+	RegisterMiddleware("permission", permissionMiddleware)
+}
+
+// RegisterMiddleware adds a middleware to the end of the chain, closest to the handlers
+func RegisterMiddleware(name string, handle MiddlewareFunc) {
+	Dispatcher.addMiddleware(name, handle)
+}
+
+func (d *MessageDispatcher) addMiddleware(name string, handle MiddlewareFunc) {
+	d.middlewares = append(d.middlewares, Middleware{Name: name, Handle: handle})
+	core.LogInfoF("Registered middleware: %s", name)
+}
+
+// runMiddleware runs the message through the middleware chain, with handlers at the end
+func (d *MessageDispatcher) runMiddleware(m *Message, handlers Next) bool {
+	var run func(i int) bool
+	run = func(i int) bool {
+		if i == len(d.middlewares) {
+			return handlers()
+		}
+		return d.middlewares[i].Handle(m, func() bool { return run(i + 1) })
+	}
+	return run(0)
+}
+
+// Metrics returns a copy of the timing counters, by command
+func (d *MessageDispatcher) Metrics() map[string]CommandMetrics {
+	d.metricsMu.Lock()
+	defer d.metricsMu.Unlock()
+	metrics := make(map[string]CommandMetrics, len(d.metrics))
+	for command, m := range d.metrics {
+		metrics[command] = *m
+	}
+	return metrics
+}
+
+// recoverMiddleware keeps a panicking handler from taking down the bot
+func recoverMiddleware(m *Message, next Next) (handled bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			core.LogErrorF("Panic while handling %s from %s: %v\n%s", m.Command, authorName(m), r, debug.Stack())
+			m.MarkFailed()
+			m.ReplyError("%s", m.T("dispatch.panic"))
+			handled = true
+		}
+	}()
+	return next()
+}
+
+// timingMiddleware records how often and how long each command runs, and logs slow commands
+func (d *MessageDispatcher) timingMiddleware(m *Message, next Next) bool {
+	start := time.Now()
+	handled := next()
+	elapsed := time.Since(start)
+
+	d.metricsMu.Lock()
+	metrics := d.metrics[m.Command]
+	if metrics == nil {
+		metrics = &CommandMetrics{}
+		d.metrics[m.Command] = metrics
+	}
+	metrics.Calls++
+	if !handled {
+		metrics.Unhandled++
+	}
+	metrics.Total += elapsed
+	if elapsed > metrics.Max {
+		metrics.Max = elapsed
+	}
+	d.metricsMu.Unlock()
+
+	if elapsed > slowCommandThreshold {
+		core.LogWarnF("Command %s took %s", m.Command, elapsed)
+	} else {
+		core.LogDebugF("Command %s took %s", m.Command, elapsed)
+	}
+	return handled
+}
+
+// auditMiddleware logs every use of a command that requires elevated permissions
+func auditMiddleware(m *Message, next Next) bool {
+	handled := next()
+	if spec := m.Spec(); spec != nil && spec.Permission > core.PermissionUser {
+		where := "DM"
+		if !m.IsPM {
+			where = fmt.Sprintf("channel %s in guild %s", m.ChannelID, m.GuildID)
+		}
+		core.LogInfoF("Audit: %s (%s) ran %s%s %s in %s, handled: %t", authorName(m), authorId(m), m.Prefix, m.Command,
+			strings.Join(m.Args, " "), where, handled)
+	}
+	return handled
+}
+
+// permissionMiddleware stops commands the user doesn't have permission to run
+func permissionMiddleware(m *Message, next Next) bool {
+	if spec := m.Spec(); spec != nil && !m.HasPermission(spec.Permission) {
+		m.MarkFailed()
+		if m.IsSlashCommand() {
+			m.ReplyToSender(m.T("dispatch.permission_denied"), spec.Permission)
+		} else {
+			m.ReplyToChannel(m.T("dispatch.permission_denied"), spec.Permission)
+		}
+		return true
+	}
+	return next()
+}
+
+// fetchCommandAlias looks up the custom command of a message, replaced in tests
+var fetchCommandAlias = database.FetchCommandAlias
+
+// cooldownMiddleware applies the guild cooldown to custom commands. DMs and bot channels are exempt, as are commands
+// with a cooldown of their own, which the custom command handler applies. Commands on cooldown are silently ignored.
+// The cooldown only starts when the command was run, not when a later check such as its channel or role
+// restrictions turned it down.
+func (d *MessageDispatcher) cooldownMiddleware(m *Message, next Next) bool {
+	if m.IsPM || m.Spec() != nil {
+		return next()
+	}
+	if _, _, found := d.BuiltinRoute(m.Command); found {
+		return next()
+	}
+	settings := m.GuildSettings()
+	if settings.IsBotChannel(m.ChannelID) || settings.CustomCommandCooldown <= 0 {
+		return next()
+	}
+	if alias := fetchCommandAlias(m.Command); alias == nil || alias.Cooldown != nil {
+		return next()
+	}
+	if cooldownLeft(m.Command, m.ChannelID, settings.CustomCommandCooldown) > 0 {
+		return true
+	}
+	handled := next()
+	if handled && !m.Failed() {
+		startCooldown(m.Command, m.ChannelID)
+	}
+	return handled
+}
+
+// CooldownRemaining returns how long a command is still on cooldown for a key, such as a channel ID. If it isn't on
+// cooldown it returns 0, and the cooldown starts over.
+func CooldownRemaining(command, key string, cooldownSeconds int) time.Duration {
+	if cooldownSeconds <= 0 {
+		return 0
+	}
+	commandCooldownsMu.Lock()
+	defer commandCooldownsMu.Unlock()
+	if remaining := cooldownLeftLocked(command, key, cooldownSeconds); remaining > 0 {
+		return remaining
+	}
+	commandCooldowns[command+":"+key] = time.Now()
+	return 0
+}
+
+// cooldownLeft returns how long a command is still on cooldown for a key, without starting it
+func cooldownLeft(command, key string, cooldownSeconds int) time.Duration {
+	commandCooldownsMu.Lock()
+	defer commandCooldownsMu.Unlock()
+	return cooldownLeftLocked(command, key, cooldownSeconds)
+}
+
+func cooldownLeftLocked(command, key string, cooldownSeconds int) time.Duration {
+	if lastUsed, exists := commandCooldowns[command+":"+key]; exists {
+		if remaining := time.Duration(cooldownSeconds)*time.Second - time.Since(lastUsed); remaining > 0 {
+			return remaining
+		}
+	}
+	return 0
+}
+
+// startCooldown starts the cooldown of a command for a key over
+func startCooldown(command, key string) {
+	commandCooldownsMu.Lock()
+	defer commandCooldownsMu.Unlock()
+	commandCooldowns[command+":"+key] = time.Now()
+}
+
+func authorName(m *Message) string {
+	if m.Author == nil {
+		return "unknown"
+	}
+	return m.Author.Username
+}
+
+func authorId(m *Message) string {
+	if m.Author == nil {
+		return ""
+	}
+	return m.Author.ID
+}
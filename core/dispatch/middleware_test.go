@@ -0,0 +1,162 @@
+package dispatch
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRunMiddleware(t *testing.T) {
+	d := newTestDispatcher()
+	var calls []string
+	record := func(name string) MiddlewareFunc {
+		return func(m *Message, next Next) bool {
+			calls = append(calls, name+" before")
+			handled := next()
+			calls = append(calls, name+" after")
+			return handled
+		}
+	}
+	d.addMiddleware("outer", record("outer"))
+	d.addMiddleware("inner", record("inner"))
+
+	handled := d.runMiddleware(&Message{Command: "test"}, func() bool {
+		calls = append(calls, "handlers")
+		return true
+	})
+	if !handled {
+		t.Error("Expected the message to be handled")
+	}
+	expected := "outer before,inner before,handlers,inner after,outer after"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(calls, ","))
+	}
+}
+
+func TestRunMiddleware_ShortCircuit(t *testing.T) {
+	d := newTestDispatcher()
+	d.addMiddleware("stop", func(m *Message, next Next) bool {
+		return true
+	})
+	handlersRan := false
+	handled := d.runMiddleware(&Message{Command: "test"}, func() bool {
+		handlersRan = true
+		return true
+	})
+	if !handled || handlersRan {
+		t.Errorf("Expected the middleware to handle the message without running the handlers, handled %t, ran %t", handled, handlersRan)
+	}
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	d := newTestDispatcher()
+	d.addMiddleware("timing", d.timingMiddleware)
+	d.runMiddleware(&Message{Command: "dist"}, func() bool { return true })
+	d.runMiddleware(&Message{Command: "dist"}, func() bool { return false })
+
+	metrics := d.Metrics()["dist"]
+	if metrics.Calls != 2 || metrics.Unhandled != 1 {
+		t.Errorf("Expected 2 calls and 1 unhandled, got %d and %d", metrics.Calls, metrics.Unhandled)
+	}
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	var recorded []database.CommandUsage
+	recordUsage = func(usage database.CommandUsage) bool {
+		recorded = append(recorded, usage)
+		return true
+	}
+	defer func() { recordUsage = database.QueueCommandUsage }()
+
+	d := newTestDispatcher()
+	d.addMiddleware("usage", usageMiddleware)
+	session := discord.NewFake("bot")
+	session.AddChannel(&discordgo.Channel{ID: "channel1", GuildID: "guild1"})
+	message := func() *Message {
+		return &Message{Message: &discordgo.Message{Author: &discordgo.User{ID: "user1"}, ChannelID: "channel1", GuildID: "guild1"},
+			Session: session, Command: "dist"}
+	}
+	d.runMiddleware(message(), func() bool { return true })
+	d.runMiddleware(message(), func() bool { return false })
+	errorReply := message()
+	d.runMiddleware(errorReply, func() bool {
+		errorReply.ReplyError("System not found.")
+		return true
+	})
+	marked := message()
+	d.runMiddleware(marked, func() bool {
+		marked.MarkFailed()
+		return true
+	})
+
+	if len(recorded) != 3 {
+		t.Fatalf("Expected 3 handled commands recorded, got %d", len(recorded))
+	}
+	if usage := recorded[0]; usage.Command != "dist" || usage.UserId != "user1" || usage.GuildId != "guild1" || !usage.Success {
+		t.Errorf("Expected a successful use of dist by user1 in guild1, got %+v", usage)
+	}
+	if recorded[1].Success {
+		t.Error("Expected the command with an error reply to be recorded as a failure")
+	}
+	if recorded[2].Success {
+		t.Error("Expected the command marked as failed to be recorded as a failure")
+	}
+}
+
+func TestCooldownRemaining(t *testing.T) {
+	if remaining := CooldownRemaining("cooldowntest", "channel1", 60); remaining != 0 {
+		t.Errorf("Expected the first use to be allowed, got %s", remaining)
+	}
+	if remaining := CooldownRemaining("cooldowntest", "channel1", 60); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("Expected the second use to be on cooldown for up to a minute, got %s", remaining)
+	}
+	if remaining := CooldownRemaining("cooldowntest", "channel2", 60); remaining != 0 {
+		t.Errorf("Expected another channel to have its own cooldown, got %s", remaining)
+	}
+	if remaining := CooldownRemaining("cooldowntest", "channel1", 0); remaining != 0 {
+		t.Errorf("Expected no cooldown to always be allowed, got %s", remaining)
+	}
+}
+
+func TestCooldownMiddleware(t *testing.T) {
+	d := newTestDispatcher()
+	d.addHandlerForCommand(MessageCommand{Command: "ping"}, &d.commandHandlers, &echoHandler{})
+	var lookups []string
+	fetchCommandAlias = func(cmd string) *database.CommandAlias {
+		lookups = append(lookups, cmd)
+		return &database.CommandAlias{Command: cmd}
+	}
+	core.Settings.SetTestCustomCommandCooldown(60)
+	defer func() {
+		fetchCommandAlias = database.FetchCommandAlias
+		core.Settings.SetTestCustomCommandCooldown(0)
+	}()
+
+	run := func(command string, failed bool) bool {
+		ran := false
+		m := &Message{Command: command, Message: &discordgo.Message{ChannelID: "cooldown-channel"}, outcome: &commandOutcome{}}
+		d.cooldownMiddleware(m, func() bool {
+			ran = true
+			if failed {
+				m.MarkFailed()
+			}
+			return true
+		})
+		return ran
+	}
+
+	if !run("ping", false) || !run("ping", false) || len(lookups) > 0 {
+		t.Errorf("Expected built-in commands to run without a cooldown or alias lookup, looked up %v", lookups)
+	}
+	if !run("faq", true) || !run("faq", false) {
+		t.Error("Expected a turned down command not to start the cooldown")
+	}
+	if run("faq", false) {
+		t.Error("Expected the command to be on cooldown after it ran")
+	}
+}
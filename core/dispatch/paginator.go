@@ -0,0 +1,132 @@
+package dispatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Pages are turned with buttons in the page namespace. The action is the name of the listing and the data is
+// "page:owner:args", so the listing is read again from the start for every page.
+const (
+	pageComponents = "page"
+	pageButtonsTTL = 15 * time.Minute
+	pageCurrent    = "-"
+)
+
+// Page is the part of a listing shown in one reply
+type Page struct {
+	Number int    // Page number, starting at 0
+	Size   int    // Entries per page
+	Args   string // Arguments of the listing, such as a sort order
+}
+
+// Offset returns the index of the first entry on the page
+func (p Page) Offset() int {
+	return p.Number * p.Size
+}
+
+// PageSource renders a page of a listing, and returns it with the total number of entries in the listing
+type PageSource func(m *Message, page Page) (reply Reply, total int)
+
+// Paginator shows a listing a page at a time, with buttons for the previous and next pages. The buttons can
+// only be used by the user the listing was shown to. Register it with RegisterPaginator.
+type Paginator struct {
+	Name   string // Unique name of the listing
+	Size   int    // Entries per page
+	Source PageSource
+}
+
+// RegisterPaginator routes the page buttons of the listing to the paginator
+func RegisterPaginator(p *Paginator) {
+	Dispatcher.registerPaginator(p)
+}
+
+func (d *MessageDispatcher) registerPaginator(p *Paginator) {
+	if d.paginators == nil {
+		d.paginators = map[string]*Paginator{}
+		d.registerComponents(pageComponents, core.PermissionUser, d.turnPage)
+	}
+	if _, exists := d.paginators[p.Name]; exists {
+		core.LogWarnF("Paginator %s is already registered, replacing it.", p.Name)
+	}
+	d.paginators[p.Name] = p
+}
+
+// Respond replies with the first page of the listing
+func (p *Paginator) Respond(m *Message, args string, private bool) error {
+	reply := p.Render(m, args, 0)
+	reply.Private = private
+	return m.Respond(reply)
+}
+
+// Render returns a page of the listing for the author of the message, with the page buttons when there's more
+// than one page. Pages past the end show the last page, as the listing may have shrunk.
+func (p *Paginator) Render(m *Message, args string, number int) Reply {
+	page := Page{Number: number, Size: p.Size, Args: args}
+	if page.Number < 0 {
+		page.Number = 0
+	}
+	reply, total := p.Source(m, page)
+	pages := (total + p.Size - 1) / p.Size
+	if pages > 0 && page.Number >= pages {
+		page.Number = pages - 1
+		reply, total = p.Source(m, page)
+		pages = (total + p.Size - 1) / p.Size
+	}
+	if pages > 1 {
+		reply.Components = append(reply.Components, p.buttons(authorId(m), page, pages))
+	}
+	return reply
+}
+
+// buttons returns the row of page buttons, with the page number in between
+func (p *Paginator) buttons(owner string, page Page, pages int) discordgo.ActionsRow {
+	previousId, previousOk := p.pageID(owner, page.Number-1, page.Args)
+	previous := discordgo.Button{Label: "◀", Style: discordgo.SecondaryButton, Disabled: page.Number == 0 || !previousOk,
+		CustomID: previousId}
+	current := discordgo.Button{Label: fmt.Sprintf("%d/%d", page.Number+1, pages), Style: discordgo.SecondaryButton,
+		Disabled: true, CustomID: ComponentID(pageComponents, p.Name, pageCurrent, 0)}
+	nextId, nextOk := p.pageID(owner, page.Number+1, page.Args)
+	next := discordgo.Button{Label: "▶", Style: discordgo.SecondaryButton, Disabled: page.Number == pages-1 || !nextOk,
+		CustomID: nextId}
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{previous, current, next}}
+}
+
+// pageID returns the custom ID of a button showing a page, storing the arguments if they don't fit in it. If they
+// can't be stored, it returns false with an ID of the page alone, unique in the row, for a disabled button.
+func (p *Paginator) pageID(owner string, number int, args string) (string, bool) {
+	data := strings.Join([]string{strconv.Itoa(number), owner, args}, componentSep)
+	id := ComponentID(pageComponents, p.Name, data, pageButtonsTTL)
+	if len(id) <= componentIdLimit {
+		return id, true
+	}
+	id, err := StatefulComponentID(pageComponents, p.Name, data, pageButtonsTTL)
+	if err != nil {
+		core.LogErrorF("Failed to store the page state of %s: %s", p.Name, err)
+		return ComponentID(pageComponents, p.Name, strconv.Itoa(number), 0), false
+	}
+	return id, true
+}
+
+// turnPage shows the page of a listing that a page button points to
+func (d *MessageDispatcher) turnPage(m *Message, click *ComponentClick) {
+	p := d.paginators[click.Action]
+	parts := strings.SplitN(click.Data, componentSep, 3)
+	if p == nil || len(parts) != 3 {
+		return
+	}
+	number, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	if parts[1] != authorId(m) {
+		m.ReplyToChannel("%s", m.T("dispatch.page_not_yours"))
+		return
+	}
+	m.Update(p.Render(m, parts[2], number))
+}
@@ -0,0 +1,94 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPaginator(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	entries := 7
+	p := &Paginator{Name: "numbers", Size: 3, Source: func(m *Message, page Page) (Reply, int) {
+		var lines []string
+		for i := page.Offset(); i < entries && i < page.Offset()+page.Size; i++ {
+			lines = append(lines, fmt.Sprintf("%s %d", page.Args, i))
+		}
+		return Reply{Content: strings.Join(lines, "\n")}, entries
+	}}
+	d.registerPaginator(p)
+
+	m := &Message{Message: testMessage("p1", "10", "numbers"), Session: fake, Command: "numbers"}
+	if err := p.Respond(m, "n", false); err != nil {
+		t.Fatalf("Failed to respond: %s", err)
+	}
+	sent := fake.SentTo("10")
+	if len(sent) != 1 || sent[0].Content != "n 0\nn 1\nn 2" {
+		t.Fatalf("Expected the first page, got %v", sent)
+	}
+
+	click := func(userId string, message *discordgo.Message, button int) {
+		customId := pageButton(t, message, button).CustomID
+		interaction := &discordgo.Interaction{
+			ID:        customId,
+			Type:      discordgo.InteractionMessageComponent,
+			ChannelID: "10",
+			GuildID:   "20",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: userId}},
+			Message:   message,
+			Data:      discordgo.MessageComponentInteractionData{CustomID: customId},
+		}
+		d.HandleInteraction(context.Background(), fake, &discordgo.InteractionCreate{Interaction: interaction})
+	}
+
+	if !pageButton(t, sent[0], 0).Disabled || pageButton(t, sent[0], 1).Label != "1/3" {
+		t.Error("Expected the previous button to be disabled on the first page")
+	}
+	click("1", sent[0], 2)
+	click("1", fake.SentTo("10")[0], 2)
+	sent = fake.SentTo("10")
+	if len(sent) != 1 || sent[0].Content != "n 6" || !pageButton(t, sent[0], 2).Disabled {
+		t.Fatalf("Expected the last page with the next button disabled, got %v", sent)
+	}
+
+	click("2", sent[0], 0)
+	sent = fake.SentTo("10")
+	if len(sent) != 2 || sent[0].Content != "n 6" || sent[1].Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("Expected other users not to turn the pages, got %v", sent)
+	}
+
+	entries = 2
+	click("1", sent[0], 0)
+	if sent = fake.SentTo("10"); sent[0].Content != "n 0\nn 1" || len(sent[0].Components) != 0 {
+		t.Errorf("Expected the only page left without buttons, got %v", sent[0])
+	}
+}
+
+func TestPaginator_StateNotStored(t *testing.T) {
+	p := &Paginator{Name: "numbers", Size: 3}
+	// The arguments don't fit in the custom ID, and can't be stored without a database
+	row := p.buttons("1", Page{Number: 1, Size: 3, Args: strings.Repeat("x", 100)}, 3)
+	ids := map[string]bool{}
+	for i, component := range row.Components {
+		button := component.(discordgo.Button)
+		if !button.Disabled {
+			t.Errorf("Expected button %d to be disabled", i)
+		}
+		ids[button.CustomID] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("Expected unique custom IDs in the row, got %v", ids)
+	}
+}
+
+// pageButton returns a button from the page row, the last row of the message
+func pageButton(t *testing.T, message *discordgo.Message, index int) discordgo.Button {
+	if len(message.Components) == 0 {
+		t.Fatalf("Expected page buttons on %q", message.Content)
+	}
+	row := message.Components[len(message.Components)-1].(discordgo.ActionsRow)
+	return row.Components[index].(discordgo.Button)
+}
@@ -0,0 +1,85 @@
+package dispatch
+
+import (
+	"slices"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Permission resolves the permission level of the author of the message. Owners come from the config file,
+// granted roles from the userrole table and Discord roles from the guild role mappings. Carrier owners are
+// always carrier managers, and everyone in an admin channel is an admin.
+func (m *Message) Permission() core.PermissionLevel {
+	if m.Author == nil {
+		return core.PermissionUser
+	}
+	if core.Settings.IsOwner(m.Author.ID) {
+		return core.PermissionOwner
+	}
+
+	level := database.FetchUserRole(m.Author.ID)
+	if core.Settings.IsAdminChannel(m.ChannelID) && level < core.PermissionAdmin {
+		level = core.PermissionAdmin
+	}
+	if core.Settings.IsCarrierOwner(m.Author.ID) && level < core.PermissionCarrierManager {
+		level = core.PermissionCarrierManager
+	}
+
+	if m.GuildID == "" {
+		return level
+	}
+	memberRoles := map[string]bool{}
+	for _, role := range m.memberRoles() {
+		memberRoles[role] = true
+	}
+	for _, mapping := range database.FetchGuildRoles(m.GuildID) {
+		if memberRoles[mapping.RoleId] && mapping.Role > level {
+			level = mapping.Role
+		}
+	}
+	return level
+}
+
+// AuthorPermission resolves the permission level of the author of a message that isn't a command, such as a
+// carrier update, the same way as for commands
+func AuthorPermission(s discord.Session, message *discordgo.Message) core.PermissionLevel {
+	m := &Message{Message: message, Session: s}
+	return m.Permission()
+}
+
+// HasPermission returns true if the author of the message has at least the given permission level
+func (m *Message) HasPermission(level core.PermissionLevel) bool {
+	return level <= core.PermissionUser || m.Permission() >= level
+}
+
+// HasAnyRole returns true if the author of the message has one of the Discord roles in the guild it was sent in
+func (m *Message) HasAnyRole(roleIds []string) bool {
+	if m.GuildID == "" || m.Author == nil {
+		return false
+	}
+	for _, role := range m.memberRoles() {
+		if slices.Contains(roleIds, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// memberRoles returns the Discord role IDs of the author in the guild the message was sent in
+func (m *Message) memberRoles() []string {
+	if m.Member != nil && len(m.Member.Roles) > 0 {
+		return m.Member.Roles
+	}
+	if m.Session == nil {
+		return nil
+	}
+	member, err := m.Session.Member(m.GuildID, m.Author.ID)
+	if err != nil {
+		core.LogErrorF("Failed to fetch member %s in guild %s: %s", m.Author.ID, m.GuildID, err)
+		return nil
+	}
+	return member.Roles
+}
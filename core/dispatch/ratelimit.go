@@ -0,0 +1,164 @@
+package dispatch
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"GoBot/core"
+)
+
+const rateLimitPruneSize = 1000 // Number of buckets before full ones are dropped
+
+// tokenBucket holds up to RateLimit.Commands tokens, refilled at Commands per Seconds. Each command takes one.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	refill time.Duration // Time to refill from empty
+}
+
+// rateCheck is a bucket a command has to take a token from
+type rateCheck struct {
+	key   string
+	limit core.RateLimit
+}
+
+type rateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	notified map[string]time.Time // When a throttled user was last told, by user ID
+	now      func() time.Time
+}
+
+var limiter = newRateLimiter()
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:  map[string]*tokenBucket{},
+		notified: map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// allow takes a token from every bucket, or none if any of them is empty. When throttled it returns how long
+// until all buckets have a token again.
+func (r *rateLimiter) allow(checks []rateCheck) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if len(r.buckets) > rateLimitPruneSize {
+		r.prune(now)
+	}
+
+	var wait time.Duration
+	var buckets []*tokenBucket
+	for _, check := range checks {
+		if !check.limit.Enabled() {
+			continue
+		}
+		capacity := float64(check.limit.Commands)
+		rate := capacity / check.limit.Seconds
+		bucket := r.buckets[check.key]
+		if bucket == nil {
+			bucket = &tokenBucket{tokens: capacity, last: now, refill: time.Duration(check.limit.Seconds * float64(time.Second))}
+			r.buckets[check.key] = bucket
+		}
+		bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+		bucket.last = now
+		if bucket.tokens < 1 {
+			if w := time.Duration((1 - bucket.tokens) / rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	return true, 0
+}
+
+// prune drops buckets that have been idle long enough to be full again
+func (r *rateLimiter) prune(now time.Time) {
+	for key, bucket := range r.buckets {
+		if now.Sub(bucket.last) > bucket.refill {
+			delete(r.buckets, key)
+		}
+	}
+	for userId, until := range r.notified {
+		if now.After(until) {
+			delete(r.notified, userId)
+		}
+	}
+}
+
+// shouldNotify returns true if the user hasn't already been told they're throttled in the current window
+func (r *rateLimiter) shouldNotify(userId string, wait time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if until, ok := r.notified[userId]; ok && now.Before(until) {
+		return false
+	}
+	r.notified[userId] = now.Add(wait)
+	return true
+}
+
+// rateChecks returns the buckets a command counts against
+func (d *MessageDispatcher) rateChecks(m *Message, limits core.RateLimitConfig) []rateCheck {
+	userId := authorId(m)
+	checks := []rateCheck{
+		{key: "user:" + userId, limit: limits.User},
+		{key: "channel:" + m.ChannelID, limit: limits.Channel},
+	}
+	if group := d.commandGroup(m.Command); group != "" {
+		for name, limit := range limits.Groups {
+			if strings.EqualFold(name, group) {
+				checks = append(checks, rateCheck{key: "group:" + group + ":" + userId, limit: limit})
+			}
+		}
+	}
+	return checks
+}
+
+// commandGroup returns the group of the handler a command or prefix is registered to
+func (d *MessageDispatcher) commandGroup(command string) string {
+	if handlers := d.commandHandlers[command]; len(handlers) > 0 {
+		return handlers[0].CommandGroup()
+	}
+	for _, prefix := range d.matchingPrefixes(command) {
+		if handlers := d.prefixHandlers[prefix]; len(handlers) > 0 {
+			return handlers[0].CommandGroup()
+		}
+	}
+	return ""
+}
+
+// rateLimitMiddleware throttles users and channels sending too many commands or component clicks. Owners and bot
+// channels are exempt, and unknown commands don't count. A throttled user is told once per window, further commands
+// are ignored.
+func (d *MessageDispatcher) rateLimitMiddleware(m *Message, next Next) bool {
+	if core.Settings.IsOwner(authorId(m)) || (!m.IsPM && m.GuildSettings().IsBotChannel(m.ChannelID)) ||
+		!(m.isComponentClick() || d.routesCommand(m.Command)) {
+		return next()
+	}
+	allowed, wait := limiter.allow(d.rateChecks(m, core.Settings.RateLimits()))
+	if allowed {
+		return next()
+	}
+
+	core.LogDebugF("Rate limited %s from %s for %s", m.Command, authorName(m), wait)
+	m.MarkFailed()
+	seconds := int(math.Ceil(wait.Seconds()))
+	if m.IsSlashCommand() {
+		m.ReplyToSender(m.T("dispatch.rate_limited"), seconds)
+	} else if limiter.shouldNotify(authorId(m), wait) {
+		m.ReplyToChannel(m.T("dispatch.rate_limited_mention"), authorId(m), seconds)
+	}
+	return true
+}
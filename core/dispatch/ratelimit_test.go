@@ -0,0 +1,110 @@
+package dispatch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+func newTestRateLimiter(now *time.Time) *rateLimiter {
+	r := newRateLimiter()
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRateLimiter(&now)
+	checks := []rateCheck{{key: "user:1", limit: core.RateLimit{Commands: 3, Seconds: 30}}}
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := r.allow(checks); !allowed {
+			t.Fatalf("Expected command %d to be allowed within the burst", i+1)
+		}
+	}
+	allowed, wait := r.allow(checks)
+	if allowed {
+		t.Fatal("Expected the fourth command to be throttled")
+	}
+	if wait != 10*time.Second {
+		t.Errorf("Expected to wait 10s for the next token, got %s", wait)
+	}
+
+	now = now.Add(10 * time.Second)
+	if allowed, _ := r.allow(checks); !allowed {
+		t.Error("Expected a command to be allowed after a token was refilled")
+	}
+}
+
+func TestRateLimiter_AllOrNothing(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRateLimiter(&now)
+	user := rateCheck{key: "user:1", limit: core.RateLimit{Commands: 5, Seconds: 60}}
+	group := rateCheck{key: "group:EDSM Queries:1", limit: core.RateLimit{Commands: 1, Seconds: 60}}
+
+	if allowed, _ := r.allow([]rateCheck{user, group}); !allowed {
+		t.Fatal("Expected the first command to be allowed")
+	}
+	if allowed, _ := r.allow([]rateCheck{user, group}); allowed {
+		t.Fatal("Expected the group limit to throttle the second command")
+	}
+	if tokens := r.buckets["user:1"].tokens; tokens != 4 {
+		t.Errorf("Expected a throttled command not to use a user token, got %v tokens left", tokens)
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	now := time.Now()
+	r := newTestRateLimiter(&now)
+	for i := 0; i < 100; i++ {
+		if allowed, _ := r.allow([]rateCheck{{key: "user:1"}}); !allowed {
+			t.Fatal("Expected no throttling without a configured limit")
+		}
+	}
+}
+
+func TestRateLimiter_ShouldNotify(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRateLimiter(&now)
+	if !r.shouldNotify("1", 10*time.Second) {
+		t.Error("Expected the first throttled command to be answered")
+	}
+	if r.shouldNotify("1", 10*time.Second) {
+		t.Error("Expected no second reply within the window")
+	}
+	now = now.Add(11 * time.Second)
+	if !r.shouldNotify("1", 10*time.Second) {
+		t.Error("Expected a reply in the next window")
+	}
+}
+
+func TestRateLimitMiddleware_UnknownCommands(t *testing.T) {
+	core.Settings.SetTestRateLimits(core.RateLimitConfig{User: core.RateLimit{Commands: 1, Seconds: 60}})
+	defer core.Settings.SetTestRateLimits(core.RateLimitConfig{})
+	d, fake := newEchoDispatcher()
+	d.addMiddleware("ratelimit", d.rateLimitMiddleware)
+	dm := discord.DMChannelID("ratelimit")
+	fake.AddChannel(&discordgo.Channel{ID: dm, Type: discordgo.ChannelTypeDM})
+	message := func(id, content string) *discordgo.Message {
+		m := testMessage(id, dm, content)
+		m.Author.ID = "ratelimit"
+		return m
+	}
+
+	d.Dispatch(context.Background(), fake, message("r1", "nosuchcommand"))
+	d.Dispatch(context.Background(), fake, message("r2", "echo one"))
+	d.Dispatch(context.Background(), fake, message("r3", "echo two"))
+
+	sent := fake.SentTo(dm)
+	if len(sent) != 2 || sent[0].Content != "You said: one" {
+		t.Fatalf("Expected the unknown command not to use the only token, got %v", sent)
+	}
+	if !strings.Contains(sent[1].Content, "too quickly") {
+		t.Errorf("Expected the second echo to be throttled, got %q", sent[1].Content)
+	}
+}
@@ -0,0 +1,142 @@
+package dispatch
+
+import (
+	"sync"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// trackedReply is a message the bot sent in reply to a command
+type trackedReply struct {
+	channelId string
+	messageId string
+}
+
+// trackedCommand is a command message and the replies sent to it
+type trackedCommand struct {
+	content string
+	replies []trackedReply
+	at      time.Time
+}
+
+// replyTracker remembers the replies to recent commands, so they can be updated when the command is edited
+type replyTracker struct {
+	mu       sync.Mutex
+	commands map[string]*trackedCommand // By ID of the command message
+	now      func() time.Time
+}
+
+var replies = newReplyTracker()
+
+func newReplyTracker() *replyTracker {
+	return &replyTracker{commands: map[string]*trackedCommand{}, now: time.Now}
+}
+
+// start begins tracking replies to a command message, dropping commands older than the edit window. An edited
+// command keeps the time it was first sent, so the window isn't extended by editing it.
+func (t *replyTracker) start(messageId, content string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	for id, command := range t.commands {
+		if now.Sub(command.at) > core.Settings.ReplyEditWindow() {
+			delete(t.commands, id)
+		}
+	}
+	if _, tracked := t.commands[messageId]; !tracked {
+		t.commands[messageId] = &trackedCommand{content: content, at: now}
+	}
+}
+
+// add records a reply sent to a command message
+func (t *replyTracker) add(messageId string, reply trackedReply) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if command := t.commands[messageId]; command != nil {
+		command.replies = append(command.replies, reply)
+	}
+}
+
+// edited returns the replies to the earlier version of an edited message. It returns false if the message isn't a
+// command sent within the edit window, or if the content didn't change, as Discord also sends updates when it adds
+// link previews. The returned replies are no longer tracked.
+func (t *replyTracker) edited(messageId, content string) ([]trackedReply, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	command := t.commands[messageId]
+	if command == nil || t.now().Sub(command.at) > core.Settings.ReplyEditWindow() {
+		return nil, false
+	}
+	if command.content == content {
+		return nil, false
+	}
+	previous := command.replies
+	command.content, command.replies = content, nil
+	return previous, true
+}
+
+// replySet sends the channel replies for one dispatch of a command. When the command was edited, the earlier
+// replies are edited in order instead of sending new ones.
+type replySet struct {
+	mu        sync.Mutex
+	tracker   *replyTracker
+	messageId string
+	previous  []trackedReply
+}
+
+func (r *replySet) send(s discord.Session, channelId string, data *discordgo.MessageSend) error {
+	r.mu.Lock()
+	var previous *trackedReply
+	// Files can't be replaced in an edit, those are sent as a new message
+	if len(r.previous) > 0 && len(data.Files) == 0 {
+		previous, r.previous = &r.previous[0], r.previous[1:]
+	}
+	r.mu.Unlock()
+
+	if previous != nil {
+		embeds := data.Embeds
+		if embeds == nil {
+			embeds = []*discordgo.MessageEmbed{}
+		}
+		components := data.Components
+		if components == nil {
+			components = []discordgo.MessageComponent{}
+		}
+		edit := discordgo.NewMessageEdit(previous.channelId, previous.messageId)
+		edit.Content, edit.Embeds, edit.Components = &data.Content, &embeds, &components
+		edit.AllowedMentions = data.AllowedMentions
+		_, err := s.ChannelMessageEditComplex(edit)
+		if err == nil {
+			r.tracker.add(r.messageId, *previous)
+			return nil
+		}
+		core.LogErrorF("Failed to edit reply %s, sending a new one: %s", previous.messageId, err)
+	}
+
+	sent, err := s.ChannelMessageSendComplex(channelId, data)
+	if err != nil {
+		return err
+	}
+	r.tracker.add(r.messageId, trackedReply{channelId: sent.ChannelID, messageId: sent.ID})
+	return nil
+}
+
+// finish deletes the earlier replies that weren't reused
+func (r *replySet) finish(s discord.Session) {
+	r.mu.Lock()
+	unused := r.previous
+	r.previous = nil
+	r.mu.Unlock()
+	deleteReplies(s, unused)
+}
+
+func deleteReplies(s discord.Session, replies []trackedReply) {
+	for _, reply := range replies {
+		if err := s.ChannelMessageDelete(reply.channelId, reply.messageId); err != nil {
+			core.LogErrorF("Failed to delete reply %s: %s", reply.messageId, err)
+		}
+	}
+}
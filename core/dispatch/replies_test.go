@@ -0,0 +1,57 @@
+package dispatch
+
+import (
+	"testing"
+	"time"
+
+	"GoBot/core"
+)
+
+func TestReplyTracker_Edited(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newReplyTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.start("1", "#dist sol")
+	tracker.add("1", trackedReply{channelId: "10", messageId: "100"})
+
+	if _, changed := tracker.edited("1", "#dist sol"); changed {
+		t.Error("Expected an update with the same content not to count as an edit")
+	}
+	previous, changed := tracker.edited("1", "#dist sol achenar")
+	if !changed || len(previous) != 1 || previous[0].messageId != "100" {
+		t.Errorf("Expected the earlier reply for an edit, got %v", previous)
+	}
+	if previous, _ = tracker.edited("1", "#dist sol"); len(previous) != 0 {
+		t.Errorf("Expected the replies to be handed out only once, got %v", previous)
+	}
+
+	if previous, changed = tracker.edited("2", "#dist sol"); changed || previous != nil {
+		t.Error("Expected an untracked message not to be dispatched again")
+	}
+
+	tracker.start("3", "#carriers")
+	tracker.add("3", trackedReply{channelId: "10", messageId: "300"})
+	now = now.Add(time.Hour)
+	if previous, changed = tracker.edited("3", "#carrier"); changed || previous != nil {
+		t.Errorf("Expected edits after the edit window to be ignored, got %v", previous)
+	}
+}
+
+func TestReplyTracker_EditWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newReplyTracker()
+	tracker.now = func() time.Time { return now }
+	step := core.Settings.ReplyEditWindow() * 2 / 3
+
+	tracker.start("1", "#dist sol")
+	now = now.Add(step)
+	if _, changed := tracker.edited("1", "#dist sol achenar"); !changed {
+		t.Fatal("Expected an edit within the window to count")
+	}
+	tracker.start("1", "#dist sol achenar")
+	now = now.Add(step)
+	if _, changed := tracker.edited("1", "#dist sol"); changed {
+		t.Error("Expected the window to start when the command was first sent, not when it was last edited")
+	}
+}
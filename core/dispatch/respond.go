@@ -0,0 +1,157 @@
+package dispatch
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"GoBot/core"
+	"GoBot/core/discord"
+	"GoBot/core/i18n"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	messageLimit     = 2000 // Discord message length limit
+	embedsPerMessage = 10   // Discord limit on embeds in a single message
+)
+
+// Reply is a response to a command
+type Reply struct {
+	Content string
+	Embeds  []*discordgo.MessageEmbed
+	Files   []*discordgo.File
+	// Rows of buttons and select menus, see ComponentID
+	Components []discordgo.MessageComponent
+	Private    bool // Send to the author in a DM, or as an ephemeral reply to a slash command
+	Error      bool // The reply reports an error, which marks the command as failed
+	// Mentions in the reply that notify, all of them if nil. Set it for replies with text from users.
+	AllowedMentions *discordgo.MessageAllowedMentions
+}
+
+// Responder sends the replies to a command. Content longer than a Discord message is split on line boundaries
+// and sent as several messages, with the embeds, files and components in the last one.
+type Responder interface {
+	Respond(reply Reply) error
+}
+
+// channelResponder replies to a message in its channel, and to private replies in a DM. If the DM can't be sent,
+// for example because the user doesn't allow DMs from server members, it replies in the channel instead.
+type channelResponder struct {
+	session   discord.Session
+	channelId string
+	authorId  string
+	locale    string    // Language of the note on replies that couldn't be sent by DM
+	replies   *replySet // Tracks the channel replies, nil if they aren't tracked
+}
+
+func (c *channelResponder) Respond(reply Reply) error {
+	if reply.Private {
+		err := c.sendPrivate(reply)
+		if err == nil {
+			return nil
+		}
+		core.LogErrorF("Failed to send DM to %s, replying in the channel instead: %s", c.authorId, err)
+		reply.Content = i18n.T(c.locale, "dispatch.dm_failed", c.authorId) + "\n" + reply.Content
+	}
+	for _, part := range splitReply(reply) {
+		if err := c.send(c.channelId, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *channelResponder) sendPrivate(reply Reply) error {
+	ch, err := c.session.UserChannelCreate(c.authorId)
+	if err != nil {
+		return err
+	}
+	for i, part := range splitReply(reply) {
+		if err := c.send(ch.ID, part); err != nil {
+			if i > 0 {
+				// Part of the reply was already sent, don't repeat it in the channel
+				core.LogErrorF("Failed to send DM to %s: %s", c.authorId, err)
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *channelResponder) send(channelId string, data *discordgo.MessageSend) error {
+	if c.replies != nil && channelId == c.channelId {
+		return c.replies.send(c.session, channelId, data)
+	}
+	_, err := c.session.ChannelMessageSendComplex(channelId, data)
+	return err
+}
+
+// splitReply splits a reply into messages within Discord's limits. Embeds, files and components go with the last message,
+// the allowed mentions apply to all of them.
+func splitReply(reply Reply) []*discordgo.MessageSend {
+	var parts []*discordgo.MessageSend
+	for _, content := range splitContent(reply.Content, messageLimit) {
+		parts = append(parts, &discordgo.MessageSend{Content: content})
+	}
+	if len(parts) == 0 {
+		parts = append(parts, &discordgo.MessageSend{})
+	}
+
+	last := parts[len(parts)-1]
+	for embeds := reply.Embeds; len(embeds) > 0; {
+		if len(last.Embeds) > 0 {
+			last = &discordgo.MessageSend{}
+			parts = append(parts, last)
+		}
+		count := min(embedsPerMessage, len(embeds))
+		last.Embeds, embeds = embeds[:count], embeds[count:]
+	}
+	last.Files = reply.Files
+	last.Components = reply.Components
+	for _, part := range parts {
+		part.AllowedMentions = reply.AllowedMentions
+	}
+	return parts
+}
+
+// splitContent splits text into chunks of at most limit bytes, on line boundaries where possible.
+// Lines longer than the limit are split on the last space that fits.
+func splitContent(content string, limit int) []string {
+	var chunks []string
+	var chunk strings.Builder
+	flush := func() {
+		if text := strings.TrimRight(chunk.String(), "\n"); text != "" {
+			chunks = append(chunks, text)
+		}
+		chunk.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		for len(line) > limit {
+			flush()
+			cut := splitPoint(line, limit)
+			chunks = append(chunks, strings.TrimRight(line[:cut], " "))
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		if chunk.Len()+len(line) > limit {
+			flush()
+		}
+		chunk.WriteString(line)
+	}
+	flush()
+	return chunks
+}
+
+// splitPoint returns where to split a line that's too long: after the last space that fits,
+// or at the limit without splitting a character.
+func splitPoint(line string, limit int) int {
+	if space := strings.LastIndex(line[:limit], " "); space > 0 {
+		return space + 1
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return cut
+}
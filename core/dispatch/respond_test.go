@@ -0,0 +1,85 @@
+package dispatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSplitContent(t *testing.T) {
+	if chunks := splitContent("short reply", messageLimit); len(chunks) != 1 || chunks[0] != "short reply" {
+		t.Errorf("Expected a short reply to stay whole, got %v", chunks)
+	}
+	if chunks := splitContent("", messageLimit); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for empty content, got %v", chunks)
+	}
+
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", 49))
+	}
+	chunks := splitContent(strings.Join(lines, "\n"), messageLimit)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > messageLimit {
+			t.Errorf("Expected chunks within %d characters, got %d", messageLimit, len(chunk))
+		}
+		for _, line := range strings.Split(chunk, "\n") {
+			if len(line) != 49 {
+				t.Errorf("Expected chunks split on line boundaries, got a line of %d characters", len(line))
+			}
+		}
+	}
+}
+
+func TestSplitContent_LongLine(t *testing.T) {
+	chunks := splitContent(strings.Repeat("word ", 10), 12)
+	if chunks[0] != "word word" {
+		t.Errorf("Expected a long line split on spaces, got %q", chunks[0])
+	}
+
+	chunks = splitContent(strings.Repeat("å", 10), 5)
+	for _, chunk := range chunks {
+		if !strings.HasPrefix(chunk, "å") || len(chunk) > 5 {
+			t.Errorf("Expected a line without spaces split between characters, got %q", chunk)
+		}
+	}
+}
+
+func TestSplitReply(t *testing.T) {
+	var embeds []*discordgo.MessageEmbed
+	for i := 0; i < 12; i++ {
+		embeds = append(embeds, &discordgo.MessageEmbed{})
+	}
+	files := []*discordgo.File{{Name: "log.txt"}}
+	parts := splitReply(Reply{Content: strings.Repeat("x\n", 1500), Embeds: embeds, Files: files})
+	if len(parts) != 3 {
+		t.Fatalf("Expected 2 text messages and an extra embed message, got %d", len(parts))
+	}
+	if len(parts[1].Embeds) != embedsPerMessage || len(parts[2].Embeds) != 2 {
+		t.Errorf("Expected embeds on the last messages, got %d and %d", len(parts[1].Embeds), len(parts[2].Embeds))
+	}
+	if parts[2].Files == nil || parts[0].Files != nil {
+		t.Error("Expected the files on the last message")
+	}
+
+	if parts = splitReply(Reply{Embeds: embeds[:1]}); len(parts) != 1 || parts[0].Content != "" {
+		t.Error("Expected a single message for an embed only reply")
+	}
+}
+
+func TestSplitReply_AllowedMentions(t *testing.T) {
+	mentions := &discordgo.MessageAllowedMentions{Users: []string{"1"}}
+	parts := splitReply(Reply{Content: strings.Repeat("line of text\n", 300), AllowedMentions: mentions})
+	if len(parts) != 2 {
+		t.Fatalf("Expected the reply split in 2 messages, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if part.AllowedMentions != mentions {
+			t.Errorf("Expected the allowed mentions on message %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package dispatch
+
+import (
+	"sort"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// addPrefix adds a prefix to the routing order: longest first, so "randomcat" is offered to a "randomc" handler
+// before a "random" one, and alphabetically between prefixes of the same length
+func (d *MessageDispatcher) addPrefix(prefix string) {
+	for _, existing := range d.prefixOrder {
+		if existing == prefix {
+			return
+		}
+	}
+	d.prefixOrder = append(d.prefixOrder, prefix)
+	sort.Slice(d.prefixOrder, func(i, j int) bool {
+		a, b := d.prefixOrder[i], d.prefixOrder[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// matchingPrefixes returns the registered prefixes the command starts with, in routing order
+func (d *MessageDispatcher) matchingPrefixes(command string) []string {
+	var matches []string
+	for _, prefix := range d.prefixOrder {
+		if strings.HasPrefix(command, prefix) {
+			matches = append(matches, prefix)
+		}
+	}
+	return matches
+}
+
+// BuiltinRoute returns the built-in command or the longest prefix a command is routed to, and false if there is none
+// and the command goes to the custom commands
+func (d *MessageDispatcher) BuiltinRoute(command string) (name string, isPrefix bool, found bool) {
+	command = strings.ToLower(command)
+	if len(d.commandHandlers[command]) > 0 {
+		return command, false, true
+	}
+	if prefixes := d.matchingPrefixes(command); len(prefixes) > 0 {
+		return prefixes[0], true, true
+	}
+	return "", false, false
+}
+
+// routesCommand returns true if a command goes to a built-in command or prefix, or names a custom command or category
+func (d *MessageDispatcher) routesCommand(command string) bool {
+	if _, _, found := d.BuiltinRoute(command); found {
+		return true
+	}
+	return database.HasCommandAlias(command) || database.HasCommandGroup(command)
+}
+
+// logConflicts warns about a command or prefix about to be registered that overlaps one of another handler
+func (d *MessageDispatcher) logConflicts(command string, isPrefix bool, handler MessageHandler) {
+	name := toName(handler)
+	others := func(handlers []MessageHandler) []string {
+		var names []string
+		for _, h := range handlers {
+			if other := toName(h); other != name {
+				names = append(names, other)
+			}
+		}
+		return names
+	}
+
+	if isPrefix {
+		if owners := others(d.prefixHandlers[command]); len(owners) > 0 {
+			core.LogWarnF("Prefix %s of %s is already registered by %s.", command, name, strings.Join(owners, ", "))
+		}
+		for _, prefix := range d.prefixOrder {
+			if prefix != command && (strings.HasPrefix(command, prefix) || strings.HasPrefix(prefix, command)) {
+				if owners := others(d.prefixHandlers[prefix]); len(owners) > 0 {
+					core.LogWarnF("Prefix %s of %s overlaps prefix %s of %s, the longer one is tried first.",
+						command, name, prefix, strings.Join(owners, ", "))
+				}
+			}
+		}
+		for other, handlers := range d.commandHandlers {
+			if strings.HasPrefix(other, command) {
+				if owners := others(handlers); len(owners) > 0 {
+					core.LogWarnF("Command %s of %s shadows prefix %s of %s.", other, strings.Join(owners, ", "), command, name)
+				}
+			}
+		}
+		return
+	}
+
+	if owners := others(d.commandHandlers[command]); len(owners) > 0 {
+		core.LogWarnF("Command %s of %s is already registered by %s.", command, name, strings.Join(owners, ", "))
+	}
+	for _, prefix := range d.matchingPrefixes(command) {
+		if owners := others(d.prefixHandlers[prefix]); len(owners) > 0 {
+			core.LogWarnF("Command %s of %s shadows prefix %s of %s.", command, name, prefix, strings.Join(owners, ", "))
+		}
+	}
+}
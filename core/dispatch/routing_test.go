@@ -0,0 +1,87 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"GoBot/core/discord"
+)
+
+// prefixHandler replies with the prefix and suffix it handled, and leaves the suffixes it ignores to others
+type prefixHandler struct {
+	NoOpMessageHandler
+	ignore string
+}
+
+func (h *prefixHandler) HandlePrefix(prefix, suffix string, m *Message) bool {
+	if suffix == h.ignore {
+		return false
+	}
+	m.ReplyToChannel("%s|%s", prefix, suffix)
+	return true
+}
+
+func TestPrefixRouting_LongestFirst(t *testing.T) {
+	d, fake := newEchoDispatcher()
+	for _, prefix := range []string{"r", "random", "ra", "randomc", "rb"} {
+		d.addHandlerForCommand(MessageCommand{Command: prefix}, &d.prefixHandlers, &prefixHandler{ignore: "x"})
+	}
+
+	want := []string{"randomc", "random", "ra", "rb", "r"}
+	if len(d.prefixOrder) != len(want) {
+		t.Fatalf("Expected prefixes %v, got %v", want, d.prefixOrder)
+	}
+	for i := range want {
+		if d.prefixOrder[i] != want[i] {
+			t.Fatalf("Expected prefixes %v, got %v", want, d.prefixOrder)
+		}
+	}
+
+	dm := discord.DMChannelID("1")
+	tests := []struct{ command, want string }{
+		{"randomcat", "randomc|at"},
+		{"randomdog", "random|dog"},
+		{"randomcx", "random|cx"}, // Passed on by the longer prefix
+		{"rat", "ra|t"},
+		{"rz", "r|z"},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 5; i++ {
+			d.Dispatch(context.Background(), fake, testMessage("p"+tt.command, dm, tt.command))
+			sent := fake.SentTo(dm)
+			if len(sent) == 0 || sent[len(sent)-1].Content != tt.want {
+				t.Fatalf("Expected %s to be handled as %s, got %v", tt.command, tt.want, sent)
+			}
+		}
+	}
+}
+
+func TestBuiltinRoute(t *testing.T) {
+	d, _ := newEchoDispatcher()
+	d.addHandlerForCommand(MessageCommand{Command: "random"}, &d.prefixHandlers, &prefixHandler{})
+	d.addHandlerForCommand(MessageCommand{Command: "wp"}, &d.prefixHandlers, &prefixHandler{})
+
+	tests := []struct {
+		command  string
+		name     string
+		isPrefix bool
+		found    bool
+	}{
+		{"echo", "echo", false, true},
+		{"Echo", "echo", false, true},
+		{"randomfact", "random", true, true},
+		{"random", "random", true, true},
+		{"wp7", "wp", true, true},
+		{"rules", "", false, false},
+		{"ran", "", false, false},
+	}
+	for _, tt := range tests {
+		name, isPrefix, found := d.BuiltinRoute(tt.command)
+		if name != tt.name || isPrefix != tt.isPrefix || found != tt.found {
+			t.Errorf("BuiltinRoute(%q) = %q, %v, %v, want %q, %v, %v", tt.command, name, isPrefix, found, tt.name, tt.isPrefix, tt.found)
+		}
+		if d.HasCommand(tt.command) != tt.found {
+			t.Errorf("Expected HasCommand(%q) to be %v", tt.command, tt.found)
+		}
+	}
+}
@@ -0,0 +1,576 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord requires a response within 3 seconds, so slow handlers get a deferred response first.
+const slashDeferAfter = 2 * time.Second
+
+var invalidSlashNameChars = regexp.MustCompile(`[^-_\p{L}\p{N}]`)
+
+// slashName converts an argument name to a valid slash command option name, e.g. "jump range" -> "jump_range"
+func slashName(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	name = invalidSlashNameChars.ReplaceAllString(name, "")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// slashDescription returns a description within Discord's 1-100 character limit
+func slashDescription(text, fallback string) string {
+	if text == "" {
+		text = fallback
+	}
+	text = strings.ReplaceAll(text, "*", "")
+	if len([]rune(text)) > 100 {
+		text = string([]rune(text)[:97]) + "..."
+	}
+	return text
+}
+
+// SlashCommands Returns the slash command registrations for all commands declared with Slash set
+func (d *MessageDispatcher) SlashCommands() []*discordgo.ApplicationCommand {
+	names := make([]string, 0, len(d.commandSpecs))
+	for name, spec := range d.commandSpecs {
+		if spec.Slash {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	commands := make([]*discordgo.ApplicationCommand, 0, len(names))
+	for _, name := range names {
+		commands = append(commands, d.commandSpecs[name].applicationCommand())
+	}
+	return commands
+}
+
+// applicationCommand builds the slash command definition from the declared arguments and flags
+func (c *MessageCommand) applicationCommand() *discordgo.ApplicationCommand {
+	cmd := &discordgo.ApplicationCommand{
+		Name:        c.Command,
+		Description: slashDescription(c.Help, c.Command),
+	}
+	if c.Permission > core.PermissionUser {
+		// Discord hides the command from members who aren't administrators, until the server allows others in
+		// its integration settings. The dispatcher still checks the permission of those who can see it.
+		permissions := int64(discordgo.PermissionAdministrator)
+		cmd.DefaultMemberPermissions = &permissions
+	}
+	for _, arg := range c.Args {
+		option := &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         slashName(arg.Name),
+			Description:  slashDescription(arg.Help, arg.Name),
+			Required:     !arg.Optional,
+			Autocomplete: arg.Autocomplete != nil,
+		}
+		switch arg.Type {
+		case ArgNumber:
+			option.Type = discordgo.ApplicationCommandOptionNumber
+		case ArgInteger:
+			option.Type = discordgo.ApplicationCommandOptionInteger
+		case ArgUser:
+			option.Type = discordgo.ApplicationCommandOptionUser
+		case ArgDiscordRole:
+			option.Type = discordgo.ApplicationCommandOptionRole
+		}
+		for _, choice := range arg.Choices {
+			option.Choices = append(option.Choices, &discordgo.ApplicationCommandOptionChoice{Name: choice, Value: choice})
+		}
+		cmd.Options = append(cmd.Options, option)
+	}
+	for _, flag := range c.Flags {
+		cmd.Options = append(cmd.Options, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        slashName(flag.Name),
+			Description: slashDescription(flag.Help, flag.Name),
+		})
+	}
+	c.localizeApplicationCommand(cmd)
+	return cmd
+}
+
+// paramsFromOptions converts slash command options to Params, validating them like typed arguments
+func (c *MessageCommand) paramsFromOptions(options []*discordgo.ApplicationCommandInteractionDataOption) (Params, error) {
+	var params Params
+	for _, opt := range options {
+		if flag := c.flagBySlashName(opt.Name); flag != nil {
+			if opt.BoolValue() {
+				params.setFlag(flag.Name)
+			}
+			continue
+		}
+		arg := c.argBySlashName(opt.Name)
+		if arg == nil {
+			continue
+		}
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionNumber:
+			params.set(arg.Name, opt.FloatValue())
+		case discordgo.ApplicationCommandOptionInteger:
+			params.set(arg.Name, opt.IntValue())
+		default:
+			// Strings, and the IDs of users and roles
+			text, _ := opt.Value.(string)
+			text = strings.TrimSpace(text)
+			if arg.Type == ArgRest {
+				params.set(arg.Name, text)
+				continue
+			}
+			value, err := arg.convert(text)
+			if err != nil {
+				return params, err
+			}
+			params.set(arg.Name, value)
+		}
+	}
+	for _, arg := range c.Args {
+		if !arg.Optional && !params.Has(arg.Name) {
+			return params, argumentError("dispatch.arg_missing", arg.Name)
+		}
+	}
+	return params, nil
+}
+
+func (c *MessageCommand) argBySlashName(name string) *Arg {
+	for i := range c.Args {
+		if slashName(c.Args[i].Name) == name {
+			return &c.Args[i]
+		}
+	}
+	return nil
+}
+
+func (c *MessageCommand) flagBySlashName(name string) *Flag {
+	for i := range c.Flags {
+		if slashName(c.Flags[i].Name) == name {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// ParseSlashCommand parses "/name option=value ..." into the data Discord would send for the slash command.
+// Values with spaces can be quoted, e.g. /dist from=Sol to="Sagittarius A*"
+func ParseSlashCommand(line string) (*discordgo.ApplicationCommandInteractionData, error) {
+	return Dispatcher.ParseSlashCommand(line)
+}
+
+// ParseSlashCommand parses "/name option=value ..." into the data Discord would send for the slash command
+func (d *MessageDispatcher) ParseSlashCommand(line string) (*discordgo.ApplicationCommandInteractionData, error) {
+	words, err := splitQuoted(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("missing command name")
+	}
+	name := strings.ToLower(words[0])
+	spec := d.commandSpecs[name]
+	if spec == nil || !spec.Slash {
+		return nil, fmt.Errorf("unknown slash command /%s", name)
+	}
+
+	declared := map[string]*discordgo.ApplicationCommandOption{}
+	for _, option := range spec.applicationCommand().Options {
+		declared[option.Name] = option
+	}
+	data := &discordgo.ApplicationCommandInteractionData{Name: name}
+	for _, word := range words[1:] {
+		key, text, found := strings.Cut(word, "=")
+		option := declared[strings.ToLower(key)]
+		if !found || option == nil {
+			return nil, fmt.Errorf("/%s has no option %s", name, key)
+		}
+		var value interface{} = text
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionNumber, discordgo.ApplicationCommandOptionInteger:
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", option.Name)
+			}
+			value = number
+		case discordgo.ApplicationCommandOptionBoolean:
+			flag, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", option.Name)
+			}
+			value = flag
+		}
+		data.Options = append(data.Options, &discordgo.ApplicationCommandInteractionDataOption{
+			Name: option.Name, Type: option.Type, Value: value,
+		})
+	}
+	return data, nil
+}
+
+// splitQuoted splits on spaces outside of double quotes, and removes the quotes
+func splitQuoted(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted, inWord = !quoted, true
+		case r == ' ' && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+			}
+			inWord = false
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// RegisterSlashCommands registers all slash commands with Discord
+func RegisterSlashCommands(s *discordgo.Session) {
+	guildId := core.Settings.SlashCommandGuildId()
+
+	// Only register to a specific guild, not globally
+	if guildId == "" {
+		core.LogInfo("slashCommandGuildId not set, skipping slash command registration")
+		return
+	}
+
+	allCommands := Dispatcher.SlashCommands()
+
+	// Filter commands if allowlist is configured
+	allowlist := core.Settings.SlashCommandAllowlist()
+	if len(allowlist) > 0 {
+		allowlistMap := make(map[string]bool)
+		for _, name := range allowlist {
+			allowlistMap[name] = true
+		}
+		filtered := make([]*discordgo.ApplicationCommand, 0)
+		for _, cmd := range allCommands {
+			if allowlistMap[cmd.Name] {
+				filtered = append(filtered, cmd)
+			}
+		}
+		core.LogInfoF("Slash command allowlist active: %v (registering %d of %d commands)", allowlist, len(filtered), len(allCommands))
+		allCommands = filtered
+	}
+
+	// Use bulk overwrite for efficiency (single API call instead of one per command)
+	registered, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildId, allCommands)
+	if err != nil {
+		core.LogErrorF("Failed to register slash commands: %s", err)
+		return
+	}
+
+	core.LogInfoF("Registered %d slash commands to guild %s", len(registered), guildId)
+}
+
+// HandleInteraction routes slash command, autocomplete and component interactions to the registered handlers
+func HandleInteraction(ctx context.Context, s discord.Session, i *discordgo.InteractionCreate) {
+	Dispatcher.HandleInteraction(ctx, s, i)
+}
+
+// HandleInteraction routes slash command, autocomplete and component interactions to the registered handlers
+func (d *MessageDispatcher) HandleInteraction(ctx context.Context, s discord.Session, i *discordgo.InteractionCreate) {
+	if !d.work.Start() {
+		return
+	}
+	defer d.work.Done()
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		d.dispatchSlash(ctx, s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		d.autocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		d.dispatchComponent(ctx, s, i)
+	}
+}
+
+func (d *MessageDispatcher) dispatchSlash(ctx context.Context, s discord.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	command := strings.ToLower(data.Name)
+	spec := d.commandSpecs[command]
+	if spec == nil || !spec.Slash {
+		core.LogWarnF("Received unknown slash command %s", data.Name)
+		return
+	}
+
+	reply := newInteractionReply(s, i.Interaction, spec.Ephemeral)
+	ctx, cancel := context.WithTimeout(ctx, core.Settings.HandlerTimeout())
+	defer cancel()
+	cmdMessage := &Message{
+		ctx:         ctx,
+		Message:     interactionMessage(i),
+		Session:     s,
+		Command:     command,
+		Prefix:      database.FetchGuildSettings(i.GuildID).CommandPrefix,
+		IsPM:        i.GuildID == "",
+		interaction: reply,
+		spec:        spec,
+	}
+
+	handled := d.runMiddleware(cmdMessage, func() bool {
+		params, err := spec.paramsFromOptions(data.Options)
+		if err != nil {
+			cmdMessage.MarkFailed()
+			reply.send(cmdMessage.T("dispatch.error", cmdMessage.argumentError(err)), true)
+			return true
+		}
+		cmdMessage.Params = params
+
+		core.LogDebugF("Slash command %s from %s", command, cmdMessage.Author.Username)
+		for _, handler := range d.commandHandlers[command] {
+			if handler.HandleCommand(cmdMessage) {
+				core.LogDebug("   => handled.")
+				return true
+			}
+		}
+		return false
+	})
+	if !handled {
+		reply.send(cmdMessage.T("dispatch.not_understood"), true)
+	}
+}
+
+// interactionMessage builds a message with the author, channel and guild of the interaction for the handlers
+func interactionMessage(i *discordgo.InteractionCreate) *discordgo.Message {
+	message := &discordgo.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Member:    i.Member,
+		Author:    i.User,
+	}
+	if i.Member != nil {
+		message.Author = i.Member.User
+	}
+	return message
+}
+
+func (d *MessageDispatcher) autocomplete(s discord.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	spec := d.commandSpecs[strings.ToLower(data.Name)]
+	if spec == nil {
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, opt := range data.Options {
+		if !opt.Focused {
+			continue
+		}
+		if arg := spec.argBySlashName(opt.Name); arg != nil && arg.Autocomplete != nil {
+			choices = arg.Autocomplete(opt.StringValue())
+		}
+		break
+	}
+	if len(choices) > 25 {
+		choices = choices[:25] // Discord limit
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		core.LogErrorF("Failed to respond to autocomplete for %s: %s", data.Name, err)
+	}
+}
+
+// interactionReply keeps track of whether an interaction has been responded to. The first reply
+// is the interaction response, any following replies are sent as followup messages.
+type interactionReply struct {
+	mu          sync.Mutex
+	session     discord.Session
+	interaction *discordgo.Interaction
+	ephemeral   bool // Default for channel replies
+	responded   bool
+	deferTimer  *time.Timer
+	// The interaction is a click on a component, and the response updated the message of the component
+	component    bool
+	editsMessage bool
+	// The response was deferred visible to everyone, and the first followup will fill it in with that visibility
+	publicDefer bool
+}
+
+func newInteractionReply(s discord.Session, i *discordgo.Interaction, ephemeral bool) *interactionReply {
+	reply := &interactionReply{session: s, interaction: i, ephemeral: ephemeral}
+	reply.deferTimer = time.AfterFunc(slashDeferAfter, reply.deferResponse)
+	return reply
+}
+
+func messageFlags(ephemeral bool) discordgo.MessageFlags {
+	if ephemeral {
+		return discordgo.MessageFlagsEphemeral
+	}
+	return 0
+}
+
+// deferResponse acknowledges the interaction so Discord shows "thinking..." until the first followup.
+// For a component, the acknowledgement is a deferred update of its message.
+func (r *interactionReply) deferResponse() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.responded {
+		return
+	}
+	r.responded = true
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: messageFlags(r.ephemeral)},
+	}
+	if r.component {
+		response = &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}
+		r.editsMessage = true
+	} else {
+		r.publicDefer = !r.ephemeral
+	}
+	if err := r.session.InteractionRespond(r.interaction, response); err != nil {
+		core.LogErrorF("Failed to defer interaction response: %s", err)
+	}
+}
+
+// finish acknowledges a component interaction the handler didn't respond to, so Discord doesn't show it as failed
+func (r *interactionReply) finish() {
+	r.deferTimer.Stop()
+	if r.component {
+		r.deferResponse()
+	}
+}
+
+func (r *interactionReply) send(content string, ephemeral bool) {
+	if err := r.Respond(Reply{Content: content, Private: ephemeral}); err != nil {
+		core.LogErrorF("Failed to send interaction reply: %s", err)
+	}
+}
+
+// Respond sends the first message as the interaction response and the rest as followups.
+// Private replies are ephemeral, others use the default of the command.
+func (r *interactionReply) Respond(reply Reply) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deferTimer.Stop()
+
+	ephemeral := reply.Private || r.ephemeral
+	for _, part := range splitReply(reply) {
+		var err error
+		if !r.responded {
+			r.responded = true
+			err = r.session.InteractionRespond(r.interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content:         part.Content,
+					Embeds:          part.Embeds,
+					Files:           part.Files,
+					Components:      part.Components,
+					AllowedMentions: part.AllowedMentions,
+					Flags:           messageFlags(ephemeral),
+				},
+			})
+		} else {
+			err = r.followup(part, ephemeral)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *interactionReply) followup(part *discordgo.MessageSend, ephemeral bool) error {
+	if r.publicDefer {
+		r.publicDefer = false
+		// A private reply would fill in the public "thinking..." message, so that is removed first and the reply
+		// sent as a followup of its own
+		if ephemeral {
+			if err := r.session.InteractionResponseDelete(r.interaction); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := r.session.FollowupMessageCreate(r.interaction, &discordgo.WebhookParams{
+		Content:         part.Content,
+		Embeds:          part.Embeds,
+		Files:           part.Files,
+		Components:      part.Components,
+		AllowedMentions: part.AllowedMentions,
+		Flags:           messageFlags(ephemeral),
+	})
+	return err
+}
+
+// Update replaces the message of a clicked component with the reply. Content that doesn't fit
+// in the message is sent in followups.
+func (r *interactionReply) Update(reply Reply) error {
+	if !r.component {
+		return errors.New("only a component interaction can update its message")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deferTimer.Stop()
+
+	parts := splitReply(reply)
+	first := parts[0]
+	components := first.Components
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	embeds := first.Embeds
+	if embeds == nil {
+		embeds = []*discordgo.MessageEmbed{}
+	}
+
+	var err error
+	switch {
+	case !r.responded:
+		r.responded, r.editsMessage = true, true
+		err = r.session.InteractionRespond(r.interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: first.Content, Embeds: embeds, Components: components},
+		})
+	case r.editsMessage:
+		_, err = r.session.InteractionResponseEdit(r.interaction, &discordgo.WebhookEdit{
+			Content: &first.Content, Embeds: &embeds, Components: &components,
+		})
+	default:
+		// The response was a new message, edit the message of the component directly
+		edit := discordgo.NewMessageEdit(r.interaction.ChannelID, r.interaction.Message.ID)
+		edit.Content, edit.Embeds, edit.Components = &first.Content, &embeds, &components
+		_, err = r.session.ChannelMessageEditComplex(edit)
+	}
+	if err != nil {
+		return err
+	}
+	for _, part := range parts[1:] {
+		if err := r.followup(part, r.ephemeral); err != nil {
+			return err
+		}
+	}
+	return nil
+}
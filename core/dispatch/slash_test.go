@@ -0,0 +1,129 @@
+package dispatch
+
+import (
+	"testing"
+
+	"GoBot/core"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestApplicationCommand_Options(t *testing.T) {
+	cmd := MessageCommand{Command: "kly/hr", Help: "Calculate max kly travelled per hour.", Slash: true,
+		Args: []Arg{
+			{Name: "jump range", Type: ArgNumber},
+			{Name: "count", Type: ArgInteger, Optional: true},
+			{Name: "field", Type: ArgString, Choices: []string{"jump", "all"}},
+		},
+		Flags: []Flag{{Name: "here", Help: "Reply in the channel"}}}
+
+	appCmd := cmd.applicationCommand()
+	if len(appCmd.Options) != 4 {
+		t.Fatalf("Expected 4 options, got %d", len(appCmd.Options))
+	}
+	expected := []struct {
+		name     string
+		kind     discordgo.ApplicationCommandOptionType
+		required bool
+	}{
+		{"jump_range", discordgo.ApplicationCommandOptionNumber, true},
+		{"count", discordgo.ApplicationCommandOptionInteger, false},
+		{"field", discordgo.ApplicationCommandOptionString, true},
+		{"here", discordgo.ApplicationCommandOptionBoolean, false},
+	}
+	for i, e := range expected {
+		opt := appCmd.Options[i]
+		if opt.Name != e.name || opt.Type != e.kind || opt.Required != e.required {
+			t.Errorf("Option %d: expected %s/%v/%v, got %s/%v/%v", i, e.name, e.kind, e.required, opt.Name, opt.Type, opt.Required)
+		}
+	}
+	if len(appCmd.Options[2].Choices) != 2 {
+		t.Errorf("Expected 2 choices for field, got %d", len(appCmd.Options[2].Choices))
+	}
+}
+
+func TestApplicationCommand_DefaultMemberPermissions(t *testing.T) {
+	if perms := (&MessageCommand{Command: "carriers", Slash: true}).applicationCommand().DefaultMemberPermissions; perms != nil {
+		t.Errorf("Expected a command for everyone to have no default permissions, got %d", *perms)
+	}
+	cmd := MessageCommand{Command: "carrierjump", Slash: true, Permission: core.PermissionCarrierManager}
+	if perms := cmd.applicationCommand().DefaultMemberPermissions; perms == nil || *perms != discordgo.PermissionAdministrator {
+		t.Errorf("Expected a carrier manager command to be limited to administrators by default, got %v", perms)
+	}
+}
+
+func TestInteractionReply_PrivateAfterPublicDefer(t *testing.T) {
+	fake := discord.NewFake(testBotId)
+	reply := newInteractionReply(fake, &discordgo.Interaction{ID: "i1", ChannelID: "10"}, false)
+	reply.deferTimer.Stop()
+	reply.deferResponse()
+
+	if err := reply.Respond(Reply{Content: "Only for you", Private: true}); err != nil {
+		t.Fatalf("Failed to respond: %s", err)
+	}
+	reply.Respond(Reply{Content: "For everyone"})
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[0].Content != "Only for you" || sent[0].Flags != discordgo.MessageFlagsEphemeral {
+		t.Fatalf("Expected the private reply to stay ephemeral, got %v", sent)
+	}
+	if sent[1].Flags != 0 {
+		t.Errorf("Expected the public reply to be visible to everyone, got flags %d", sent[1].Flags)
+	}
+}
+
+func TestParamsFromOptions(t *testing.T) {
+	cmd := MessageCommand{Command: "carrierclear", Args: []Arg{
+		{Name: "carrier", Type: ArgStationId},
+		{Name: "jump range", Type: ArgNumber},
+	}, Flags: []Flag{{Name: "here"}}}
+
+	params, err := cmd.paramsFromOptions([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "carrier", Type: discordgo.ApplicationCommandOptionString, Value: "w7h-6dz"},
+		{Name: "jump_range", Type: discordgo.ApplicationCommandOptionNumber, Value: 68.5},
+		{Name: "here", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.String("carrier") != "W7H-6DZ" {
+		t.Errorf("Expected carrier='W7H-6DZ', got %q", params.String("carrier"))
+	}
+	if params.Number("jump range") != 68.5 {
+		t.Errorf("Expected jump range=68.5, got %f", params.Number("jump range"))
+	}
+	if !params.Flag("here") {
+		t.Error("Expected here flag to be set")
+	}
+
+	_, err = cmd.paramsFromOptions([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "carrier", Type: discordgo.ApplicationCommandOptionString, Value: "nope"},
+	})
+	if err == nil {
+		t.Error("Expected error for invalid station ID, got nil")
+	}
+}
+
+func TestParseSlashCommand(t *testing.T) {
+	d := newTestDispatcher()
+	d.addHandlerForCommand(MessageCommand{Command: "dist", Slash: true, Args: []Arg{
+		{Name: "from", Type: ArgSystemName},
+		{Name: "jump range", Type: ArgNumber, Optional: true},
+	}, Flags: []Flag{{Name: "here"}}}, &d.commandHandlers, &testHandler{})
+
+	data, err := d.ParseSlashCommand(`/dist from="Sagittarius A*" jump_range=68.5 here=true`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.Name != "dist" || len(data.Options) != 3 {
+		t.Fatalf("Expected /dist with 3 options, got /%s with %d", data.Name, len(data.Options))
+	}
+	if data.Options[0].StringValue() != "Sagittarius A*" || data.Options[1].FloatValue() != 68.5 || !data.Options[2].BoolValue() {
+		t.Errorf("Expected typed option values, got %v, %v and %v", data.Options[0].Value, data.Options[1].Value, data.Options[2].Value)
+	}
+
+	for _, line := range []string{"/unknown", "/dist to=Sol", "/dist jump_range=far", `/dist from="Sol`} {
+		if _, err := d.ParseSlashCommand(line); err == nil {
+			t.Errorf("Expected an error for %s", line)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package dispatch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/i18n"
+)
+
+const maxSuggestions = 3
+
+// Suggest returns up to three known commands closest to the unknown command, by edit distance and prefix.
+// Built-in commands the user doesn't have permission for are left out.
+func (d *MessageDispatcher) Suggest(command string, level core.PermissionLevel) []string {
+	candidates := map[string]bool{}
+	for name, spec := range d.commandSpecs {
+		candidates[name] = spec.Permission <= level
+	}
+	for name := range d.prefixHandlers {
+		if _, exists := candidates[name]; !exists {
+			candidates[name] = true
+		}
+	}
+	for _, name := range database.FetchCommandNames() {
+		if _, exists := candidates[name]; !exists {
+			candidates[name] = true
+		}
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for name, allowed := range candidates {
+		if !allowed || name == command {
+			continue
+		}
+		if distance, ok := similarity(command, name); ok {
+			matches = append(matches, match{name, distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// similarity returns the edit distance between the typed command and a candidate, and whether it's close
+// enough to suggest. Candidates starting with what was typed count as close, e.g. "carr" for "carriers".
+func similarity(typed, candidate string) (int, bool) {
+	distance := editDistance(typed, candidate)
+	maxDistance := len([]rune(typed)) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	} else if maxDistance > 3 {
+		maxDistance = 3
+	}
+	if distance <= maxDistance {
+		return distance, true
+	}
+	if len(typed) >= 3 && strings.HasPrefix(candidate, typed) {
+		return distance, true
+	}
+	return distance, false
+}
+
+// editDistance returns the number of single character edits needed to turn a into b. Swapping two
+// adjacent characters counts as one edit, since that's the most common typo.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// shouldSuggest checks the suggestion mode for the channel the message was sent in
+func shouldSuggest(m *Message) bool {
+	if m.IsPM {
+		return true
+	}
+	settings := m.GuildSettings()
+	switch settings.SuggestionModeFor(m.ChannelID) {
+	case core.SuggestAlways:
+		return true
+	case core.SuggestSilent:
+		return false
+	default:
+		return settings.IsBotChannel(m.ChannelID)
+	}
+}
+
+// formatSuggestions returns " Did you mean ...?" for the suggestions, or an empty string if there are none
+func formatSuggestions(locale, prefix string, suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		quoted[i] = fmt.Sprintf("`%s%s`", prefix, suggestion)
+	}
+	return i18n.T(locale, "dispatch.did_you_mean", strings.Join(quoted, ", "))
+}
+
+// replyUnknownCommand answers a command that no handler took. Directly addressed messages always get a reply,
+// prefixed ones only when there is something to suggest and the channel allows suggestions.
+func (d *MessageDispatcher) replyUnknownCommand(m *Message, isDirectAddressed bool) {
+	var suggestions string
+	if shouldSuggest(m) {
+		suggestions = formatSuggestions(m.Locale(), m.Prefix, d.Suggest(m.Command, m.Permission()))
+	}
+	if isDirectAddressed {
+		m.ReplyToSender(m.T("dispatch.not_understood_help"), suggestions, m.Prefix, HelpCommand)
+	} else if suggestions != "" {
+		m.ReplyToChannel(m.T("dispatch.unknown_command"), m.Prefix, m.Command, suggestions)
+	}
+}
@@ -0,0 +1,61 @@
+package dispatch
+
+import (
+	"strings"
+	"testing"
+
+	"GoBot/core"
+	"GoBot/core/i18n"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"help", "help", 0},
+		{"hepl", "help", 1},
+		{"carrier", "carriers", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, test := range tests {
+		if distance := editDistance(test.a, test.b); distance != test.expected {
+			t.Errorf("Expected distance %d between %q and %q, got %d", test.expected, test.a, test.b, distance)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	d := newTestDispatcher()
+	carriers := &testHandler{group: "Fleet Carriers"}
+	d.addHandlerForCommand(MessageCommand{Command: "carriers"}, &d.commandHandlers, carriers)
+	d.addHandlerForCommand(MessageCommand{Command: "carrierinfo"}, &d.commandHandlers, carriers)
+	d.addHandlerForCommand(MessageCommand{Command: "carrierjump", Permission: core.PermissionCarrierManager}, &d.commandHandlers, carriers)
+	d.addHandlerForCommand(MessageCommand{Command: "help"}, &d.commandHandlers, carriers)
+
+	suggestions := d.Suggest("carrier", core.PermissionUser)
+	if strings.Join(suggestions, ",") != "carriers,carrierinfo" {
+		t.Errorf("Expected carriers and carrierinfo, got %v", suggestions)
+	}
+	suggestions = d.Suggest("carrier", core.PermissionCarrierManager)
+	if strings.Join(suggestions, ",") != "carriers,carrierinfo,carrierjump" {
+		t.Errorf("Expected carrierjump for a carrier manager, got %v", suggestions)
+	}
+	if suggestions = d.Suggest("hlep", core.PermissionUser); len(suggestions) != 1 || suggestions[0] != "help" {
+		t.Errorf("Expected help, got %v", suggestions)
+	}
+	if suggestions = d.Suggest("xyzzy", core.PermissionUser); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions, got %v", suggestions)
+	}
+}
+
+func TestFormatSuggestions(t *testing.T) {
+	if text := formatSuggestions(i18n.DefaultLocale, "#", nil); text != "" {
+		t.Errorf("Expected empty text without suggestions, got %q", text)
+	}
+	if text := formatSuggestions(i18n.DefaultLocale, "#", []string{"bar", "baz"}); text != " Did you mean `#bar`, `#baz`?" {
+		t.Errorf("Expected suggestion text, got %q", text)
+	}
+}
@@ -0,0 +1,60 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// commandOutcome is shared by the copies of a Message, so a failure reported by a reply or handler
+// is seen by the usage middleware
+type commandOutcome struct {
+	failed atomic.Bool
+}
+
+// recordUsage queues a command usage record, replaced in tests
+var recordUsage = database.QueueCommandUsage
+
+// MarkFailed records that the command failed. Error replies and replies that can't be sent are marked automatically.
+func (m Message) MarkFailed() {
+	if m.outcome != nil {
+		m.outcome.failed.Store(true)
+	}
+}
+
+// Failed returns true if the command has been marked as failed
+func (m Message) Failed() bool {
+	return m.outcome != nil && m.outcome.failed.Load()
+}
+
+// usageMiddleware records every handled command, including custom commands, for the usage statistics.
+// Unknown commands aren't recorded. Commands that ran past the handler timeout count as failed.
+func usageMiddleware(m *Message, next Next) bool {
+	if m.outcome == nil {
+		m.outcome = &commandOutcome{}
+	}
+	start := time.Now()
+	handled := next()
+	if !handled {
+		return false
+	}
+	if errors.Is(m.Context().Err(), context.DeadlineExceeded) {
+		core.LogWarnF("Command %s from %s ran past the handler timeout", m.Command, authorName(m))
+		m.MarkFailed()
+	}
+	recordUsage(database.CommandUsage{
+		Command:   m.Command,
+		UserId:    authorId(m),
+		ChannelId: m.ChannelID,
+		GuildId:   m.GuildID,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Success:   !m.Failed(),
+		Slash:     m.IsSlashCommand(),
+		CreatedAt: start.Unix(),
+	})
+	return true
+}
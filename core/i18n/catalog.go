@@ -0,0 +1,196 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"GoBot/core"
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultLocale is the locale of the built-in English messages, used when a message has no translation
+const DefaultLocale = "en-US"
+
+// Catalogs are flat JSON objects of message key to text, named after the Discord locale they're for, e.g. de.json.
+// Texts are fmt formats, translations can reorder the arguments with %[2]s.
+//
+//go:embed locales/*.json
+var builtin embed.FS
+
+var (
+	catalogs   = map[string]map[string]string{}
+	catalogsMu sync.RWMutex
+)
+
+func init() {
+	if err := loadCatalogs(builtin, "locales"); err != nil {
+		core.LogErrorF("Failed to load the built-in message catalogs: %s", err)
+	}
+}
+
+// LoadDirectory adds the catalogs in a directory, overriding built-in messages with the same key.
+// A missing directory isn't an error.
+func LoadDirectory(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return loadCatalogs(os.DirFS(dir), ".")
+}
+
+func loadCatalogs(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		if _, ok := discordgo.Locales[discordgo.Locale(locale)]; !ok {
+			core.LogWarnF("Message catalog %s isn't named after a Discord locale, skipping it.", file)
+			continue
+		}
+		AddCatalog(locale, messages)
+		core.LogDebugF("Loaded %d messages for %s from %s", len(messages), locale, file)
+	}
+	return nil
+}
+
+// AddCatalog adds messages for a locale, replacing those with the same key
+func AddCatalog(locale string, messages map[string]string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if catalogs[locale] == nil {
+		catalogs[locale] = map[string]string{}
+	}
+	for key, text := range messages {
+		catalogs[locale][key] = text
+	}
+}
+
+// Locales returns the locales that have a catalog, sorted
+func Locales() []string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Resolve returns the catalog used for a locale: its own, or one for the same language, so "en-GB" uses "en-US".
+// Returns false if there is none.
+func Resolve(locale string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	for candidate := range catalogs {
+		if strings.EqualFold(candidate, locale) {
+			return candidate, true
+		}
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	var match string
+	for candidate := range catalogs {
+		if candidateLanguage, _, _ := strings.Cut(candidate, "-"); strings.EqualFold(candidateLanguage, language) {
+			if match == "" || candidate < match {
+				match = candidate
+			}
+		}
+	}
+	return match, match != ""
+}
+
+// Parse finds the catalog for a locale code, or a language name such as "German" or "Deutsch"
+func Parse(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", false
+	}
+	if locale, ok := Resolve(text); ok {
+		return locale, true
+	}
+	for locale, name := range discordgo.Locales {
+		if strings.EqualFold(name, text) || strings.EqualFold(Lookup(string(locale), "language.name"), text) {
+			return Resolve(string(locale))
+		}
+	}
+	return "", false
+}
+
+// Name returns the name of a locale in its own language, e.g. "Deutsch"
+func Name(locale string) string {
+	if name := Lookup(locale, "language.name"); name != "" {
+		return name
+	}
+	if name, ok := discordgo.Locales[discordgo.Locale(locale)]; ok {
+		return name
+	}
+	return locale
+}
+
+// Lookup returns the text of a message in the catalog of the locale itself, or an empty string if it has none
+func Lookup(locale, key string) string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	return catalogs[locale][key]
+}
+
+// Translate returns the text of a message for a locale, falling back to the default locale.
+// Returns false if neither has the message.
+func Translate(locale, key string) (string, bool) {
+	if resolved, ok := Resolve(locale); ok {
+		if text := Lookup(resolved, key); text != "" {
+			return text, true
+		}
+	}
+	text := Lookup(DefaultLocale, key)
+	return text, text != ""
+}
+
+// T returns a message for a locale, formatted with the arguments. Without arguments the text is returned
+// unformatted, so it can be used as the format of a reply. Unknown keys are returned as they are.
+func T(locale, key string, args ...interface{}) string {
+	text, ok := Translate(locale, key)
+	if !ok {
+		core.LogWarnF("No message %s in the catalogs", key)
+		text = key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Localizations returns the translations of a message by Discord locale, for slash command registration.
+// Every Discord locale that resolves to a catalog other than the default with the message gets a translation.
+func Localizations(key string, format func(string) string) map[discordgo.Locale]string {
+	localizations := map[discordgo.Locale]string{}
+	for locale := range discordgo.Locales {
+		resolved, ok := Resolve(string(locale))
+		if locale == discordgo.Unknown || !ok || resolved == DefaultLocale {
+			continue
+		}
+		if text := Lookup(resolved, key); text != "" {
+			localizations[locale] = format(text)
+		}
+	}
+	if len(localizations) == 0 {
+		return nil
+	}
+	return localizations
+}
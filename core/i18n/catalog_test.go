@@ -0,0 +1,108 @@
+package i18n
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+		found  bool
+	}{
+		{"en-US", "en-US", true},
+		{"en-GB", "en-US", true},
+		{"DE", "de", true},
+		{"fr", "fr", true},
+		{"ja", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		locale, found := Resolve(tt.locale)
+		if locale != tt.want || found != tt.found {
+			t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.locale, locale, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for text, want := range map[string]string{"de": "de", "German": "de", "deutsch": "de", "Français": "fr", "en-GB": "en-US"} {
+		if locale, ok := Parse(text); !ok || locale != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", text, locale, ok, want)
+		}
+	}
+	for _, text := range []string{"", "Klingon", "ja"} {
+		if locale, ok := Parse(text); ok {
+			t.Errorf("Expected %q not to be a language, got %q", text, locale)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if text := T("de", "help.page", 2, 3); text != "Seite 2/3" {
+		t.Errorf("Expected the German text, got %q", text)
+	}
+	if text := T("ja", "help.page", 2, 3); text != "Page 2/3" {
+		t.Errorf("Expected the English text for a locale without a catalog, got %q", text)
+	}
+	AddCatalog("de", map[string]string{"test.only_english": ""})
+	AddCatalog(DefaultLocale, map[string]string{"test.only_english": "100%"})
+	if text := T("de", "test.only_english"); text != "100%" {
+		t.Errorf("Expected the unformatted English text for a missing translation, got %q", text)
+	}
+	if text := T("de", "test.missing"); text != "test.missing" {
+		t.Errorf("Expected the key of an unknown message, got %q", text)
+	}
+}
+
+// Matches a verb of a format, with its optional argument index
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*[0-9.]*([a-zA-Z%])`)
+
+// formatArgs returns the verb used for each argument of a format
+func formatArgs(format string) map[int]string {
+	args := map[int]string{}
+	next := 1
+	for _, match := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if match[2] == "%" {
+			continue
+		}
+		if match[1] != "" {
+			next, _ = strconv.Atoi(match[1])
+		}
+		args[next] = match[2]
+		next++
+	}
+	return args
+}
+
+func TestCatalogs(t *testing.T) {
+	english := catalogs[DefaultLocale]
+	for _, locale := range Locales() {
+		if locale == DefaultLocale {
+			continue
+		}
+		for key, text := range catalogs[locale] {
+			if strings.HasPrefix(key, "command.") || strings.HasPrefix(key, "group.") || strings.HasPrefix(key, "test.") {
+				continue
+			}
+			source, ok := english[key]
+			if !ok {
+				t.Errorf("%s has %s, which isn't in %s", locale, key, DefaultLocale)
+				continue
+			}
+			want, got := formatArgs(source), formatArgs(text)
+			if len(want) != len(got) {
+				t.Errorf("%s %s takes %d arguments, the English text %d", locale, key, len(got), len(want))
+				continue
+			}
+			for i, verb := range want {
+				if got[i] != verb {
+					t.Errorf("%s %s formats argument %d with %%%s, the English text with %%%s", locale, key, i, got[i], verb)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// WorkGroup tracks in-flight work, so shutdown can stop new work from starting and wait for the rest.
+// The zero value is ready to use.
+type WorkGroup struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Start registers a unit of work. Returns false if the group is closed, in which case the work must not run.
+// Every successful Start must be followed by Done.
+func (w *WorkGroup) Start() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	w.wg.Add(1)
+	return true
+}
+
+// Done marks a unit of work as finished
+func (w *WorkGroup) Done() {
+	w.wg.Done()
+}
+
+// Go runs fn in a goroutine as a unit of work. Returns false if the group is closed and fn wasn't started.
+func (w *WorkGroup) Go(fn func()) bool {
+	if !w.Start() {
+		return false
+	}
+	go func() {
+		defer w.Done()
+		fn()
+	}()
+	return true
+}
+
+// Close stops new work from starting and waits for the work in flight to finish, or for ctx to be done.
+func (w *WorkGroup) Close(ctx context.Context) error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -66,6 +66,7 @@ func LogErrorF(format string, v ...interface{}) {
 	}
 }
 
+// LogFatalF logs and exits without running deferred functions, so it must not be used once the database is open
 func LogFatalF(format string, v ...interface{}) {
 	doLogF(log.Fatal, format, v...)
 	os.Exit(2)
@@ -101,6 +102,7 @@ func LogError(v ...interface{}) {
 	}
 }
 
+// LogFatal logs and exits without running deferred functions, so it must not be used once the database is open
 func LogFatal(v ...interface{}) {
 	doLog(log.Fatal, v...)
 	os.Exit(2)
@@ -0,0 +1,42 @@
+package core
+
+import "strings"
+
+// PermissionLevel is a bot role. Each level includes the permissions of the levels below it.
+type PermissionLevel int
+
+const (
+	PermissionUser           PermissionLevel = iota // Everyone
+	PermissionCarrierManager                        // Can manage fleet carriers and view followers
+	PermissionAdmin                                 // Can manage custom commands and bot configuration
+	PermissionOwner                                 // Bot owners from the config file, can grant roles
+)
+
+var permissionNames = map[PermissionLevel]string{
+	PermissionUser:           "user",
+	PermissionCarrierManager: "carriermanager",
+	PermissionAdmin:          "admin",
+	PermissionOwner:          "owner",
+}
+
+// PermissionLevelNames returns the names of all permission levels, lowest first
+func PermissionLevelNames() []string {
+	return []string{"user", "carriermanager", "admin", "owner"}
+}
+
+func (p PermissionLevel) String() string {
+	if name, ok := permissionNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParsePermissionLevel returns the permission level with the given name
+func ParsePermissionLevel(name string) (PermissionLevel, bool) {
+	for level, levelName := range permissionNames {
+		if strings.EqualFold(levelName, name) {
+			return level, true
+		}
+	}
+	return PermissionUser, false
+}
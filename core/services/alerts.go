@@ -1,16 +1,19 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"GoBot/core"
 	"GoBot/core/database"
+	"github.com/bwmarrin/discordgo"
 )
 
 // CheckProximityAlerts checks all active proximity alerts against a carrier's new location.
 // If a carrier has jumped within range of an alert's target system, a DM is sent and the alert is deleted.
-func CheckProximityAlerts(stationId, system string) {
-	if discordSession == nil {
+func CheckProximityAlerts(ctx context.Context, stationId, system string) {
+	s := session()
+	if s == nil {
 		return
 	}
 
@@ -20,7 +23,7 @@ func CheckProximityAlerts(stationId, system string) {
 	}
 
 	// Get coords for the carrier's new system
-	carrierCoords, err := GetSystemCoords(system)
+	carrierCoords, err := GetSystemCoords(ctx, system)
 	if err != nil || carrierCoords == nil {
 		return
 	}
@@ -33,7 +36,7 @@ func CheckProximityAlerts(stationId, system string) {
 			continue
 		}
 
-		alertCoords, err := GetSystemCoords(alert.SystemName)
+		alertCoords, err := GetSystemCoords(ctx, alert.SystemName)
 		if err != nil || alertCoords == nil {
 			continue
 		}
@@ -47,13 +50,13 @@ func CheckProximityAlerts(stationId, system string) {
 		msg := fmt.Sprintf("**Carrier Alert:** %s has jumped to **%s**, which is **%.1f ly** from your alert system **%s**.",
 			carrierName, system, distance, alert.SystemName)
 
-		ch, err := discordSession.UserChannelCreate(alert.UserID)
+		ch, err := s.UserChannelCreate(alert.UserID)
 		if err != nil {
 			core.LogErrorF("Failed to open DM channel for proximity alert user %s: %s", alert.UserID, err)
 			continue
 		}
 
-		_, err = discordSession.ChannelMessageSend(ch.ID, msg)
+		_, err = s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{Content: msg})
 		if err != nil {
 			core.LogErrorF("Failed to send proximity alert DM to user %s: %s", alert.UserID, err)
 			continue
@@ -1,12 +1,15 @@
 package services
 
 import (
+	"context"
 	"regexp"
 	"strings"
 	"unicode"
 
 	"GoBot/core"
-
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"GoBot/core/dispatch"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -59,17 +62,23 @@ func findCarrierByName(name string) *core.CarrierConfig {
 	return nil
 }
 
-// ProcessCarrierUpdateMessage processes a message from the carrier update channel
-func ProcessCarrierUpdateMessage(authorId, channelId, content string) {
+// ProcessCarrierUpdateMessage processes a message from the carrier update channel of a guild
+func ProcessCarrierUpdateMessage(ctx context.Context, s discord.Session, message *discordgo.Message) {
+	if !work.Start() {
+		return
+	}
+	defer work.Done()
+	guildId, authorId, content := message.GuildID, message.Author.ID, message.Content
+
 	// Check if this is the carrier update channel
-	configuredChannel := core.Settings.CarrierUpdateChannelId()
-	if channelId != configuredChannel {
+	configuredChannel := database.FetchGuildSettings(guildId).CarrierUpdateChannelId
+	if configuredChannel == "" || message.ChannelID != configuredChannel {
 		return
 	}
 
-	// Check if author is a carrier owner
-	if !core.Settings.IsCarrierOwner(authorId) {
-		core.LogDebugF("Carrier update from non-owner %s, ignoring", authorId)
+	// Check if the author may manage carriers, as a carrier owner, by a granted role or by a Discord role
+	if dispatch.AuthorPermission(s, message) < core.PermissionCarrierManager {
+		core.LogDebugF("Carrier update from %s without carrier manager permission, ignoring", authorId)
 		return
 	}
 
@@ -86,19 +95,36 @@ func ProcessCarrierUpdateMessage(authorId, channelId, content string) {
 
 	// Process each carrier update
 	for _, update := range updates {
-		processCarrierUpdate(&update)
+		processCarrierUpdate(ctx, guildId, &update)
 	}
 }
 
-// ProcessCarrierUpdateChannelOnStartup fetches and processes recent messages from the carrier update channel
-func ProcessCarrierUpdateChannelOnStartup(s *discordgo.Session) {
-	channelId := core.Settings.CarrierUpdateChannelId()
-	if channelId == "" {
+// ProcessCarrierUpdateChannelOnStartup fetches and processes recent messages from the carrier update
+// channel in the config file, and the ones configured per guild
+func ProcessCarrierUpdateChannelOnStartup(ctx context.Context, s discord.Session) {
+	processed := map[string]bool{}
+	channelIds := []string{core.Settings.CarrierUpdateChannelId()}
+	for _, settings := range database.FetchGuildSettingsWithOverride(database.GuildCarrierUpdateChannel) {
+		channelIds = append(channelIds, settings.CarrierUpdateChannelId)
+	}
+	for _, channelId := range channelIds {
+		if channelId == "" || processed[channelId] {
+			continue
+		}
+		processed[channelId] = true
+		processCarrierUpdateChannel(ctx, s, channelId)
+	}
+}
+
+func processCarrierUpdateChannel(ctx context.Context, s discord.Session, channelId string) {
+	channel, err := s.Channel(channelId)
+	if err != nil {
+		core.LogErrorF("Failed to fetch carrier update channel %s: %s", channelId, err)
 		return
 	}
 
 	// Fetch recent messages from the channel (newest first from Discord API)
-	messages, err := s.ChannelMessages(channelId, 20, "", "", "")
+	messages, err := s.ChannelMessages(channelId, 20)
 	if err != nil {
 		core.LogErrorF("Failed to fetch carrier update channel messages: %s", err)
 		return
@@ -111,7 +137,9 @@ func ProcessCarrierUpdateChannelOnStartup(s *discordgo.Session) {
 		if msg.Author == nil {
 			continue
 		}
-		ProcessCarrierUpdateMessage(msg.Author.ID, channelId, msg.Content)
+		// Fetched messages don't have the guild set
+		msg.GuildID = channel.GuildID
+		ProcessCarrierUpdateMessage(ctx, s, msg)
 	}
 }
 
@@ -373,7 +401,7 @@ func isAlphaWord(s string) bool {
 }
 
 // processCarrierUpdate applies a carrier update if values have changed
-func processCarrierUpdate(update *CarrierUpdate) {
+func processCarrierUpdate(ctx context.Context, guildId string, update *CarrierUpdate) {
 	info, err := GetCarrierInfo(update.StationId)
 	if err != nil {
 		core.LogErrorF("Failed to get carrier info for %s: %s", update.StationId, err)
@@ -448,6 +476,6 @@ func processCarrierUpdate(update *CarrierUpdate) {
 
 	// Post flight log if any changes were made
 	if len(changes) > 0 {
-		PostCarrierFlightLog(update.StationId, changes)
+		PostCarrierFlightLog(ctx, guildId, update.StationId, changes)
 	}
 }
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"context"
 	"testing"
 
 	"GoBot/core"
+	"GoBot/core/database"
+	"GoBot/core/discord"
+	"github.com/bwmarrin/discordgo"
 )
 
 func setupTestCarriers() {
@@ -15,6 +19,36 @@ func setupTestCarriers() {
 	})
 }
 
+func TestProcessCarrierUpdateMessage_Permission(t *testing.T) {
+	setupTestDB(t)
+	setupTestCarriers()
+	SetDiscordSession(nil)
+	database.SetGuildSetting("g1", database.GuildCarrierUpdateChannel, "50")
+	database.SetGuildRole("g1", "777", core.PermissionCarrierManager)
+	fake := discord.NewFake("900")
+	fake.AddMember("g1", &discordgo.Member{User: &discordgo.User{ID: "2"}, Roles: []string{"777"}})
+	update := func(authorId, destination string) *discordgo.Message {
+		return &discordgo.Message{GuildID: "g1", ChannelID: "50", Author: &discordgo.User{ID: authorId},
+			Content: "Carrier: TBQ-6VX\nDestination: " + destination}
+	}
+	destination := func() string {
+		info, err := GetCarrierInfo("TBQ-6VX")
+		if err != nil || info.Destination == nil {
+			return ""
+		}
+		return *info.Destination
+	}
+
+	ProcessCarrierUpdateMessage(context.Background(), fake, update("1", "Colonia"))
+	if got := destination(); got != "" {
+		t.Fatalf("Expected an update from a user without permission to be ignored, got destination %q", got)
+	}
+	ProcessCarrierUpdateMessage(context.Background(), fake, update("2", "Colonia"))
+	if got := destination(); got != "Colonia" {
+		t.Errorf("Expected an update from a member with a carrier manager role to apply, got destination %q", got)
+	}
+}
+
 // --- isPlaceholder tests ---
 
 func TestIsPlaceholder_ExactMatches(t *testing.T) {
@@ -1,28 +1,45 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"GoBot/core"
 	"GoBot/core/database"
+	"GoBot/core/discord"
+	"GoBot/core/i18n"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-var discordSession *discordgo.Session
+// discordSession holds a sessionHolder with the session services post with. It's read by the EDDN listener,
+// the scheduler and handlers while it's set, so it's only accessed atomically.
+var discordSession atomic.Value
+
+type sessionHolder struct {
+	discord.Session
+}
 
 // Matches an optional leading weekday token with trailing comma/whitespace
 // (e.g. "Thursday, ", "Thu ", "Monday ") so ParseJumpTime can skip it.
 // Long names precede short ones so RE2's leftmost-first alternation picks them.
 var weekdayPrefixPattern = regexp.MustCompile(`(?i)^(?:monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|wed|thu|fri|sat|sun)\.?,?\s+`)
 
-// SetDiscordSession sets the Discord session for posting flight logs
-func SetDiscordSession(s *discordgo.Session) {
-	discordSession = s
+// SetDiscordSession sets the Discord session for posting flight logs, alerts and reminders. Set it before starting
+// the services that post.
+func SetDiscordSession(s discord.Session) {
+	discordSession.Store(sessionHolder{s})
+}
+
+// session returns the Discord session services post with, or nil if it isn't set yet
+func session() discord.Session {
+	holder, _ := discordSession.Load().(sessionHolder)
+	return holder.Session
 }
 
 // CarrierInfo contains full carrier information for display
@@ -98,6 +115,7 @@ func SetCarrierJumpTime(stationId string, timestamp int64) error {
 	if !database.UpdateCarrierJumpTime(stationId, &timestamp) {
 		return fmt.Errorf("failed to update jump time")
 	}
+	rescheduleCarrierReminders(stationId)
 	return nil
 }
 
@@ -149,6 +167,7 @@ func ClearCarrierField(stationId string, field string) error {
 	switch strings.ToLower(field) {
 	case "jump":
 		database.UpdateCarrierJumpTime(stationId, nil)
+		rescheduleCarrierReminders(stationId)
 	case "dest":
 		database.UpdateCarrierDestination(stationId, nil)
 	case "status":
@@ -157,6 +176,7 @@ func ClearCarrierField(stationId string, field string) error {
 		database.UpdateCarrierJumpTime(stationId, nil)
 		database.UpdateCarrierDestination(stationId, nil)
 		database.UpdateCarrierStatus(stationId, nil)
+		rescheduleCarrierReminders(stationId)
 	default:
 		return fmt.Errorf("invalid field: %s (use jump, dest, status, or all)", field)
 	}
@@ -164,18 +184,18 @@ func ClearCarrierField(stationId string, field string) error {
 }
 
 // FormatCarrierList formats all carriers for display
-func FormatCarrierList() string {
+func FormatCarrierList(ctx context.Context, locale string) string {
 	var sb strings.Builder
 
-	sb.WriteString("**OFFICIAL FLEET CARRIERS**\n")
-	sb.WriteString("Times shown in your local time\n\n")
+	sb.WriteString(i18n.T(locale, "carriers.list_title") + "\n")
+	sb.WriteString(i18n.T(locale, "carriers.list_times") + "\n\n")
 
 	carriers := GetAllCarriersInfo()
 	for i, c := range carriers {
 		if i > 0 {
 			sb.WriteString("\n")
 		}
-		sb.WriteString(formatSingleCarrier(c))
+		sb.WriteString(formatSingleCarrier(ctx, locale, c))
 	}
 
 	sb.WriteString("\n<https://distantworlds3.space/carriers/>")
@@ -183,13 +203,15 @@ func FormatCarrierList() string {
 	return sb.String()
 }
 
-// PostCarrierFlightLog posts a carrier update to the flight log channel
-func PostCarrierFlightLog(stationId string, changes []string) {
+// PostCarrierFlightLog posts a carrier update to the flight log channel of a guild, in the language of the guild.
+// Updates that don't come from a guild, such as EDDN, are posted to all flight log channels.
+func PostCarrierFlightLog(ctx context.Context, guildId, stationId string, changes []string) {
 	if core.Settings.DisableFlightLogs() {
 		return
 	}
-	channelId := core.Settings.CarrierFlightLogChannelId()
-	if channelId == "" || discordSession == nil {
+	channels := flightLogChannels(guildId)
+	s := session()
+	if len(channels) == 0 || s == nil {
 		return
 	}
 
@@ -199,24 +221,63 @@ func PostCarrierFlightLog(stationId string, changes []string) {
 		return
 	}
 
-	var sb strings.Builder
+	posts := map[string]string{}
+	for _, channel := range channels {
+		post, ok := posts[channel.locale]
+		if !ok {
+			var sb strings.Builder
+
+			// Format the carrier entry
+			sb.WriteString(formatSingleCarrier(ctx, channel.locale, info))
+
+			// Add what changed
+			if len(changes) > 0 {
+				sb.WriteString("\n" + i18n.T(channel.locale, "carriers.changes") + " ")
+				sb.WriteString(strings.Join(changes, ", "))
+			}
+			post = sb.String()
+			posts[channel.locale] = post
+		}
+
+		_, err = s.ChannelMessageSendComplex(channel.id, &discordgo.MessageSend{Content: post})
+		if err != nil {
+			core.LogErrorF("Failed to post flight log to %s: %s", channel.id, err)
+		}
+	}
+}
 
-	// Format the carrier entry
-	sb.WriteString(formatSingleCarrier(info))
+// flightLogChannel is a channel to post flight logs in, and the language of its guild
+type flightLogChannel struct {
+	id     string
+	locale string
+}
 
-	// Add what changed
-	if len(changes) > 0 {
-		sb.WriteString("\n**Changes:** ")
-		sb.WriteString(strings.Join(changes, ", "))
+// flightLogChannels returns the flight log channel for a guild, or all flight log channels if guildId is empty
+func flightLogChannels(guildId string) []flightLogChannel {
+	if guildId != "" {
+		settings := database.FetchGuildSettings(guildId)
+		if settings.CarrierFlightLogChannelId != "" {
+			return []flightLogChannel{{settings.CarrierFlightLogChannelId, settings.Locale}}
+		}
+		return nil
 	}
 
-	_, err = discordSession.ChannelMessageSend(channelId, sb.String())
-	if err != nil {
-		core.LogErrorF("Failed to post flight log: %s", err)
+	var channels []flightLogChannel
+	seen := map[string]bool{}
+	add := func(channelId, locale string) {
+		if channelId != "" && !seen[channelId] {
+			seen[channelId] = true
+			channels = append(channels, flightLogChannel{channelId, locale})
+		}
 	}
+	add(core.Settings.CarrierFlightLogChannelId(), core.Settings.Locale())
+	for _, settings := range database.FetchGuildSettingsWithOverride(database.GuildFlightLogChannel) {
+		add(settings.CarrierFlightLogChannelId, settings.Locale)
+	}
+	return channels
 }
 
-func formatSingleCarrier(c *CarrierInfo) string {
+func formatSingleCarrier(ctx context.Context, locale string, c *CarrierInfo) string {
 	var sb strings.Builder
 
 	// Header: NAME - STATION-ID (linked to Inara if available)
@@ -238,25 +299,29 @@ func formatSingleCarrier(c *CarrierInfo) string {
 	movedAfterDeparture := c.JumpTime != nil && c.LocationChanged != nil && *c.LocationChanged >= *c.JumpTime
 
 	// Location with timestamps
-	locationLabel := "Last Known Location"
+	locationLabel := i18n.T(locale, "carriers.last_known_location")
 	if stationaryLocation {
-		locationLabel = "Current Location"
+		locationLabel = i18n.T(locale, "carriers.current_location")
+	}
+	system := c.CurrentSystem
+	if system == "Unknown" {
+		system = i18n.T(locale, "carriers.unknown_system")
 	}
-	locationLine := fmt.Sprintf("\U0001F4CD %s: %s", locationLabel, c.CurrentSystem) // 📍
+	locationLine := "\U0001F4CD " + i18n.T(locale, "carriers.location", locationLabel, system) // 📍
 	if c.LocationChanged != nil {
-		locationLine += fmt.Sprintf(" (changed <t:%d:R>", *c.LocationChanged)
+		locationLine += " (" + i18n.T(locale, "carriers.location_changed", *c.LocationChanged)
 		if c.LocationUpdated != nil && *c.LocationUpdated != *c.LocationChanged {
-			locationLine += fmt.Sprintf(", last confirmed <t:%d:R>", *c.LocationUpdated)
+			locationLine += ", " + i18n.T(locale, "carriers.location_confirmed", *c.LocationUpdated)
 		}
 		locationLine += ")"
 	} else if c.LocationUpdated != nil {
-		locationLine += fmt.Sprintf(" (updated <t:%d:R>)", *c.LocationUpdated)
+		locationLine += " (" + i18n.T(locale, "carriers.location_updated", *c.LocationUpdated) + ")"
 	}
 	sb.WriteString(locationLine + "\n")
 
 	// Pending jump from EDDN (scheduled jump)
 	if c.PendingJumpDest != nil && c.PendingJumpTime != nil && *c.PendingJumpTime > now {
-		sb.WriteString(fmt.Sprintf("\U0001F4E1 Pending Jump: %s (<t:%d:R>)\n", *c.PendingJumpDest, *c.PendingJumpTime)) // 📡
+		sb.WriteString("\U0001F4E1 " + i18n.T(locale, "carriers.pending_jump", *c.PendingJumpDest, *c.PendingJumpTime) + "\n") // 📡
 	}
 
 	// Effective departure time: manual JumpTime, or fall back to last location change
@@ -271,24 +336,24 @@ func formatSingleCarrier(c *CarrierInfo) string {
 	// Departure (always shown)
 	if inTransit {
 		if departedTime != nil {
-			sb.WriteString(fmt.Sprintf("\U0001F680 In Transit (departed <t:%d:F>)\n", *departedTime)) // 🚀
+			sb.WriteString("\U0001F680 " + i18n.T(locale, "carriers.in_transit_since", *departedTime) + "\n") // 🚀
 		} else {
-			sb.WriteString("\U0001F680 In Transit\n") // 🚀
+			sb.WriteString("\U0001F680 " + i18n.T(locale, "carriers.in_transit") + "\n") // 🚀
 		}
 	} else if c.JumpTime != nil && *c.JumpTime > now {
-		sb.WriteString(fmt.Sprintf("\u23F1\uFE0F Departing <t:%d:F> (<t:%d:R>)\n", *c.JumpTime, *c.JumpTime)) // ⏱️
+		sb.WriteString("\u23F1\uFE0F " + i18n.T(locale, "carriers.departing", *c.JumpTime) + "\n") // ⏱️
 	} else if departedTime != nil && *departedTime <= now && (!stationaryLocation || movedAfterDeparture) {
-		sb.WriteString(fmt.Sprintf("\U0001F680 Departed <t:%d:F> (<t:%d:R>)\n", *departedTime, *departedTime)) // 🚀
+		sb.WriteString("\U0001F680 " + i18n.T(locale, "carriers.departed", *departedTime) + "\n") // 🚀
 	} else if c.JumpTime != nil && *c.JumpTime <= now && stationaryLocation {
-		sb.WriteString(fmt.Sprintf("\u23F1\uFE0F Departure scheduled <t:%d:F> (expected soon)\n", *c.JumpTime)) // ⏱️
+		sb.WriteString("\u23F1\uFE0F " + i18n.T(locale, "carriers.departure_expected", *c.JumpTime) + "\n") // ⏱️
 	} else {
-		sb.WriteString("\u23F1\uFE0F Departure: TBD\n") // ⏱️
+		sb.WriteString("\u23F1\uFE0F " + i18n.T(locale, "carriers.departure_tbd") + "\n") // ⏱️
 	}
 
 	// Destination (always shown)
 	if c.Destination != nil && *c.Destination != "" &&
 		!strings.EqualFold(c.CurrentSystem, *c.Destination) {
-		destLine := fmt.Sprintf("\U0001F4CC Destination: %s", *c.Destination) // 📌
+		destLine := "\U0001F4CC " + i18n.T(locale, "carriers.destination", *c.Destination) // 📌
 		if c.CurrentSystem != "Unknown" {
 			// Use extracted system name for EDSM lookup (e.g. "Thuecheae OH-Y a96-0"
 			// from "Thuecheae OH-Y a96-0 Body 4"), fall back to full destination
@@ -296,58 +361,58 @@ func formatSingleCarrier(c *CarrierInfo) string {
 			if extracted := ExtractProcGenSystemName(destSystem); extracted != "" {
 				destSystem = extracted
 			}
-			dist, err := GetDistanceBetweenSystems(c.CurrentSystem, destSystem)
+			dist, err := GetDistanceBetweenSystems(ctx, c.CurrentSystem, destSystem)
 			if err == nil && dist >= 0 {
 				destLine += fmt.Sprintf(" (%.1f ly)", dist)
 			}
 		}
 		sb.WriteString(destLine + "\n")
 	} else {
-		sb.WriteString("\U0001F4CC Destination: TBD\n") // 📌
+		sb.WriteString("\U0001F4CC " + i18n.T(locale, "carriers.destination_tbd") + "\n") // 📌
 	}
 
 	// Status (only if set)
 	if c.Status != nil && *c.Status != "" {
-		sb.WriteString(fmt.Sprintf("\u2139\uFE0F Status: %s\n", *c.Status)) // ℹ️
+		sb.WriteString("\u2139\uFE0F " + i18n.T(locale, "carriers.status", *c.Status) + "\n") // ℹ️
 	}
 
 	return sb.String()
 }
 
 // FormatCarrierInfo formats detailed carrier info with stats for /carrierinfo
-func FormatCarrierInfo(stationId string) string {
+func FormatCarrierInfo(ctx context.Context, locale, stationId string) string {
 	info, err := GetCarrierInfo(stationId)
 	if err != nil {
-		return fmt.Sprintf("Carrier %s not found.", stationId)
+		return i18n.T(locale, "carriers.info_not_found", stationId)
 	}
 
 	var sb strings.Builder
-	sb.WriteString(formatSingleCarrier(info))
+	sb.WriteString(formatSingleCarrier(ctx, locale, info))
 	sb.WriteString("\n")
-	sb.WriteString(FormatCarrierStats(stationId))
+	sb.WriteString(FormatCarrierStats(locale, stationId))
 	return sb.String()
 }
 
 // FormatCarrierStats formats carrier activity statistics
-func FormatCarrierStats(stationId string) string {
+func FormatCarrierStats(locale, stationId string) string {
 	total, weekly := database.GetCarrierStats(stationId)
 
 	if total.Jumps == 0 && total.LocationEvents == 0 && total.DockedEvents == 0 {
-		return "\U0001F4CA **Statistics:** No activity recorded yet\n" // 📊
+		return "\U0001F4CA " + i18n.T(locale, "carriers.stats_none") + "\n" // 📊
 	}
 
 	var sb strings.Builder
-	sb.WriteString("\U0001F4CA **Statistics**\n") // 📊
-	sb.WriteString(fmt.Sprintf("\U0001F680 Hyperspace jumps: %d (%.1f ly)\n", total.Jumps, total.LYJumped))
-	sb.WriteString(fmt.Sprintf("\U0001F4E1 Transponder pings: %d\n", total.LocationEvents))
-	sb.WriteString(fmt.Sprintf("\U0001F6EC Commanders docked: %d\n", total.DockedEvents))
+	sb.WriteString("\U0001F4CA " + i18n.T(locale, "carriers.stats") + "\n") // 📊
+	sb.WriteString("\U0001F680 " + i18n.T(locale, "carriers.stats_jumps", total.Jumps, total.LYJumped) + "\n")
+	sb.WriteString("\U0001F4E1 " + i18n.T(locale, "carriers.stats_pings", total.LocationEvents) + "\n")
+	sb.WriteString("\U0001F6EC " + i18n.T(locale, "carriers.stats_docked", total.DockedEvents) + "\n")
 
 	weeklyDiffers := weekly.Jumps != total.Jumps || weekly.LocationEvents != total.LocationEvents || weekly.DockedEvents != total.DockedEvents
 	if weeklyDiffers {
-		sb.WriteString("\n**This week:**\n")
-		sb.WriteString(fmt.Sprintf("\U0001F680 Hyperspace jumps: %d (%.1f ly)\n", weekly.Jumps, weekly.LYJumped))
-		sb.WriteString(fmt.Sprintf("\U0001F4E1 Transponder pings: %d\n", weekly.LocationEvents))
-		sb.WriteString(fmt.Sprintf("\U0001F6EC Commanders docked: %d\n", weekly.DockedEvents))
+		sb.WriteString("\n" + i18n.T(locale, "carriers.stats_week") + "\n")
+		sb.WriteString("\U0001F680 " + i18n.T(locale, "carriers.stats_jumps", weekly.Jumps, weekly.LYJumped) + "\n")
+		sb.WriteString("\U0001F4E1 " + i18n.T(locale, "carriers.stats_pings", weekly.LocationEvents) + "\n")
+		sb.WriteString("\U0001F6EC " + i18n.T(locale, "carriers.stats_docked", weekly.DockedEvents) + "\n")
 	}
 
 	return sb.String()
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// commandExportVersion is the version of the export format, raised when it changes incompatibly
+const commandExportVersion = 1
+
+// CommandExport is the JSON format custom commands and categories are exported and imported in
+type CommandExport struct {
+	Version    int                `json:"version"`
+	ExportedAt int64              `json:"exported_at"`
+	Categories []ExportedCategory `json:"categories"`
+	Commands   []ExportedCommand  `json:"commands"`
+}
+
+// ExportedCategory is a custom command category
+type ExportedCategory struct {
+	Name string  `json:"name"`
+	Help *string `json:"help,omitempty"`
+}
+
+// ExportedCommand is a custom command, with the name of its category
+type ExportedCommand struct {
+	Command  string           `json:"command"`
+	Value    string           `json:"value"`
+	Help     *string          `json:"help,omitempty"`
+	Longhelp *string          `json:"longhelp,omitempty"`
+	DM       bool             `json:"dm"`
+	Category *string          `json:"category,omitempty"`
+	Response *CommandResponse `json:"response,omitempty"` // Files are exported by name, without their contents
+
+	Cooldown      *int     `json:"cooldown,omitempty"`       // Seconds between uses, omitted for the server cooldown
+	CooldownScope string   `json:"cooldown_scope,omitempty"` // database.CooldownPerChannel if omitted
+	ChannelMode   string   `json:"channel_mode,omitempty"`
+	Channels      []string `json:"channels,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	ExplainDenied bool     `json:"explain_denied,omitempty"`
+}
+
+// rules returns the cooldown and restrictions of the command as they're stored
+func (c *ExportedCommand) rules() database.CommandRules {
+	scope := c.CooldownScope
+	if scope == "" {
+		scope = database.CooldownPerChannel
+	}
+	return database.CommandRules{Cooldown: c.Cooldown, CooldownScope: scope, ChannelMode: c.ChannelMode,
+		Channels: strings.Join(c.Channels, ","), Roles: strings.Join(c.Roles, ","), ExplainDenied: c.ExplainDenied}
+}
+
+// validateRules checks the cooldown and restrictions of the command
+func (c *ExportedCommand) validateRules() error {
+	if c.Cooldown != nil && *c.Cooldown < 0 {
+		return fmt.Errorf("negative cooldown %d", *c.Cooldown)
+	}
+	switch c.CooldownScope {
+	case "", database.CooldownPerChannel, database.CooldownPerUser:
+	default:
+		return fmt.Errorf("invalid cooldown scope %q", c.CooldownScope)
+	}
+	switch c.ChannelMode {
+	case "":
+		if len(c.Channels) > 0 {
+			return fmt.Errorf("channels without a channel mode")
+		}
+	case database.ChannelsAllow, database.ChannelsDeny:
+		if len(c.Channels) == 0 {
+			return fmt.Errorf("channel mode %s without channels", c.ChannelMode)
+		}
+	default:
+		return fmt.Errorf("invalid channel mode %q", c.ChannelMode)
+	}
+	for _, id := range append(slices.Clone(c.Channels), c.Roles...) {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return fmt.Errorf("invalid channel or role ID %q", id)
+		}
+	}
+	return nil
+}
+
+// ImportPolicy decides what happens to imported commands that already exist with a different text or settings
+type ImportPolicy string
+
+const (
+	ImportSkip      ImportPolicy = "skip"      // Keep the existing command
+	ImportOverwrite ImportPolicy = "overwrite" // Replace the existing command
+	ImportRename    ImportPolicy = "rename"    // Import the command under a free name, such as faq-2
+)
+
+// ImportPolicies are the valid import policies
+var ImportPolicies = []string{string(ImportSkip), string(ImportOverwrite), string(ImportRename)}
+
+// ImportAction is what an import does with a command or category
+type ImportAction string
+
+const (
+	ImportAdd       ImportAction = "add"
+	ImportUpdate    ImportAction = "update"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportConflict  ImportAction = "conflict" // Exists with differences and is kept by the skip policy
+	ImportRenamed   ImportAction = "rename"
+	ImportReserved  ImportAction = "reserved" // The name is taken by something that can't be replaced
+)
+
+// ImportChange is what an import does with one command or category
+type ImportChange struct {
+	Name    string
+	Action  ImportAction
+	NewName string   // Name a renamed command is imported as
+	Fields  []string // Fields that differ from the existing command or category
+	Reason  string   // Why a reserved name is skipped
+}
+
+// ImportPlan is what importing an export would change, worked out without changing anything
+type ImportPlan struct {
+	Policy     ImportPolicy
+	Categories []ImportChange
+	Commands   []ImportChange
+	export     *CommandExport
+}
+
+// ExportCustomCommands returns all custom commands and categories
+func ExportCustomCommands() *CommandExport {
+	export := &CommandExport{Version: commandExportVersion, ExportedAt: time.Now().Unix(),
+		Categories: []ExportedCategory{}, Commands: []ExportedCommand{}}
+	for _, group := range database.FetchCommandGroups() {
+		export.Categories = append(export.Categories, ExportedCategory{Name: group.Command, Help: group.Help})
+		for _, cmd := range group.FetchCommands() {
+			export.Commands = append(export.Commands, exportCommand(cmd, &group.Command))
+		}
+	}
+	for _, cmd := range database.FetchStandaloneCommands() {
+		export.Commands = append(export.Commands, exportCommand(cmd, nil))
+	}
+	sort.Slice(export.Commands, func(i, j int) bool {
+		return export.Commands[i].Command < export.Commands[j].Command
+	})
+	return export
+}
+
+func exportCommand(cmd database.CommandAlias, category *string) ExportedCommand {
+	response, err := ParseCommandResponse(cmd.Response)
+	if err != nil {
+		core.LogErrorF("Exporting command %s without its response: %s", cmd.Command, err)
+	}
+	exported := ExportedCommand{Command: cmd.Command, Value: cmd.Value, Help: cmd.Help, Longhelp: cmd.Longhelp, DM: cmd.PMEnabled,
+		Category: category, Response: response, Cooldown: cmd.Cooldown, ChannelMode: cmd.ChannelMode, Channels: cmd.ChannelIds(),
+		Roles: cmd.RoleIds(), ExplainDenied: cmd.ExplainDenied}
+	if cmd.Cooldown != nil {
+		exported.CooldownScope = cmd.CooldownScope
+	}
+	return exported
+}
+
+// ParseCommandExport parses and checks an export
+func ParseCommandExport(data []byte) (*CommandExport, error) {
+	var export CommandExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a valid export: %w", err)
+	}
+	if export.Version != commandExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d, expected %d", export.Version, commandExportVersion)
+	}
+	categories := map[string]bool{}
+	for _, category := range export.Categories {
+		if category.Name == "" || strings.ContainsAny(category.Name, " \n") {
+			return nil, fmt.Errorf("invalid category name %q", category.Name)
+		}
+		if categories[category.Name] {
+			return nil, fmt.Errorf("category %s is in the export twice", category.Name)
+		}
+		categories[category.Name] = true
+	}
+	commands := map[string]bool{}
+	for _, cmd := range export.Commands {
+		if cmd.Command == "" || strings.ContainsAny(cmd.Command, " \n") {
+			return nil, fmt.Errorf("invalid command name %q", cmd.Command)
+		}
+		if commands[cmd.Command] || categories[cmd.Command] {
+			return nil, fmt.Errorf("%s is in the export twice", cmd.Command)
+		}
+		if cmd.Category != nil && !categories[*cmd.Category] {
+			return nil, fmt.Errorf("command %s is in category %s, which isn't in the export", cmd.Command, *cmd.Category)
+		}
+		if _, err := ParseTemplate(cmd.Value); err != nil {
+			return nil, fmt.Errorf("command %s: %w", cmd.Command, err)
+		}
+		if err := cmd.Response.Validate(); err != nil {
+			return nil, fmt.Errorf("command %s: %w", cmd.Command, err)
+		}
+		if err := cmd.validateRules(); err != nil {
+			return nil, fmt.Errorf("command %s: %w", cmd.Command, err)
+		}
+		commands[cmd.Command] = true
+	}
+	return &export, nil
+}
+
+// PlanImport works out what importing the export would change. Reserved returns why a name can't be used for
+// a custom command, such as a built-in command with the name, or an empty string if it can.
+func PlanImport(export *CommandExport, policy ImportPolicy, reserved func(name string) string) *ImportPlan {
+	plan := &ImportPlan{Policy: policy, export: export}
+	imported := map[string]bool{}
+	for _, category := range export.Categories {
+		imported[category.Name] = true
+	}
+	for _, cmd := range export.Commands {
+		imported[cmd.Command] = true
+	}
+
+	for _, category := range export.Categories {
+		change := ImportChange{Name: category.Name}
+		existing := database.FetchCommandGroup(category.Name)
+		switch {
+		case reserved(category.Name) != "":
+			change.Action, change.Reason = ImportReserved, reserved(category.Name)
+		case database.HasCommandAlias(category.Name):
+			change.Action, change.Reason = ImportReserved, "an existing custom command"
+		case existing == nil:
+			change.Action = ImportAdd
+		case optional(existing.Help) == optional(category.Help):
+			change.Action = ImportUnchanged
+		case policy == ImportOverwrite:
+			change.Action, change.Fields = ImportUpdate, []string{"help"}
+		default:
+			change.Action, change.Fields = ImportConflict, []string{"help"}
+		}
+		plan.Categories = append(plan.Categories, change)
+	}
+
+	for _, cmd := range export.Commands {
+		change := ImportChange{Name: cmd.Command}
+		existing := database.FetchCommandAlias(cmd.Command)
+		switch {
+		case reserved(cmd.Command) != "":
+			change.Action, change.Reason = ImportReserved, reserved(cmd.Command)
+		case database.HasCommandGroup(cmd.Command):
+			change.Action, change.Reason = ImportReserved, "an existing category"
+		case existing == nil:
+			change.Action = ImportAdd
+		default:
+			change.Fields = commandDifferences(existing, cmd)
+			switch {
+			case len(change.Fields) == 0:
+				change.Action = ImportUnchanged
+			case policy == ImportOverwrite:
+				change.Action = ImportUpdate
+			case policy == ImportRename:
+				name, earlier := renamedCommandName(cmd, imported, reserved)
+				change.Action, change.NewName = ImportRenamed, name
+				if earlier {
+					change.Action = ImportUnchanged
+				}
+				imported[name] = true
+			default:
+				change.Action = ImportConflict
+			}
+		}
+		plan.Commands = append(plan.Commands, change)
+	}
+	return plan
+}
+
+// commandDifferences returns the fields of an exported command that differ from the existing one
+func commandDifferences(existing *database.CommandAlias, cmd ExportedCommand) []string {
+	var fields []string
+	if existing.Value != cmd.Value {
+		fields = append(fields, "text")
+	}
+	if optional(existing.Help) != optional(cmd.Help) {
+		fields = append(fields, "help")
+	}
+	if optional(existing.Longhelp) != optional(cmd.Longhelp) {
+		fields = append(fields, "long help")
+	}
+	if existing.PMEnabled != cmd.DM {
+		fields = append(fields, "DM")
+	}
+	// Compared as encoded again, so the JSON formatting doesn't matter
+	if response, _ := ParseCommandResponse(existing.Response); optional(response.Encode()) != optional(cmd.Response.Encode()) {
+		fields = append(fields, "response")
+	}
+	rules := cmd.rules()
+	if optionalInt(existing.Cooldown) != optionalInt(rules.Cooldown) ||
+		(existing.Cooldown != nil && existing.CooldownScope != rules.CooldownScope) {
+		fields = append(fields, "cooldown")
+	}
+	if existing.ChannelMode != rules.ChannelMode || existing.Channels != rules.Channels {
+		fields = append(fields, "channels")
+	}
+	if existing.Roles != rules.Roles {
+		fields = append(fields, "roles")
+	}
+	if existing.ExplainDenied != rules.ExplainDenied {
+		fields = append(fields, "explaining denied uses")
+	}
+	category := ""
+	if existing.GroupId != nil {
+		for _, group := range database.FetchCommandGroups() {
+			if group.Id == int64(*existing.GroupId) {
+				category = group.Command
+			}
+		}
+	}
+	if category != optional(cmd.Category) {
+		fields = append(fields, "category")
+	}
+	return fields
+}
+
+// renamedCommandName returns the first of name-2, name-3, ... that isn't used or reserved. If one of those is the
+// same as the command, it was renamed by an earlier import, and that name is returned with true.
+func renamedCommandName(cmd ExportedCommand, imported map[string]bool, reserved func(string) string) (string, bool) {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", cmd.Command, i)
+		if imported[candidate] || reserved(candidate) != "" || database.HasCommandGroup(candidate) {
+			continue
+		}
+		existing := database.FetchCommandAlias(candidate)
+		if existing == nil {
+			return candidate, false
+		}
+		if len(commandDifferences(existing, cmd)) == 0 {
+			return candidate, true
+		}
+	}
+}
+
+// Apply makes the changes of the plan in one transaction, recording a revision of every imported command. Returns
+// the number of commands added or changed, none if it fails.
+func (p *ImportPlan) Apply(userId, userName string) (int, error) {
+	var categories []database.CommandGroup
+	skipped := map[string]bool{}
+	for i, change := range p.Categories {
+		category := p.export.Categories[i]
+		switch change.Action {
+		case ImportAdd, ImportUpdate:
+			categories = append(categories, database.CommandGroup{Command: category.Name, Help: category.Help})
+		case ImportReserved:
+			skipped[category.Name] = true
+		}
+	}
+
+	var commands []database.CategorizedCommand
+	for i, change := range p.Commands {
+		cmd := p.export.Commands[i]
+		name := cmd.Command
+		switch change.Action {
+		case ImportAdd, ImportUpdate:
+		case ImportRenamed:
+			name = change.NewName
+		default:
+			continue
+		}
+		alias := database.CategorizedCommand{CommandAlias: database.CommandAlias{Command: name, Value: cmd.Value, Help: cmd.Help,
+			Longhelp: cmd.Longhelp, PMEnabled: cmd.DM, Response: cmd.Response.Encode(), CommandRules: cmd.rules()}}
+		if cmd.Category != nil && !skipped[*cmd.Category] {
+			alias.Category = cmd.Category
+		}
+		commands = append(commands, alias)
+	}
+	if err := database.SaveCommandAliases(categories, commands, database.RevisionImport, userId, userName); err != nil {
+		return 0, err
+	}
+	return len(commands), nil
+}
+
+// Counts returns the number of commands the plan adds, updates, renames, skips and leaves unchanged
+func (p *ImportPlan) Counts() map[ImportAction]int {
+	counts := map[ImportAction]int{}
+	for _, change := range p.Commands {
+		counts[change.Action]++
+	}
+	return counts
+}
+
+// FormatImportPlan describes the changes of an import for review before applying it
+func FormatImportPlan(plan *ImportPlan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**Import plan** (conflicts: %s)\n", plan.Policy))
+	for _, section := range []struct {
+		title   string
+		changes []ImportChange
+	}{{"Categories", plan.Categories}, {"Commands", plan.Commands}} {
+		var lines []string
+		for _, change := range section.changes {
+			if change.Action != ImportUnchanged {
+				lines = append(lines, formatImportChange(change))
+			}
+		}
+		if len(lines) > 0 {
+			sb.WriteString("**" + section.title + ":**\n" + strings.Join(lines, "\n") + "\n")
+		}
+	}
+	counts := plan.Counts()
+	sb.WriteString(fmt.Sprintf("%d new, %d updated, %d renamed, %d skipped, %d unchanged",
+		counts[ImportAdd], counts[ImportUpdate], counts[ImportRenamed], counts[ImportConflict]+counts[ImportReserved],
+		counts[ImportUnchanged]))
+	return sb.String()
+}
+
+func formatImportChange(change ImportChange) string {
+	switch change.Action {
+	case ImportAdd:
+		return fmt.Sprintf("`+` %s (new)", change.Name)
+	case ImportUpdate:
+		return fmt.Sprintf("`~` %s (overwrites %s)", change.Name, strings.Join(change.Fields, ", "))
+	case ImportRenamed:
+		return fmt.Sprintf("`→` %s as %s (differs in %s)", change.Name, change.NewName, strings.Join(change.Fields, ", "))
+	case ImportConflict:
+		return fmt.Sprintf("`!` %s skipped, the existing one differs in %s", change.Name, strings.Join(change.Fields, ", "))
+	case ImportReserved:
+		return fmt.Sprintf("`!` %s skipped, the name is used by %s", change.Name, change.Reason)
+	default:
+		return fmt.Sprintf("`=` %s", change.Name)
+	}
+}
+
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optionalInt formats an optional number for comparison, with nil different from every number
+func optionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
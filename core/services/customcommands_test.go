@@ -0,0 +1,172 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"GoBot/core/database"
+)
+
+func TestParseCommandExport(t *testing.T) {
+	valid := `{"version": 1, "categories": [{"name": "info", "help": "About us"}],
+		"commands": [{"command": "faq", "value": "Read the {{FAQ}}", "category": "info"}, {"command": "hi", "value": "Hello", "dm": true}]}`
+	export, err := ParseCommandExport([]byte(valid))
+	if err != nil {
+		t.Fatalf("Failed to parse a valid export: %s", err)
+	}
+	if len(export.Commands) != 2 || *export.Commands[0].Category != "info" || !export.Commands[1].DM || *export.Categories[0].Help != "About us" {
+		t.Errorf("Unexpected export %+v", export)
+	}
+
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"version": 1, "commands": [`, "not a valid export"},
+		{`{"version": 2}`, "unsupported export version 2"},
+		{`{"version": 1, "commands": [{"command": "two words", "value": "x"}]}`, "invalid command name"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x"}, {"command": "a", "value": "y"}]}`, "a is in the export twice"},
+		{`{"version": 1, "categories": [{"name": "a"}], "commands": [{"command": "a", "value": "x"}]}`, "a is in the export twice"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x", "category": "missing"}]}`, "which isn't in the export"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "Hello {user"}]}`, "command a: unclosed {"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x", "cooldown": -1}]}`, "negative cooldown"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x", "channel_mode": "sometimes", "channels": ["1"]}]}`, "invalid channel mode"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x", "channel_mode": "allow"}]}`, "without channels"},
+		{`{"version": 1, "commands": [{"command": "a", "value": "x", "roles": ["@Member"]}]}`, "invalid channel or role ID"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCommandExport([]byte(tt.json)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseCommandExport(%s) = %v, want an error containing %q", tt.json, err, tt.want)
+		}
+	}
+}
+
+func TestFormatImportPlan(t *testing.T) {
+	plan := &ImportPlan{Policy: ImportRename,
+		Categories: []ImportChange{{Name: "info", Action: ImportUnchanged}},
+		Commands: []ImportChange{
+			{Name: "faq", Action: ImportRenamed, NewName: "faq-2", Fields: []string{"text", "help"}},
+			{Name: "ping", Action: ImportReserved, Reason: "the built-in command of the same name"},
+			{Name: "hi", Action: ImportAdd},
+			{Name: "same", Action: ImportUnchanged},
+		}}
+	want := "**Import plan** (conflicts: rename)\n**Commands:**\n" +
+		"`→` faq as faq-2 (differs in text, help)\n" +
+		"`!` ping skipped, the name is used by the built-in command of the same name\n" +
+		"`+` hi (new)\n" +
+		"1 new, 0 updated, 1 renamed, 1 skipped, 1 unchanged"
+	if got := FormatImportPlan(plan); got != want {
+		t.Errorf("FormatImportPlan() = %q, want %q", got, want)
+	}
+}
+
+func TestExportCustomCommands_Rules(t *testing.T) {
+	setupTestDB(t)
+	seconds := 30
+	database.SaveCommandAlias(database.CommandAlias{Command: "faq", Value: "Read the FAQ", CommandRules: database.CommandRules{
+		Cooldown: &seconds, CooldownScope: database.CooldownPerUser, ChannelMode: database.ChannelsAllow, Channels: "1,2", Roles: "3"}})
+
+	export := ExportCustomCommands()
+	cmd := export.Commands[0]
+	if cmd.Cooldown == nil || *cmd.Cooldown != 30 || cmd.CooldownScope != database.CooldownPerUser || cmd.ChannelMode != database.ChannelsAllow ||
+		len(cmd.Channels) != 2 || len(cmd.Roles) != 1 || cmd.ExplainDenied {
+		t.Fatalf("Expected the rules in the export, got %+v", cmd)
+	}
+	if plan := PlanImport(export, ImportOverwrite, noReserved); plan.Commands[0].Action != ImportUnchanged {
+		t.Errorf("Expected importing the export to change nothing, got %+v", plan.Commands[0])
+	}
+
+	export.Commands[0].Roles, export.Commands[0].Cooldown = nil, nil
+	plan := PlanImport(export, ImportOverwrite, noReserved)
+	if change := plan.Commands[0]; change.Action != ImportUpdate || strings.Join(change.Fields, ", ") != "cooldown, roles" {
+		t.Fatalf("Expected the cooldown and roles to be overwritten, got %+v", change)
+	}
+	if _, err := plan.Apply("1", "cmdr"); err != nil {
+		t.Fatalf("Failed to apply the import: %s", err)
+	}
+	if alias := database.FetchCommandAlias("faq"); alias.Cooldown != nil || alias.Roles != "" || alias.Channels != "1,2" {
+		t.Errorf("Expected the imported rules, got %+v", alias.CommandRules)
+	}
+}
+
+// importTestExport is imported over the faq and hi commands of setupImportTest, changing the text of faq
+const importTestExport = `{"version": 1, "categories": [{"name": "info"}], "commands": [
+	{"command": "faq", "value": "New FAQ", "category": "info"}, {"command": "hi", "value": "Hello"}, {"command": "news", "value": "News"}]}`
+
+func setupImportTest(t *testing.T) *CommandExport {
+	setupTestDB(t)
+	database.SaveCommandAlias(database.CommandAlias{Command: "faq", Value: "Old FAQ"})
+	database.SaveCommandAlias(database.CommandAlias{Command: "hi", Value: "Hello"})
+	export, err := ParseCommandExport([]byte(importTestExport))
+	if err != nil {
+		t.Fatalf("Failed to parse the export: %s", err)
+	}
+	return export
+}
+
+func applyImport(t *testing.T, export *CommandExport, policy ImportPolicy) (*ImportPlan, int) {
+	plan := PlanImport(export, policy, noReserved)
+	applied, err := plan.Apply("1", "cmdr")
+	if err != nil {
+		t.Fatalf("Failed to apply the import: %s", err)
+	}
+	return plan, applied
+}
+
+func commandValue(name string) string {
+	if cmd := database.FetchCommandAlias(name); cmd != nil {
+		return cmd.Value
+	}
+	return "<missing>"
+}
+
+func TestImportPlan_Skip(t *testing.T) {
+	export := setupImportTest(t)
+	plan, applied := applyImport(t, export, ImportSkip)
+	if counts := plan.Counts(); counts[ImportConflict] != 1 || counts[ImportUnchanged] != 1 || counts[ImportAdd] != 1 || applied != 1 {
+		t.Fatalf("Expected faq skipped, hi unchanged and news added, got %+v", plan.Commands)
+	}
+	if commandValue("faq") != "Old FAQ" || commandValue("news") != "News" || database.FetchCommandGroup("info") == nil {
+		t.Errorf("Expected the existing faq kept and news imported, got %q and %q", commandValue("faq"), commandValue("news"))
+	}
+	if rev := database.FetchLatestCommandRevision("news"); rev == nil || rev.Action != database.RevisionImport {
+		t.Errorf("Expected an import revision of news, got %+v", rev)
+	}
+}
+
+func TestImportPlan_Overwrite(t *testing.T) {
+	export := setupImportTest(t)
+	plan, applied := applyImport(t, export, ImportOverwrite)
+	if change := plan.Commands[0]; change.Action != ImportUpdate || strings.Join(change.Fields, ", ") != "text, category" || applied != 2 {
+		t.Fatalf("Expected the text and category of faq overwritten, got %+v", plan.Commands)
+	}
+	if cmd := database.FetchCommandAlias("faq"); cmd.Value != "New FAQ" || cmd.GroupId == nil {
+		t.Errorf("Expected faq overwritten in its category, got %+v", cmd)
+	}
+}
+
+func TestImportPlan_Rename(t *testing.T) {
+	export := setupImportTest(t)
+	database.SaveCommandAlias(database.CommandAlias{Command: "faq-2", Value: "Something else"})
+	plan, applied := applyImport(t, export, ImportRename)
+	if change := plan.Commands[0]; change.Action != ImportRenamed || change.NewName != "faq-3" || applied != 2 {
+		t.Fatalf("Expected faq imported as faq-3, got %+v", plan.Commands)
+	}
+	if commandValue("faq") != "Old FAQ" || commandValue("faq-2") != "Something else" || commandValue("faq-3") != "New FAQ" {
+		t.Errorf("Expected the existing commands kept and faq-3 imported, got %q, %q and %q",
+			commandValue("faq"), commandValue("faq-2"), commandValue("faq-3"))
+	}
+
+	// Importing again finds the command renamed by the earlier import instead of adding faq-4
+	plan, applied = applyImport(t, export, ImportRename)
+	if change := plan.Commands[0]; change.Action != ImportUnchanged || change.NewName != "faq-3" || applied != 0 {
+		t.Errorf("Expected faq found as faq-3, got %+v", plan.Commands)
+	}
+	if database.HasCommandAlias("faq-4") {
+		t.Error("Expected no faq-4")
+	}
+}
+
+func noReserved(string) string {
+	return ""
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     []string
+	}{
+		{"same", "same", []string{"  same"}},
+		{"a\nb\nc", "a\nx\nc", []string{"  a", "- b", "+ x", "  c"}},
+		{"a\nc", "a\nb\nc", []string{"  a", "+ b", "  c"}},
+		{"a\nb", "b", []string{"- a", "  b"}},
+	}
+	for _, tt := range tests {
+		if got := DiffLines(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiffLines(%q, %q) = %q, want %q", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
@@ -66,8 +66,16 @@ type suspiciousLocation struct {
 // suspiciousLocations maps stationId -> pending suspicious location
 var suspiciousLocations = make(map[string]*suspiciousLocation)
 
-// StartEDDNListener starts the EDDN listener in a goroutine
-func StartEDDNListener() {
+var (
+	// stopEDDN disconnects the listener, nil when it isn't running
+	stopEDDN context.CancelFunc
+	// eddnStopped is closed when the listener has stopped
+	eddnStopped chan struct{}
+)
+
+// StartEDDNListener starts the EDDN listener in a goroutine. Received messages are processed with ctx,
+// so they aren't cancelled when the listener is stopped.
+func StartEDDNListener(ctx context.Context) {
 	// Build lookup map of our carrier callsigns
 	carrierCallsigns = make(map[string]bool)
 	for _, c := range core.Settings.Carriers() {
@@ -79,23 +87,48 @@ func StartEDDNListener() {
 		return
 	}
 
-	go eddnListenerLoop()
+	listenCtx, cancel := context.WithCancel(ctx)
+	stopEDDN = cancel
+	eddnStopped = make(chan struct{})
+	go eddnListenerLoop(ctx, listenCtx)
+}
+
+// StopEDDNListener disconnects from EDDN and waits for the listener to stop, or for ctx to be done.
+// Messages already received are still being processed when it returns.
+func StopEDDNListener(ctx context.Context) error {
+	if stopEDDN == nil {
+		return nil
+	}
+	stopEDDN()
+	select {
+	case <-eddnStopped:
+		core.LogInfo("EDDN listener stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func eddnListenerLoop() {
+func eddnListenerLoop(ctx, listenCtx context.Context) {
+	defer close(eddnStopped)
 	for {
-		err := connectAndListen()
+		err := connectAndListen(ctx, listenCtx)
+		if listenCtx.Err() != nil {
+			return
+		}
 		if err != nil {
 			core.LogErrorF("EDDN listener error: %s, reconnecting in 30s...", err)
-			time.Sleep(30 * time.Second)
+			select {
+			case <-listenCtx.Done():
+				return
+			case <-time.After(30 * time.Second):
+			}
 		}
 	}
 }
 
-func connectAndListen() error {
-	ctx := context.Background()
-
-	sub := zmq4.NewSub(ctx)
+func connectAndListen(ctx, listenCtx context.Context) error {
+	sub := zmq4.NewSub(listenCtx)
 	defer sub.Close()
 
 	err := sub.Dial(eddnRelayURL)
@@ -121,11 +154,14 @@ func connectAndListen() error {
 			continue
 		}
 
-		go processEDDNMessage(msg.Frames[0])
+		frame := msg.Frames[0]
+		if !work.Go(func() { processEDDNMessage(ctx, frame) }) {
+			return nil // Shutting down
+		}
 	}
 }
 
-func processEDDNMessage(compressed []byte) {
+func processEDDNMessage(ctx context.Context, compressed []byte) {
 	// Decompress zlib
 	reader, err := zlib.NewReader(bytes.NewReader(compressed))
 	if err != nil {
@@ -146,7 +182,7 @@ func processEDDNMessage(compressed []byte) {
 
 	// Route based on schema - carrier data comes through journal schema
 	if strings.Contains(eddnMsg.Schema, "/journal/") {
-		processJournalMessage(eddnMsg.Message, eddnMsg.Header.UploaderID)
+		processJournalMessage(ctx, eddnMsg.Message, eddnMsg.Header.UploaderID)
 	}
 }
 
@@ -179,7 +215,7 @@ func parseEDDNTimestamp(timestamp string) int64 {
 	return time.Now().Unix()
 }
 
-func processJournalMessage(raw json.RawMessage, uploaderID string) {
+func processJournalMessage(ctx context.Context, raw json.RawMessage, uploaderID string) {
 	var msg JournalMessage
 	if err := json.Unmarshal(raw, &msg); err != nil {
 		return
@@ -194,32 +230,33 @@ func processJournalMessage(raw json.RawMessage, uploaderID string) {
 	switch msg.Event {
 	case "CarrierJump":
 		if isOurs {
-			updateCarrierFromEDDN(msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
+			updateCarrierFromEDDN(ctx, msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
 			core.LogDebugF("Carrier %s: pending jump cleared (source: EDDN CarrierJump)", msg.StationName)
 			database.ClearCarrierPendingJump(msg.StationName)
+			rescheduleCarrierReminders(msg.StationName)
 		} else {
-			checkAndRecordFollower(msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
+			checkAndRecordFollower(ctx, msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
 		}
 	case "Location":
 		if isOurs {
-			updateCarrierFromEDDN(msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
+			updateCarrierFromEDDN(ctx, msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
 			core.LogDebugF("Carrier %s: location event recorded (source: EDDN Location)", msg.StationName)
 			database.IncrementCarrierLocationEvent(msg.StationName)
 		} else {
-			checkAndRecordFollower(msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
+			checkAndRecordFollower(ctx, msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
 		}
 	case "Docked":
 		if isOurs {
-			updateCarrierFromEDDN(msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
+			updateCarrierFromEDDN(ctx, msg.StationName, msg.StarSystem, msg.Timestamp, msg.Event, uploaderID)
 			core.LogDebugF("Carrier %s: docked event recorded (source: EDDN Docked)", msg.StationName)
 			database.IncrementCarrierDockedEvent(msg.StationName)
 		} else {
-			checkAndRecordFollower(msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
+			checkAndRecordFollower(ctx, msg.StationName, msg.StarSystem, parseEDDNTimestamp(msg.Timestamp))
 		}
 	}
 }
 
-func updateCarrierFromEDDN(stationId, system, timestamp, eventType, uploaderID string) {
+func updateCarrierFromEDDN(ctx context.Context, stationId, system, timestamp, eventType, uploaderID string) {
 	if system == "" {
 		return
 	}
@@ -255,9 +292,9 @@ func updateCarrierFromEDDN(stationId, system, timestamp, eventType, uploaderID s
 	}
 
 	// Determine if this update is suspicious and needs validation
-	suspicious, reason := isLocationSuspicious(stationId, system, state, eventTime)
+	suspicious, reason := isLocationSuspicious(ctx, stationId, system, state, eventTime)
 	if suspicious {
-		handleSuspiciousLocation(stationId, system, eventTime, eventType, uploaderID, reason)
+		handleSuspiciousLocation(ctx, stationId, system, eventTime, eventType, uploaderID, reason)
 		return
 	}
 
@@ -272,8 +309,8 @@ func updateCarrierFromEDDN(stationId, system, timestamp, eventType, uploaderID s
 		// Record jump stats with distance
 		var jumpDist float64
 		if state != nil && state.CurrentSystem != nil {
-			prevCoords, err1 := GetSystemCoords(*state.CurrentSystem)
-			newCoords, err2 := GetSystemCoords(system)
+			prevCoords, err1 := GetSystemCoords(ctx, *state.CurrentSystem)
+			newCoords, err2 := GetSystemCoords(ctx, system)
 			if err1 == nil && err2 == nil && prevCoords != nil && newCoords != nil {
 				jumpDist = CalculateDistance(prevCoords, newCoords)
 			}
@@ -290,18 +327,19 @@ func updateCarrierFromEDDN(stationId, system, timestamp, eventType, uploaderID s
 			}
 			core.LogDebugF("Carrier %s: jump time set to %d (source: EDDN %s, previous: %v)", stationId, eventTime, eventType, prevJumpTime)
 			database.UpdateCarrierJumpTime(stationId, &eventTime)
+			rescheduleCarrierReminders(stationId)
 		}
-		PostCarrierFlightLog(stationId, []string{"location: " + system})
+		PostCarrierFlightLog(ctx, "", stationId, []string{"location: " + system})
 
 		// Check proximity alerts after all DB writes are complete to avoid SQLite lock contention
-		go CheckProximityAlerts(stationId, system)
+		work.Go(func() { CheckProximityAlerts(ctx, stationId, system) })
 	} else {
 		core.LogDebugF("EDDN: %s - %s location confirmed at %s (%s) [%s]", eventType, getCarrierDisplayName(stationId), system, eventTimeStr, uploaderID)
 	}
 }
 
 // isLocationSuspicious checks if a location update should require validation
-func isLocationSuspicious(stationId, newSystem string, state *database.CarrierState, eventTime int64) (bool, string) {
+func isLocationSuspicious(ctx context.Context, stationId, newSystem string, state *database.CarrierState, eventTime int64) (bool, string) {
 	if state == nil || state.CurrentSystem == nil {
 		return false, "" // First location, accept it
 	}
@@ -319,14 +357,14 @@ func isLocationSuspicious(stationId, newSystem string, state *database.CarrierSt
 	// From here on, the location is actually changing
 
 	// Check 1: Is the system known in EDSM?
-	coords, err := GetSystemCoords(newSystem)
+	coords, err := GetSystemCoords(ctx, newSystem)
 	if err != nil || coords == nil {
 		return true, "unknown system"
 	}
 
 	// Check 2: Is the distance reasonable? (< 500ly) - only if "range" validation enabled
 	if core.Settings.CarrierValidationEnabled("range") {
-		currentCoords, err := GetSystemCoords(currentSystem)
+		currentCoords, err := GetSystemCoords(ctx, currentSystem)
 		if err == nil && currentCoords != nil {
 			dist := CalculateDistance(currentCoords, coords)
 			if dist > suspiciousDistanceThreshold {
@@ -349,7 +387,7 @@ func isLocationSuspicious(stationId, newSystem string, state *database.CarrierSt
 }
 
 // handleSuspiciousLocation tracks suspicious location and applies if validated
-func handleSuspiciousLocation(stationId, system string, eventTime int64, eventType, uploaderID, reason string) {
+func handleSuspiciousLocation(ctx context.Context, stationId, system string, eventTime int64, eventType, uploaderID, reason string) {
 	now := time.Now().Unix()
 
 	pending := suspiciousLocations[stationId]
@@ -365,8 +403,8 @@ func handleSuspiciousLocation(stationId, system string, eventTime int64, eventTy
 			core.LogDebugF("Carrier %s: location set to %q (source: EDDN validated suspicious)", stationId, system)
 			_, changed := database.UpdateCarrierLocation(stationId, system, "", eventTime)
 			if changed {
-				PostCarrierFlightLog(stationId, []string{"location: " + system + " (validated)"})
-				go CheckProximityAlerts(stationId, system)
+				PostCarrierFlightLog(ctx, "", stationId, []string{"location: " + system + " (validated)"})
+				work.Go(func() { CheckProximityAlerts(ctx, stationId, system) })
 			}
 			delete(suspiciousLocations, stationId)
 		}
@@ -410,7 +448,7 @@ func getCarrierDisplayName(stationId string) string {
 }
 
 // checkAndRecordFollower checks if an external carrier is near any of our carriers
-func checkAndRecordFollower(followerStationId, system string, eventTime int64) {
+func checkAndRecordFollower(ctx context.Context, followerStationId, system string, eventTime int64) {
 	// Don't track our own carriers as followers
 	if isOurCarrier(followerStationId) {
 		return
@@ -421,7 +459,7 @@ func checkAndRecordFollower(followerStationId, system string, eventTime int64) {
 	threshold := core.Settings.FollowerDistanceThreshold()
 
 	// Get coordinates for the follower's system
-	followerCoords, err := GetSystemCoords(system)
+	followerCoords, err := GetSystemCoords(ctx, system)
 	if err != nil || followerCoords == nil {
 		core.LogTraceF("EDDN: External carrier %s - cannot get coords for %s", followerStationId, system)
 		return // Unknown system, skip
@@ -434,7 +472,7 @@ func checkAndRecordFollower(followerStationId, system string, eventTime int64) {
 			continue
 		}
 
-		ourCoords, err := GetSystemCoords(*state.CurrentSystem)
+		ourCoords, err := GetSystemCoords(ctx, *state.CurrentSystem)
 		if err != nil || ourCoords == nil {
 			continue
 		}
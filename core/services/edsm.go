@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"math"
@@ -34,7 +35,7 @@ var (
 )
 
 // GetSystemCoords fetches coordinates for a system from EDSM (with caching)
-func GetSystemCoords(systemName string) (*SystemCoords, error) {
+func GetSystemCoords(ctx context.Context, systemName string) (*SystemCoords, error) {
 	if systemName == "" {
 		return nil, nil
 	}
@@ -51,14 +52,18 @@ func GetSystemCoords(systemName string) (*SystemCoords, error) {
 
 	// Fetch from EDSM
 	u, err := core.MakeURL("https://www.edsm.net/api-v1/system", []core.URLParams{
-		{"systemName", systemName},
-		{"coords", "1"},
+		{Key: "systemName", Val: systemName},
+		{Key: "coords", Val: "1"},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := edsmClient.Get(u.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := edsmClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -117,13 +122,13 @@ func CalculateDistance(c1, c2 *SystemCoords) float64 {
 }
 
 // GetDistanceBetweenSystems calculates distance between two systems by name
-func GetDistanceBetweenSystems(system1, system2 string) (float64, error) {
-	coords1, err := GetSystemCoords(system1)
+func GetDistanceBetweenSystems(ctx context.Context, system1, system2 string) (float64, error) {
+	coords1, err := GetSystemCoords(ctx, system1)
 	if err != nil {
 		return -1, err
 	}
 
-	coords2, err := GetSystemCoords(system2)
+	coords2, err := GetSystemCoords(ctx, system2)
 	if err != nil {
 		return -1, err
 	}
@@ -19,9 +19,10 @@ func formatCarrierLink(stationId string) string {
 	return fmt.Sprintf("[%s](<"+inaraSearchURL+">)", stationId, stationId)
 }
 
-// GetRecentFollowers returns formatted list of followers for display
-func GetRecentFollowers(sortBy string) []database.CarrierFollower {
-	return database.FetchRecentFollowers(defaultFollowerDays, defaultMinSightings, sortBy)
+// GetRecentFollowers returns a page of the followers for display, and the number of followers in all pages
+func GetRecentFollowers(sortBy string, offset, limit int) ([]database.CarrierFollower, int) {
+	followers := database.FetchRecentFollowers(defaultFollowerDays, defaultMinSightings, sortBy, offset, limit)
+	return followers, database.CountRecentFollowers(defaultFollowerDays, defaultMinSightings)
 }
 
 // GetFollowerInfo returns detailed info for a specific follower
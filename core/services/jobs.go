@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// Job types of the bot's own housekeeping
+const (
+	UsageRetentionJob          = "usage-retention"
+	ComponentStateRetentionJob = "component-state-retention"
+)
+
+func init() {
+	RegisterJobType(UsageRetentionJob, JobType{
+		Run:      pruneCommandUsage,
+		Describe: func(*database.ScheduledJob) string { return "Remove expired command usage records" },
+		Schedule: "@daily",
+		Missed:   database.MissedCatchUp,
+	})
+	RegisterJobType(ComponentStateRetentionJob, JobType{
+		Run:      pruneComponentState,
+		Describe: func(*database.ScheduledJob) string { return "Remove the expired state of buttons and select menus" },
+		Schedule: "@daily",
+		Missed:   database.MissedCatchUp,
+	})
+}
+
+func pruneCommandUsage(context.Context, *database.ScheduledJob) error {
+	days := core.Settings.UsageRetentionDays()
+	removed := database.PruneCommandUsage(time.Now().AddDate(0, 0, -days))
+	if removed > 0 {
+		core.LogInfoF("Removed %d command usage records older than %d days", removed, days)
+	}
+	return nil
+}
+
+func pruneComponentState(context.Context, *database.ScheduledJob) error {
+	removed := database.PruneComponentState(time.Now())
+	if removed > 0 {
+		core.LogInfoF("Removed %d expired component states", removed)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ReminderJob is the job type of personal reminders
+const ReminderJob = "reminder"
+
+const (
+	maxRemindersPerUser = 25
+	maxReminderAhead    = 366 * 24 * time.Hour
+)
+
+// Reminder is the payload of a reminder job
+type Reminder struct {
+	UserId    string `json:"user"`
+	ChannelId string `json:"channel,omitempty"` // Channel to remind the user in, a DM if empty
+	Text      string `json:"text,omitempty"`
+	Carrier   string `json:"carrier,omitempty"` // Station ID of the carrier whose departure the reminder is relative to
+	Before    int64  `json:"before,omitempty"`  // Seconds before the departure of the carrier
+}
+
+// ReminderTime is when a reminder is due, either at a fixed time or relative to the departure of a carrier
+type ReminderTime struct {
+	At      time.Time
+	Carrier string        // Station ID, if relative to a departure
+	Before  time.Duration // How long before the departure
+}
+
+var (
+	durationPartPattern    = regexp.MustCompile(`^(\d+)\s*([a-z]+)`)
+	beforeDeparturePattern = regexp.MustCompile(`(?i)^(.+?)\s+before\s+(.+?)(?:\s+(?:departs|leaves|jumps|departure))?$`)
+	atDeparturePattern     = regexp.MustCompile(`(?i)^when\s+(.+?)\s+(?:departs|leaves|jumps)$`)
+	reminderSeparator      = regexp.MustCompile(`(?i)\s+to\s+`)
+)
+
+var durationUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+func init() {
+	RegisterJobType(ReminderJob, JobType{
+		Run:      runReminder,
+		Describe: describeReminder,
+	})
+}
+
+// ParseReminderDuration parses a duration like "2h", "1h30m", "90 minutes" or "1 day and 2 hours"
+func ParseReminderDuration(text string) (time.Duration, error) {
+	rest := strings.ToLower(strings.TrimSpace(text))
+	if rest == "" {
+		return 0, errors.New("missing duration")
+	}
+	var total time.Duration
+	for rest != "" {
+		matches := durationPartPattern.FindStringSubmatch(rest)
+		if matches == nil {
+			return 0, fmt.Errorf("invalid duration %q", text)
+		}
+		unit, ok := durationUnits[matches[2]]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in duration %q", matches[2], text)
+		}
+		n, _ := strconv.Atoi(matches[1])
+		total += time.Duration(n) * unit
+		rest = strings.TrimSpace(rest[len(matches[0]):])
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(rest, ","), "and "))
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("duration %q must be longer than zero", text)
+	}
+	return total, nil
+}
+
+// ParseReminderTime parses when a reminder is due:
+//   - a duration: "in 2h", "30 minutes"
+//   - a time in the formats of ParseJumpTime: "at 20th January, 18:30 UTC"
+//   - relative to a carrier departure: "30m before W7H-6DZ departs", "when Odysseus departs"
+func ParseReminderTime(text string, now time.Time) (ReminderTime, error) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+
+	if matches := atDeparturePattern.FindStringSubmatch(text); matches != nil {
+		return departureReminderTime(matches[1], 0, now)
+	}
+	if matches := beforeDeparturePattern.FindStringSubmatch(text); matches != nil {
+		before, err := ParseReminderDuration(matches[1])
+		if err != nil {
+			return ReminderTime{}, err
+		}
+		return departureReminderTime(matches[2], before, now)
+	}
+	if d, err := ParseReminderDuration(strings.TrimPrefix(lower, "in ")); err == nil {
+		return ReminderTime{At: now.Add(d)}, nil
+	}
+	ts, err := ParseJumpTime(strings.TrimPrefix(lower, "at "))
+	if err != nil {
+		return ReminderTime{}, fmt.Errorf("can't tell when %q is", text)
+	}
+	return ReminderTime{At: time.Unix(ts, 0)}, nil
+}
+
+func departureReminderTime(carrierText string, before time.Duration, now time.Time) (ReminderTime, error) {
+	carrier := findCarrier(carrierText)
+	if carrier == nil {
+		return ReminderTime{}, fmt.Errorf("unknown carrier %q", carrierText)
+	}
+	departure, ok := carrierDeparture(carrier.StationId, now)
+	if !ok {
+		return ReminderTime{}, fmt.Errorf("%s has no departure scheduled", getCarrierDisplayName(carrier.StationId))
+	}
+	return ReminderTime{At: departure.Add(-before), Carrier: carrier.StationId, Before: before}, nil
+}
+
+// ParseReminder splits a reminder like "in 2h to sell cargo" into when it's due and what it's about.
+// The text is separated by "to", which may only be left out after a duration or a carrier departure.
+func ParseReminder(input string, now time.Time) (ReminderTime, string, error) {
+	input = strings.TrimSpace(input)
+	if loc := reminderSeparator.FindStringIndex(input); loc != nil {
+		if when, err := ParseReminderTime(input[:loc[0]], now); err == nil {
+			return when, strings.TrimSpace(input[loc[1]:]), nil
+		}
+	}
+
+	// Take the most words that make a duration or departure, absolute times need the separator
+	words := strings.Fields(input)
+	for n := len(words); n > 0; n-- {
+		whenText := strings.Join(words[:n], " ")
+		if _, err := ParseJumpTime(strings.TrimPrefix(strings.ToLower(whenText), "at ")); err == nil {
+			continue
+		}
+		if when, err := ParseReminderTime(whenText, now); err == nil {
+			return when, strings.Join(words[n:], " "), nil
+		}
+	}
+	if _, err := ParseReminderTime(input, now); err != nil {
+		return ReminderTime{}, "", err
+	}
+	return ReminderTime{}, "", errors.New(`put "to" between the time and what to remind you of`)
+}
+
+// findCarrier finds one of our carriers by station ID, name, or a unique part of its name
+func findCarrier(text string) *core.CarrierConfig {
+	text = strings.TrimSpace(text)
+	if c := core.Settings.GetCarrierByStationId(strings.ToUpper(text)); c != nil {
+		return c
+	}
+	if c := findCarrierByName(text); c != nil {
+		return c
+	}
+	var found *core.CarrierConfig
+	lower := strings.ToLower(text)
+	for _, c := range core.Settings.Carriers() {
+		if strings.Contains(strings.ToLower(c.Name), lower) {
+			if found != nil {
+				return nil
+			}
+			found = &c
+		}
+	}
+	return found
+}
+
+// carrierDeparture returns the next departure of a carrier, from the announced jump time or EDDN
+func carrierDeparture(stationId string, now time.Time) (time.Time, bool) {
+	state := database.FetchCarrierState(stationId)
+	if state == nil {
+		return time.Time{}, false
+	}
+	for _, departure := range []*int64{state.JumpTime, state.PendingJumpTime} {
+		if departure != nil && *departure > now.Unix() {
+			return time.Unix(*departure, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// CreateReminder schedules a reminder and returns its ID
+func CreateReminder(reminder Reminder, when ReminderTime, guildId string, now time.Time) (int64, error) {
+	if !when.At.After(now) {
+		return 0, errors.New("that time is in the past")
+	}
+	if when.At.Sub(now) > maxReminderAhead {
+		return 0, errors.New("reminders can be at most a year ahead")
+	}
+	if len(UserReminders(reminder.UserId)) >= maxRemindersPerUser {
+		return 0, fmt.Errorf("you already have %d reminders", maxRemindersPerUser)
+	}
+	reminder.Carrier = when.Carrier
+	reminder.Before = int64(when.Before / time.Second)
+	payload, err := json.Marshal(reminder)
+	if err != nil {
+		return 0, err
+	}
+	return ScheduleJob(database.ScheduledJob{
+		Type:      ReminderJob,
+		Payload:   string(payload),
+		RunAt:     when.At.Unix(),
+		Missed:    database.MissedCatchUp,
+		GuildId:   guildId,
+		CreatedBy: reminder.UserId,
+	})
+}
+
+// DecodeReminder returns the reminder of a reminder job
+func DecodeReminder(job *database.ScheduledJob) (*Reminder, error) {
+	var reminder Reminder
+	if err := json.Unmarshal([]byte(job.Payload), &reminder); err != nil {
+		return nil, fmt.Errorf("invalid reminder: %w", err)
+	}
+	return &reminder, nil
+}
+
+// UserReminders returns the reminders of a user, the next due first
+func UserReminders(userId string) []database.ScheduledJob {
+	var reminders []database.ScheduledJob
+	for _, job := range database.FetchScheduledJobsByType(ReminderJob) {
+		if job.CreatedBy == userId {
+			reminders = append(reminders, job)
+		}
+	}
+	return reminders
+}
+
+// CancelReminder removes a reminder of a user. Returns false if the user has no such reminder.
+func CancelReminder(id int64, userId string) bool {
+	job := database.FetchScheduledJob(id)
+	if job == nil || job.Type != ReminderJob || job.CreatedBy != userId {
+		return false
+	}
+	return CancelJob(id)
+}
+
+// carrierDeparted returns when a carrier last departed, from a jump time that has passed
+func carrierDeparted(stationId string, now time.Time) (time.Time, bool) {
+	state := database.FetchCarrierState(stationId)
+	if state == nil || state.JumpTime == nil || *state.JumpTime > now.Unix() {
+		return time.Time{}, false
+	}
+	return time.Unix(*state.JumpTime, 0), true
+}
+
+// rescheduleCarrierReminders moves the reminders relative to a carrier's departure when the departure changes.
+// If the carrier has departed since a reminder was set, earlier than announced, the reminder is due right away.
+// Reminders for a departure that was cleared stay where they are, and say so when due.
+func rescheduleCarrierReminders(stationId string) {
+	now := time.Now()
+	departure, upcoming := carrierDeparture(stationId, now)
+	departed, hasDeparted := carrierDeparted(stationId, now)
+	if !upcoming && !hasDeparted {
+		return
+	}
+	for _, job := range database.FetchScheduledJobsByType(ReminderJob) {
+		reminder, err := DecodeReminder(&job)
+		if err != nil || reminder.Carrier != stationId || job.RunAt <= now.Unix() {
+			continue
+		}
+		var runAt time.Time
+		switch {
+		case upcoming:
+			runAt = departure.Add(-time.Duration(reminder.Before) * time.Second)
+		case departed.Unix() >= job.CreatedAt:
+			runAt = now
+		default:
+			continue
+		}
+		if runAt.Before(now) {
+			runAt = now
+		}
+		if runAt.Unix() != job.RunAt {
+			core.LogDebugF("Moving reminder %d to the new departure of %s", job.Id, stationId)
+			database.RescheduleJob(job.Id, runAt, nil, "")
+		}
+	}
+	select {
+	case schedulerWake <- struct{}{}:
+	default:
+	}
+}
+
+// DescribeReminder returns what a reminder is about, for lists
+func DescribeReminder(reminder *Reminder) string {
+	text := reminder.Text
+	if text == "" {
+		text = "(no text)"
+	}
+	if reminder.Carrier != "" {
+		before := time.Duration(reminder.Before) * time.Second
+		if before > 0 {
+			text += fmt.Sprintf(" (%s before %s departs)", before, getCarrierDisplayName(reminder.Carrier))
+		} else {
+			text += fmt.Sprintf(" (when %s departs)", getCarrierDisplayName(reminder.Carrier))
+		}
+	}
+	return text
+}
+
+func describeReminder(job *database.ScheduledJob) string {
+	reminder, err := DecodeReminder(job)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("<@%s>: %s", reminder.UserId, DescribeReminder(reminder))
+}
+
+func runReminder(_ context.Context, job *database.ScheduledJob) error {
+	reminder, err := DecodeReminder(job)
+	if err != nil {
+		return err
+	}
+	s := session()
+	if s == nil {
+		return errors.New("not connected to Discord")
+	}
+
+	now := time.Now()
+	var lines []string
+	if reminder.Text != "" {
+		lines = append(lines, "**Reminder:** "+reminder.Text)
+	} else {
+		lines = append(lines, "**Reminder!**")
+	}
+	if reminder.Carrier != "" {
+		if departure, ok := carrierDeparture(reminder.Carrier, now); ok {
+			lines = append(lines, fmt.Sprintf("%s departs <t:%d:R>.", getCarrierDisplayName(reminder.Carrier), departure.Unix()))
+		} else if departed, ok := carrierDeparted(reminder.Carrier, now); ok && departed.Unix() >= job.CreatedAt {
+			lines = append(lines, fmt.Sprintf("%s departed <t:%d:R>.", getCarrierDisplayName(reminder.Carrier), departed.Unix()))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s no longer has a departure scheduled.", getCarrierDisplayName(reminder.Carrier)))
+		}
+	}
+	if now.Sub(time.Unix(job.RunAt, 0)) > missedGrace {
+		lines = append(lines, fmt.Sprintf("_This reminder was due <t:%d:R>._", job.RunAt))
+	}
+	content := strings.Join(lines, "\n")
+
+	if reminder.ChannelId != "" {
+		_, err = s.ChannelMessageSendComplex(reminder.ChannelId, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("<@%s> %s", reminder.UserId, content),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{reminder.UserId}},
+		})
+		if err == nil {
+			return nil
+		}
+		core.LogWarnF("Failed to send reminder %d in channel %s, sending a DM: %s", job.Id, reminder.ChannelId, err)
+	}
+	channel, err := s.UserChannelCreate(reminder.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to open DM channel with %s: %w", reminder.UserId, err)
+	}
+	if _, err = s.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{Content: content}); err != nil {
+		return fmt.Errorf("failed to DM %s: %w", reminder.UserId, err)
+	}
+	return nil
+}
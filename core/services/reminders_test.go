@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"GoBot/core/database"
+)
+
+func TestParseReminderDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"2h", 2 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"90 minutes", 90 * time.Minute},
+		{"1 day and 2 hours", 26 * time.Hour},
+		{"1d, 30 min", 24*time.Hour + 30*time.Minute},
+		{"2 Weeks", 14 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseReminderDuration(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReminderDuration(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "2", "soon", "2 fortnights", "0m", "2h sell"} {
+		if _, err := ParseReminderDuration(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestParseReminder(t *testing.T) {
+	now := time.Date(2026, time.January, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input    string
+		wantAt   time.Time
+		wantText string
+	}{
+		{"in 2h to sell cargo", now.Add(2 * time.Hour), "sell cargo"},
+		{"2h sell cargo", now.Add(2 * time.Hour), "sell cargo"},
+		{"in 1h 30m go to the station", now.Add(90 * time.Minute), "go to the station"},
+		{"30 minutes", now.Add(30 * time.Minute), ""},
+		{"20th January, 18:30 UTC to join the expedition", time.Date(2026, time.January, 20, 18, 30, 0, 0, time.UTC), "join the expedition"},
+		{"at 20th January 2027, 1830 to celebrate", time.Date(2027, time.January, 20, 18, 30, 0, 0, time.UTC), "celebrate"},
+	}
+	for _, tt := range tests {
+		when, text, err := ParseReminder(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseReminder(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if !when.At.Equal(tt.wantAt) || text != tt.wantText || when.Carrier != "" {
+			t.Errorf("ParseReminder(%q) = %v, %q, want %v, %q", tt.input, when.At, text, tt.wantAt, tt.wantText)
+		}
+	}
+
+	for _, input := range []string{"sell cargo", "20th January 18:30 sell cargo", "20th January 18:30", "later to sell cargo"} {
+		if _, _, err := ParseReminder(input, now); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestFindCarrier(t *testing.T) {
+	setupTestCarriers()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"ABC-123", "ABC-123"},
+		{"abc-123", "ABC-123"},
+		{"DSEV Odysseus", "ABC-123"},
+		{"odysseus", "ABC-123"},
+		{"DSEV", ""}, // Ambiguous
+		{"Unknown", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if c := findCarrier(tt.input); c != nil {
+			got = c.StationId
+		}
+		if got != tt.want {
+			t.Errorf("findCarrier(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRescheduleCarrierReminders(t *testing.T) {
+	setupTestDB(t)
+	setupTestCarriers()
+	now := time.Now()
+	departure := now.Add(2 * time.Hour).Unix()
+	if err := SetCarrierJumpTime("TBQ-6VX", departure); err != nil {
+		t.Fatalf("Failed to set the jump time: %s", err)
+	}
+	when, err := ParseReminderTime("30m before TBQ-6VX departs", now)
+	if err != nil {
+		t.Fatalf("Failed to parse the reminder time: %s", err)
+	}
+	id, err := CreateReminder(Reminder{UserId: "1"}, when, "", now)
+	if err != nil {
+		t.Fatalf("Failed to create reminder: %s", err)
+	}
+
+	pending := now.Add(3 * time.Hour).Unix()
+	database.UpdateCarrierPendingJump("TBQ-6VX", nil, &pending)
+	if err := ClearCarrierField("TBQ-6VX", "jump"); err != nil {
+		t.Fatalf("Failed to clear the jump time: %s", err)
+	}
+	if job := database.FetchScheduledJob(id); job.RunAt != pending-30*60 {
+		t.Errorf("Expected the reminder to follow the pending jump when the jump time is cleared, got %s",
+			time.Unix(job.RunAt, 0))
+	}
+
+	// Departing early, as reported by EDDN
+	database.ClearCarrierPendingJump("TBQ-6VX")
+	jumped := time.Now().Unix()
+	database.UpdateCarrierJumpTime("TBQ-6VX", &jumped)
+	rescheduleCarrierReminders("TBQ-6VX")
+	if job := database.FetchScheduledJob(id); job.RunAt > time.Now().Unix() {
+		t.Errorf("Expected the reminder to be due once the carrier departed, got %s", time.Unix(job.RunAt, 0))
+	}
+}
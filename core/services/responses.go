@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"GoBot/core"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord limits on what a reply can hold
+const (
+	maxResponseMessages = 5 // Messages sent after the first, a custom command shouldn't flood the channel
+	maxResponseFiles    = 10
+	maxMessageLength    = 2000
+	maxEmbedTitle       = 256
+	maxEmbedDescription = 4096
+	maxEmbedFields      = 25
+	maxEmbedFieldName   = 256
+	maxEmbedFieldValue  = 1024
+	maxEmbedFooter      = 2048
+	maxEmbedTotalLength = 6000
+)
+
+// commandFileDirectory is where files sent by custom commands are kept, under the resource directory
+const commandFileDirectory = "commands"
+
+// CommandResponse is the rich part of the reply to a custom command: an embed and files sent with its text, and
+// more messages sent after it, in order. The texts are templates. It's stored with the command as JSON.
+type CommandResponse struct {
+	Embed    *ResponseEmbed `json:"embed,omitempty"`
+	Files    []string       `json:"files,omitempty"`    // Names of files in the command file directory
+	Messages []string       `json:"messages,omitempty"` // Texts of the messages sent after the first
+}
+
+// ResponseEmbed is the embed of a custom command. The title, description, footer and fields are templates.
+type ResponseEmbed struct {
+	Title       string       `json:"title,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Image       string       `json:"image,omitempty"`
+	Thumbnail   string       `json:"thumbnail,omitempty"`
+	Footer      string       `json:"footer,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+}
+
+// EmbedField is a titled section of an embed, shown side by side with the other inline fields
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// ParseCommandResponse parses the stored response of a command. A command without one returns nil.
+func ParseCommandResponse(data *string) (*CommandResponse, error) {
+	if data == nil || *data == "" {
+		return nil, nil
+	}
+	var response CommandResponse
+	if err := json.Unmarshal([]byte(*data), &response); err != nil {
+		return nil, fmt.Errorf("invalid command response: %w", err)
+	}
+	return &response, nil
+}
+
+// Encode returns the response as stored with the command, or nil if it has nothing in it
+func (r *CommandResponse) Encode() *string {
+	if r.IsEmpty() {
+		return nil
+	}
+	data, _ := json.Marshal(r)
+	encoded := string(data)
+	return &encoded
+}
+
+// IsEmpty returns true if the response has no embed, files or messages
+func (r *CommandResponse) IsEmpty() bool {
+	return r == nil || (r.Embed == nil && len(r.Files) == 0 && len(r.Messages) == 0)
+}
+
+// Validate checks that the response can be sent, returning the first problem
+func (r *CommandResponse) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Embed != nil {
+		if err := r.Embed.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.Files) > maxResponseFiles {
+		return fmt.Errorf("at most %d files can be attached", maxResponseFiles)
+	}
+	for _, name := range r.Files {
+		if !ValidCommandFileName(name) {
+			return fmt.Errorf("invalid file name %q", name)
+		}
+	}
+	if len(r.Messages) > maxResponseMessages {
+		return fmt.Errorf("at most %d more messages can be sent", maxResponseMessages)
+	}
+	for i, message := range r.Messages {
+		if strings.TrimSpace(message) == "" || len([]rune(message)) > maxMessageLength {
+			return fmt.Errorf("message %d must have 1 to %d characters", i+2, maxMessageLength)
+		}
+		if _, err := ParseTemplate(message); err != nil {
+			return fmt.Errorf("message %d: %w", i+2, err)
+		}
+	}
+	return nil
+}
+
+// embedSpecKeys are the line prefixes of the embed spec format, see ParseEmbedSpec
+var embedSpecKeys = []string{"title", "url", "color", "colour", "image", "thumbnail", "footer", "field", "inline"}
+
+// ParseEmbedSpec parses an embed written as JSON, or one setting per line. Lines that don't start with a setting
+// make up the description:
+//
+//	title: Carrier FAQ
+//	url: https://example.com/faq
+//	color: #ff8800
+//	image: https://example.com/carrier.png
+//	thumbnail: https://example.com/logo.png
+//	footer: Ask in #help if your question isn't here
+//	field: Name | Value
+//	inline: Name | Value
+func ParseEmbedSpec(spec string) (*ResponseEmbed, error) {
+	spec = strings.TrimSpace(spec)
+	embed := &ResponseEmbed{}
+	if strings.HasPrefix(spec, "{") && json.Valid([]byte(spec)) {
+		if err := json.Unmarshal([]byte(spec), embed); err != nil {
+			return nil, fmt.Errorf("invalid embed JSON: %w", err)
+		}
+		return embed, embed.Validate()
+	}
+
+	var description []string
+	for _, line := range strings.Split(spec, "\n") {
+		key, value, found := embedSpecLine(line)
+		if !found {
+			description = append(description, line)
+			continue
+		}
+		switch key {
+		case "title":
+			embed.Title = value
+		case "url":
+			embed.URL = value
+		case "color", "colour":
+			color, err := parseColor(value)
+			if err != nil {
+				return nil, err
+			}
+			embed.Color = color
+		case "image":
+			embed.Image = value
+		case "thumbnail":
+			embed.Thumbnail = value
+		case "footer":
+			embed.Footer = value
+		case "field", "inline":
+			name, text, found := strings.Cut(value, "|")
+			if !found {
+				return nil, fmt.Errorf("fields are written as `%s: Name | Value`, got %q", key, abbreviate(value))
+			}
+			embed.Fields = append(embed.Fields, EmbedField{Name: strings.TrimSpace(name), Value: strings.TrimSpace(text),
+				Inline: key == "inline"})
+		}
+	}
+	embed.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return embed, embed.Validate()
+}
+
+// embedSpecLine splits a "key: value" line of the embed spec format
+func embedSpecLine(line string) (key, value string, found bool) {
+	key, value, found = strings.Cut(line, ":")
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, specKey := range embedSpecKeys {
+		if found && key == specKey {
+			return key, strings.TrimSpace(value), true
+		}
+	}
+	return "", "", false
+}
+
+// parseColor parses a hex colour such as #ff8800
+func parseColor(value string) (int, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "#"), "0x")
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return 0, fmt.Errorf("colours are written as #rrggbb, got %q", abbreviate(value))
+	}
+	return int(color), nil
+}
+
+// FormatEmbedSpec writes an embed in the format ParseEmbedSpec reads, or as JSON if it has text the format can't hold,
+// such as description lines that would be read as settings
+func FormatEmbedSpec(e *ResponseEmbed) string {
+	if !fitsEmbedSpec(e) {
+		data, _ := json.MarshalIndent(e, "", "  ")
+		return string(data)
+	}
+	var lines []string
+	setting := func(key, value string) {
+		if value != "" {
+			lines = append(lines, key+": "+value)
+		}
+	}
+	setting("title", e.Title)
+	setting("url", e.URL)
+	if e.Color != 0 {
+		setting("color", fmt.Sprintf("#%06x", e.Color))
+	}
+	setting("image", e.Image)
+	setting("thumbnail", e.Thumbnail)
+	setting("footer", e.Footer)
+	if e.Description != "" {
+		lines = append(lines, e.Description)
+	}
+	for _, field := range e.Fields {
+		key := "field"
+		if field.Inline {
+			key = "inline"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s | %s", key, field.Name, field.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitsEmbedSpec checks that an embed can be written in the spec format and parsed back the same
+func fitsEmbedSpec(e *ResponseEmbed) bool {
+	for _, line := range strings.Split(e.Description, "\n") {
+		if _, _, found := embedSpecLine(line); found {
+			return false
+		}
+	}
+	singleLines := []string{e.Title, e.URL, e.Image, e.Thumbnail, e.Footer}
+	for _, field := range e.Fields {
+		if strings.Contains(field.Name, "|") {
+			return false
+		}
+		singleLines = append(singleLines, field.Name, field.Value)
+	}
+	for _, text := range singleLines {
+		if strings.Contains(text, "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the embed against the Discord limits, and that its texts are valid templates
+func (e *ResponseEmbed) Validate() error {
+	if e.Title == "" && e.Description == "" && len(e.Fields) == 0 && e.Image == "" {
+		return errors.New("the embed needs a title, description, field or image")
+	}
+	if len([]rune(e.Title)) > maxEmbedTitle {
+		return fmt.Errorf("the title can have at most %d characters", maxEmbedTitle)
+	}
+	if len([]rune(e.Description)) > maxEmbedDescription {
+		return fmt.Errorf("the description can have at most %d characters", maxEmbedDescription)
+	}
+	if len([]rune(e.Footer)) > maxEmbedFooter {
+		return fmt.Errorf("the footer can have at most %d characters", maxEmbedFooter)
+	}
+	if len(e.Fields) > maxEmbedFields {
+		return fmt.Errorf("the embed can have at most %d fields", maxEmbedFields)
+	}
+	total := len([]rune(e.Title)) + len([]rune(e.Description)) + len([]rune(e.Footer))
+	texts := []string{e.Title, e.Description, e.Footer}
+	for _, field := range e.Fields {
+		if field.Name == "" || field.Value == "" {
+			return errors.New("fields need both a name and a value")
+		}
+		if len([]rune(field.Name)) > maxEmbedFieldName || len([]rune(field.Value)) > maxEmbedFieldValue {
+			return fmt.Errorf("field %s is too long, names can have %d characters and values %d", abbreviate(field.Name),
+				maxEmbedFieldName, maxEmbedFieldValue)
+		}
+		total += len([]rune(field.Name)) + len([]rune(field.Value))
+		texts = append(texts, field.Name, field.Value)
+	}
+	if total > maxEmbedTotalLength {
+		return fmt.Errorf("the embed can have at most %d characters in all", maxEmbedTotalLength)
+	}
+	for _, link := range []string{e.URL, e.Image, e.Thumbnail} {
+		if u, err := url.Parse(link); link != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+			return fmt.Errorf("%q isn't a http or https link", abbreviate(link))
+		}
+	}
+	if e.Color < 0 || e.Color > 0xffffff {
+		return errors.New("the colour must be between #000000 and #ffffff")
+	}
+	for _, text := range texts {
+		if _, err := ParseTemplate(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render builds the Discord embed, filling in the texts with render
+func (e *ResponseEmbed) Render(render func(text string) string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{Title: render(e.Title), URL: e.URL, Description: render(e.Description), Color: e.Color}
+	if e.Image != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: e.Image}
+	}
+	if e.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: e.Thumbnail}
+	}
+	if e.Footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: render(e.Footer)}
+	}
+	for _, field := range e.Fields {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: render(field.Name), Value: render(field.Value),
+			Inline: field.Inline})
+	}
+	return embed
+}
+
+// ValidCommandFileName checks that a file name stays inside the command file directory
+func ValidCommandFileName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
+
+// CommandFilePath returns where a file attached to custom commands is kept
+func CommandFilePath(name string) string {
+	return filepath.Join(core.Settings.ResourceDirectory(), commandFileDirectory, name)
+}
+
+// SaveCommandFile stores a file for custom commands and returns the name it was stored as. Files are shared by all
+// commands and kept after they're detached, as revisions may still use them, so a different file with the same
+// name is stored as name-2.ext and so on.
+func SaveCommandFile(name string, data []byte) (string, error) {
+	if !ValidCommandFileName(name) {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(CommandFilePath(name)), 0755); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		existing, err := os.ReadFile(CommandFilePath(candidate))
+		switch {
+		case os.IsNotExist(err):
+			return candidate, os.WriteFile(CommandFilePath(candidate), data, 0644)
+		case err != nil:
+			return "", err
+		case bytes.Equal(existing, data):
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEmbedSpec(t *testing.T) {
+	spec := "title: Carrier FAQ\nurl: https://example.com/faq\ncolor: #FF8800\nAnswers for {user}.\n\nAsk in {channel}.\n" +
+		"thumbnail: https://example.com/logo.png\nfield: Docking | Set docking to all\ninline: Fuel | Tritium"
+	embed, err := ParseEmbedSpec(spec)
+	if err != nil {
+		t.Fatalf("Failed to parse the embed: %s", err)
+	}
+	if embed.Title != "Carrier FAQ" || embed.URL != "https://example.com/faq" || embed.Color != 0xff8800 ||
+		embed.Thumbnail != "https://example.com/logo.png" || embed.Description != "Answers for {user}.\n\nAsk in {channel}." {
+		t.Errorf("Unexpected embed %+v", embed)
+	}
+	if len(embed.Fields) != 2 || embed.Fields[0] != (EmbedField{Name: "Docking", Value: "Set docking to all"}) || !embed.Fields[1].Inline {
+		t.Errorf("Unexpected fields %+v", embed.Fields)
+	}
+
+	// Written back, it parses to the same embed
+	again, err := ParseEmbedSpec(FormatEmbedSpec(embed))
+	if err != nil || FormatEmbedSpec(again) != FormatEmbedSpec(embed) || again.Description != embed.Description {
+		t.Errorf("Expected the formatted embed to parse the same, got %+v, %v", again, err)
+	}
+
+	json, err := ParseEmbedSpec(`{"title": "FAQ", "description": "title: in the description"}`)
+	if err != nil || json.Description != "title: in the description" {
+		t.Fatalf("Failed to parse the embed JSON: %+v, %v", json, err)
+	}
+	if formatted := FormatEmbedSpec(json); !strings.HasPrefix(formatted, "{") {
+		t.Errorf("Expected a description that reads as a setting to be formatted as JSON, got %s", formatted)
+	}
+	if embed, err := ParseEmbedSpec("{user}, read the FAQ"); err != nil || embed.Description != "{user}, read the FAQ" {
+		t.Errorf("Expected a description starting with a placeholder, got %+v, %v", embed, err)
+	}
+}
+
+func TestParseEmbedSpec_Invalid(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"url: https://example.com", "needs a title"},
+		{"title: FAQ\ncolor: orange", "colours are written as #rrggbb"},
+		{"title: FAQ\nfield: No separator", "Name | Value"},
+		{"title: FAQ\nfield: Empty |", "both a name and a value"},
+		{"title: FAQ\nimage: ftp://example.com/a.png", "isn't a http or https link"},
+		{"title: " + strings.Repeat("a", maxEmbedTitle+1), "title can have at most"},
+		{"title: {nope}", "unknown placeholder"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseEmbedSpec(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseEmbedSpec(%q) = %v, want an error containing %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestCommandResponse(t *testing.T) {
+	var empty *CommandResponse
+	if !empty.IsEmpty() || empty.Encode() != nil || empty.Validate() != nil {
+		t.Error("Expected a missing response to be empty and valid")
+	}
+	if (&CommandResponse{}).Encode() != nil {
+		t.Error("Expected an empty response not to be stored")
+	}
+
+	response := &CommandResponse{Embed: &ResponseEmbed{Title: "FAQ"}, Files: []string{"faq.png"}, Messages: []string{"See also {channel}"}}
+	parsed, err := ParseCommandResponse(response.Encode())
+	if err != nil || parsed.Embed.Title != "FAQ" || parsed.Files[0] != "faq.png" || parsed.Messages[0] != "See also {channel}" {
+		t.Errorf("Expected the response to be stored and parsed back, got %+v, %v", parsed, err)
+	}
+	if response, err := ParseCommandResponse(nil); response != nil || err != nil {
+		t.Errorf("Expected no response for a command without one, got %+v, %v", response, err)
+	}
+
+	for _, invalid := range []*CommandResponse{
+		{Files: []string{"../config.json"}},
+		{Messages: []string{"   "}},
+		{Messages: []string{"{unclosed"}},
+		{Messages: make([]string, maxResponseMessages+1)},
+	} {
+		if invalid.Validate() == nil {
+			t.Errorf("Expected %+v to be invalid", invalid)
+		}
+	}
+}
+
+func TestValidCommandFileName(t *testing.T) {
+	for name, valid := range map[string]bool{"faq.png": true, "carrier map.jpg": true, "": false, ".hidden": false,
+		"../faq.png": false, "dir/faq.png": false, `dir\faq.png`: false, "..": false} {
+		if ValidCommandFileName(name) != valid {
+			t.Errorf("ValidCommandFileName(%q) = %t, want %t", name, !valid, valid)
+		}
+	}
+}
+
+func TestFormatEmbedSpec_JSON(t *testing.T) {
+	for _, embed := range []*ResponseEmbed{
+		{Title: "FAQ", Fields: []EmbedField{{Name: "A | B", Value: "C"}}},
+		{Title: "FAQ", Fields: []EmbedField{{Name: "Steps", Value: "One\nTwo"}}},
+	} {
+		formatted := FormatEmbedSpec(embed)
+		parsed, err := ParseEmbedSpec(formatted)
+		if !strings.HasPrefix(formatted, "{") || err != nil || parsed.Fields[0] != embed.Fields[0] {
+			t.Errorf("Expected %+v to be written as JSON and parsed back, got %s", embed.Fields[0], formatted)
+		}
+	}
+}
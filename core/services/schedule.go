@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the fields minute, hour, day of month, month and day of week,
+// evaluated in UTC. Fields accept *, numbers, ranges, lists and steps, e.g. "*/15 8-18 * * mon-fri".
+// The shortcuts @hourly, @daily, @weekly and @monthly are also accepted.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // Bit sets of the allowed values
+	domRestricted, dowRestricted  bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values, starting at min
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// maxScheduleSearch limits how far ahead Next looks for schedules that never match, like February 30th
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// ParseSchedule parses a cron expression
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	fields := strings.Fields(expr)
+	if shortcut, ok := cronShortcuts[strings.ToLower(expr)]; ok {
+		fields = strings.Fields(shortcut)
+	}
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day month weekday) or a shortcut like @daily, got %q", expr)
+	}
+
+	s := &Schedule{expr: expr}
+	sets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, field := range cronFields {
+		set, err := field.parse(strings.ToLower(fields[i]))
+		if err != nil {
+			return nil, err
+		}
+		*sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domRestricted = fields[2] != "*"
+	s.dowRestricted = fields[4] != "*"
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after the given time that matches the schedule, or the zero time if there is none
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay checks the day of month and day of week. Like cron, a day matches either field when both are restricted.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// parse parses a comma separated list of values, ranges and steps into a bit set
+func (f cronField) parse(text string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(text, ",") {
+		rangeText, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			var err error
+			rangeText = part[:slash]
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, part)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangeText == "*":
+		case strings.Contains(rangeText, "-"):
+			bounds := strings.SplitN(rangeText, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range in %s field: %q", f.name, part)
+			}
+		default:
+			var err error
+			if low, err = f.value(rangeText); err != nil {
+				return 0, err
+			}
+			// A single value with a step, like 5/15, runs from the value to the end of the range
+			if step == 1 {
+				high = low
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if text == name {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s: %q, expected %d-%d", f.name, text, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"GoBot/core/database"
+)
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("Expected %q to be invalid", expr)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// A Wednesday
+	after := time.Date(2026, 10, 14, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 14, 10, 21, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 feb *", time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// Both days restricted: either matches
+		{"0 0 20 * fri", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %s", tt.expr, err)
+			continue
+		}
+		if got := schedule.Next(after); !got.Equal(tt.want) {
+			t.Errorf("Expected %q to run next at %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestScheduleNext_Never(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Expected February 30th never to come, got %s", next)
+	}
+}
+
+func TestPlanRun(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	daily, _ := ParseSchedule("@daily")
+	onTime := now.Add(-30 * time.Second).Unix()
+	missed := now.Add(-3 * time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		job      database.ScheduledJob
+		schedule *Schedule
+		run      bool
+		next     time.Time
+	}{
+		{"one-shot on time", database.ScheduledJob{RunAt: onTime, Missed: database.MissedSkip}, nil, true, time.Time{}},
+		{"one-shot missed, catch up", database.ScheduledJob{RunAt: missed, Missed: database.MissedCatchUp}, nil, true, time.Time{}},
+		{"one-shot missed, skip", database.ScheduledJob{RunAt: missed, Missed: database.MissedSkip}, nil, false, time.Time{}},
+		{"recurring missed, catch up", database.ScheduledJob{RunAt: missed, Schedule: "@daily", Missed: database.MissedCatchUp}, daily, true,
+			time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"recurring missed, skip", database.ScheduledJob{RunAt: missed, Schedule: "@daily", Missed: database.MissedSkip}, daily, false,
+			time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		run, next := planRun(&tt.job, tt.schedule, now)
+		if run != tt.run || !next.Equal(tt.next) {
+			t.Errorf("%s: expected run %t next %s, got %t %s", tt.name, tt.run, tt.next, run, next)
+		}
+	}
+}
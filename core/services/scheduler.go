@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// JobType runs the scheduled jobs of a kind. Services register their job types in init.
+type JobType struct {
+	Run func(ctx context.Context, job *database.ScheduledJob) error
+	// Optional short summary of a job for the job list, the payload is shown if not set
+	Describe func(job *database.ScheduledJob) string
+	// If set, a recurring job of this type is created on this schedule when the scheduler starts, unless one exists
+	Schedule string
+	// What to do with runs of the default recurring job that were missed
+	Missed database.MissedPolicy
+}
+
+const (
+	schedulerPollInterval = time.Minute // Longest the scheduler sleeps, in case the next job changes
+	missedGrace           = 2 * time.Minute
+	unknownJobRetry       = time.Hour
+	// Failed one-shot jobs are retried after a delay that doubles from jobRetryDelay up to jobRetryMaxDelay,
+	// and dropped after maxJobAttempts runs
+	jobRetryDelay    = time.Minute
+	jobRetryMaxDelay = time.Hour
+	maxJobAttempts   = 10
+)
+
+var (
+	jobTypes   = map[string]JobType{}
+	jobTypesMu sync.RWMutex
+
+	runningJobs   = map[int64]bool{}
+	runningJobsMu sync.Mutex
+
+	// schedulerWake wakes the scheduler when a job is added
+	schedulerWake = make(chan struct{}, 1)
+	// stopScheduler stops the scheduler loop, nil when it isn't running
+	stopScheduler context.CancelFunc
+	// schedulerStopped is closed when the scheduler loop has stopped
+	schedulerStopped chan struct{}
+)
+
+// RegisterJobType makes a job type known to the scheduler
+func RegisterJobType(name string, jobType JobType) {
+	jobTypesMu.Lock()
+	defer jobTypesMu.Unlock()
+	jobTypes[name] = jobType
+	core.LogInfoF("Registered job type: %s", name)
+}
+
+func lookupJobType(name string) (JobType, bool) {
+	jobTypesMu.RLock()
+	defer jobTypesMu.RUnlock()
+	jobType, ok := jobTypes[name]
+	return jobType, ok
+}
+
+// ScheduleJob stores a job for the scheduler and returns its ID. Recurring jobs without a run time
+// first run at the next time on their schedule.
+func ScheduleJob(job database.ScheduledJob) (int64, error) {
+	if _, ok := lookupJobType(job.Type); !ok {
+		return 0, fmt.Errorf("unknown job type %s", job.Type)
+	}
+	if job.IsRecurring() {
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			return 0, err
+		}
+		if job.RunAt == 0 {
+			job.RunAt = schedule.Next(time.Now()).Unix()
+		}
+	}
+	id, err := database.CreateScheduledJob(job)
+	if err != nil {
+		return 0, err
+	}
+	select {
+	case schedulerWake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// CancelJob removes a scheduled job. Returns false if it didn't exist. A run in progress isn't interrupted.
+func CancelJob(id int64) bool {
+	return database.DeleteScheduledJob(id)
+}
+
+// DescribeJob returns a short summary of a job
+func DescribeJob(job *database.ScheduledJob) string {
+	if jobType, ok := lookupJobType(job.Type); ok && jobType.Describe != nil {
+		return jobType.Describe(job)
+	}
+	return job.Payload
+}
+
+// StartScheduler creates the default recurring jobs and starts running due jobs in a goroutine. Jobs run with ctx.
+// Set the Discord session first, jobs such as reminders fail without it.
+func StartScheduler(ctx context.Context) {
+	ensureDefaultJobs()
+	loopCtx, cancel := context.WithCancel(ctx)
+	stopScheduler = cancel
+	schedulerStopped = make(chan struct{})
+	go schedulerLoop(ctx, loopCtx)
+}
+
+// StopScheduler stops starting jobs and waits for the scheduler to stop, or for ctx to be done.
+// Jobs already started are still running when it returns.
+func StopScheduler(ctx context.Context) error {
+	if stopScheduler == nil {
+		return nil
+	}
+	stopScheduler()
+	select {
+	case <-schedulerStopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func ensureDefaultJobs() {
+	jobTypesMu.RLock()
+	defaults := map[string]JobType{}
+	for name, jobType := range jobTypes {
+		if jobType.Schedule != "" {
+			defaults[name] = jobType
+		}
+	}
+	jobTypesMu.RUnlock()
+
+	for name, jobType := range defaults {
+		if len(database.FetchScheduledJobsByType(name)) > 0 {
+			continue
+		}
+		if _, err := ScheduleJob(database.ScheduledJob{Type: name, Schedule: jobType.Schedule, Missed: jobType.Missed}); err != nil {
+			core.LogErrorF("Failed to schedule %s job: %s", name, err)
+		}
+	}
+}
+
+func schedulerLoop(ctx, loopCtx context.Context) {
+	defer close(schedulerStopped)
+	for {
+		runDueJobs(ctx, time.Now())
+
+		wait := schedulerPollInterval
+		if next, ok := database.FetchNextJobTime(); ok {
+			wait = min(wait, max(time.Until(next), time.Second))
+		}
+		select {
+		case <-loopCtx.Done():
+			return
+		case <-schedulerWake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runDueJobs starts the jobs that are due and not already running
+func runDueJobs(ctx context.Context, now time.Time) {
+	for _, job := range database.FetchDueJobs(now) {
+		runningJobsMu.Lock()
+		if runningJobs[job.Id] {
+			runningJobsMu.Unlock()
+			continue
+		}
+		runningJobs[job.Id] = true
+		runningJobsMu.Unlock()
+
+		if !work.Go(func() { runJob(ctx, &job, now) }) {
+			// Shutting down
+			finishJob(job.Id)
+			return
+		}
+	}
+}
+
+func finishJob(id int64) {
+	runningJobsMu.Lock()
+	delete(runningJobs, id)
+	runningJobsMu.Unlock()
+}
+
+// planRun decides whether a due job runs, and when it runs next. A zero next time means the job is done.
+func planRun(job *database.ScheduledJob, schedule *Schedule, now time.Time) (run bool, next time.Time) {
+	missed := now.Sub(time.Unix(job.RunAt, 0)) > missedGrace
+	run = !missed || job.Missed != database.MissedSkip
+	if schedule != nil {
+		next = schedule.Next(now)
+	}
+	return run, next
+}
+
+func runJob(ctx context.Context, job *database.ScheduledJob, now time.Time) {
+	defer finishJob(job.Id)
+
+	jobType, ok := lookupJobType(job.Type)
+	if !ok {
+		core.LogWarnF("Job %d has unknown type %s, retrying in %s", job.Id, job.Type, unknownJobRetry)
+		database.RescheduleJob(job.Id, now.Add(unknownJobRetry), &now, "unknown job type")
+		return
+	}
+
+	var schedule *Schedule
+	if job.IsRecurring() {
+		var err error
+		if schedule, err = ParseSchedule(job.Schedule); err != nil {
+			core.LogErrorF("Job %d has an invalid schedule, removing it: %s", job.Id, err)
+			database.DeleteScheduledJob(job.Id)
+			return
+		}
+	}
+
+	run, next := planRun(job, schedule, now)
+	if !run {
+		core.LogInfoF("Skipping missed %s job %d", job.Type, job.Id)
+		if next.IsZero() {
+			database.DeleteScheduledJob(job.Id)
+		} else {
+			database.RescheduleJob(job.Id, next, nil, "")
+		}
+		return
+	}
+
+	// Recurring jobs are moved to their next run first, so a crash while running doesn't repeat them
+	if !next.IsZero() {
+		database.RescheduleJob(job.Id, next, nil, "")
+	}
+
+	core.LogDebugF("Running %s job %d", job.Type, job.Id)
+	err := runSafely(ctx, jobType, job)
+	if err != nil {
+		core.LogErrorF("%s job %d failed: %s", job.Type, job.Id, err)
+	}
+
+	if next.IsZero() {
+		if err == nil || !retryJob(job, err, now) {
+			database.DeleteScheduledJob(job.Id)
+		}
+		return
+	}
+	var errorText string
+	if err != nil {
+		errorText = err.Error()
+	}
+	database.RescheduleJob(job.Id, next, &now, errorText)
+}
+
+// retryJob schedules the next attempt of a failed one-shot job. Returns false if it has failed too often.
+func retryJob(job *database.ScheduledJob, err error, now time.Time) bool {
+	attempts := job.Attempts + 1
+	if attempts >= maxJobAttempts {
+		core.LogErrorF("Giving up on %s job %d after %d attempts", job.Type, job.Id, attempts)
+		return false
+	}
+	delay := retryDelay(attempts)
+	core.LogInfoF("Retrying %s job %d in %s", job.Type, job.Id, delay)
+	return database.RetryJob(job.Id, now.Add(delay), now, err.Error())
+}
+
+// retryDelay returns how long to wait before the next attempt of a job that failed the given number of times
+func retryDelay(attempts int) time.Duration {
+	delay := jobRetryDelay
+	for i := 1; i < attempts && delay < jobRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, jobRetryMaxDelay)
+}
+
+// runSafely runs a job, turning a panic into an error
+func runSafely(ctx context.Context, jobType JobType, job *database.ScheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprint("panic: ", r))
+		}
+	}()
+	return jobType.Run(ctx, job)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"GoBot/core"
+	"GoBot/core/database"
+)
+
+// setupTestDB opens an in-memory database with all tables, closed when the test ends
+func setupTestDB(t *testing.T) {
+	core.Settings.SetTestDatabase(":memory:")
+	database.InitalizeDatabase()
+	t.Cleanup(database.Close)
+}
+
+func TestRunJob_RetriesFailedReminder(t *testing.T) {
+	setupTestDB(t)
+	SetDiscordSession(nil)
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	id, err := CreateReminder(Reminder{UserId: "1", Text: "sell tritium"}, ReminderTime{At: now.Add(time.Minute)}, "", now)
+	if err != nil {
+		t.Fatalf("Failed to create reminder: %s", err)
+	}
+
+	due := now.Add(time.Minute)
+	runJob(context.Background(), database.FetchScheduledJob(id), due)
+	job := database.FetchScheduledJob(id)
+	if job == nil {
+		t.Fatal("Expected the reminder to survive a run without a Discord session")
+	}
+	if job.Attempts != 1 || job.LastError == nil || job.RunAt != due.Add(jobRetryDelay).Unix() {
+		t.Errorf("Expected a retry in %s with the error recorded, got %+v", jobRetryDelay, job)
+	}
+
+	job.Attempts = maxJobAttempts - 1
+	runJob(context.Background(), job, due.Add(time.Hour))
+	if database.FetchScheduledJob(id) != nil {
+		t.Error("Expected the reminder to be dropped after its last attempt")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{9, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
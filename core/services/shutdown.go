@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"GoBot/core"
+)
+
+// work tracks the EDDN messages, carrier updates, proximity alerts and scheduled jobs being processed
+var work core.WorkGroup
+
+// Shutdown stops the EDDN listener, the scheduler and new background work, and waits for the work in flight
+// until ctx is done
+func Shutdown(ctx context.Context) error {
+	stopErr := errors.Join(StopEDDNListener(ctx), StopScheduler(ctx))
+	if err := work.Close(ctx); err != nil {
+		return err
+	}
+	return stopErr
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"GoBot/core"
+)
+
+// Templates are custom command texts with placeholders in braces, such as "Hello {user}!".
+// Braces are written as {{ and }} to use them literally.
+//
+//	{user}                     name of the user running the command
+//	{mention}                  mention of the user running the command
+//	{channel}                  mention of the channel the command was run in
+//	{args}                     everything after the command
+//	{arg1}, {arg2}, ...        a single word after the command, empty if not given
+//	{random:a|b|c}             one of the choices, picked at random
+//	{time}                     the current time, shown in the reader's time zone
+//	{carrier:W7H-6DZ.field}    live carrier data, see carrierFields
+type Template struct {
+	parts []templatePart
+}
+
+// TemplateContext is what the placeholders of a template are filled in with
+type TemplateContext struct {
+	User      string // Display name of the user
+	UserId    string
+	ChannelId string
+	Args      []string
+	Now       time.Time
+}
+
+// templatePart is literal text, or a placeholder when the name is set
+type templatePart struct {
+	text  string
+	name  string
+	param string
+}
+
+// carrierFields are the carrier values available to templates
+var carrierFields = map[string]func(c *CarrierInfo, now time.Time) string{
+	"name":     func(c *CarrierInfo, _ time.Time) string { return c.Name },
+	"location": func(c *CarrierInfo, _ time.Time) string { return c.CurrentSystem },
+	"destination": func(c *CarrierInfo, now time.Time) string {
+		if c.PendingJumpDest != nil && c.PendingJumpTime != nil && *c.PendingJumpTime > now.Unix() {
+			return *c.PendingJumpDest
+		}
+		return stringOrNone(c.Destination)
+	},
+	"departure": func(c *CarrierInfo, now time.Time) string {
+		if c.PendingJumpTime != nil && *c.PendingJumpTime > now.Unix() {
+			return fmt.Sprintf("<t:%d:f>", *c.PendingJumpTime)
+		}
+		if c.JumpTime != nil {
+			return fmt.Sprintf("<t:%d:f>", *c.JumpTime)
+		}
+		return "none"
+	},
+	"status": func(c *CarrierInfo, _ time.Time) string { return stringOrNone(c.Status) },
+}
+
+// ParseTemplate parses a custom command text, returning an error describing the first invalid placeholder
+func ParseTemplate(text string) (*Template, error) {
+	t := &Template{}
+	var literal strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "{{"), strings.HasPrefix(text[i:], "}}"):
+			literal.WriteByte(text[i])
+			i++
+		case text[i] == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed { at %q, use {{ for a literal brace", abbreviate(text[i:]))
+			}
+			part, err := parsePlaceholder(text[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			if literal.Len() > 0 {
+				t.parts = append(t.parts, templatePart{text: literal.String()})
+				literal.Reset()
+			}
+			t.parts = append(t.parts, part)
+			i += end
+		default:
+			literal.WriteByte(text[i])
+		}
+	}
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, templatePart{text: literal.String()})
+	}
+	return t, nil
+}
+
+func parsePlaceholder(placeholder string) (templatePart, error) {
+	name, param, hasParam := strings.Cut(placeholder, ":")
+	part := templatePart{name: strings.ToLower(strings.TrimSpace(name)), param: strings.TrimSpace(param)}
+	switch {
+	case part.name == "user", part.name == "mention", part.name == "channel", part.name == "args", part.name == "time":
+		if hasParam {
+			return part, fmt.Errorf("{%s} doesn't take a parameter", part.name)
+		}
+	case strings.HasPrefix(part.name, "arg"):
+		if n, err := strconv.Atoi(part.name[3:]); err != nil || n < 1 || hasParam {
+			return part, fmt.Errorf("invalid placeholder {%s}, arguments are numbered from {arg1}", placeholder)
+		}
+	case part.name == "random":
+		if part.param == "" {
+			return part, fmt.Errorf("{random} needs choices, e.g. {random:heads|tails}")
+		}
+	case part.name == "carrier":
+		stationId, field, _ := cutLast(part.param, ".")
+		if core.Settings.GetCarrierByStationId(strings.ToUpper(stationId)) == nil {
+			return part, fmt.Errorf("unknown carrier %q in {%s}, valid carriers: %s", stationId, placeholder,
+				strings.Join(GetCarrierStationIds(), ", "))
+		}
+		if carrierFields[strings.ToLower(field)] == nil {
+			return part, fmt.Errorf("unknown carrier field %q in {%s}, valid fields: %s", field, placeholder,
+				strings.Join(carrierFieldNames(), ", "))
+		}
+	default:
+		return part, fmt.Errorf("unknown placeholder {%s}", placeholder)
+	}
+	return part, nil
+}
+
+// Render fills in the placeholders of the template
+func (t *Template) Render(ctx TemplateContext) string {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		switch part.name {
+		case "":
+			sb.WriteString(part.text)
+		case "user":
+			sb.WriteString(ctx.User)
+		case "mention":
+			sb.WriteString("<@" + ctx.UserId + ">")
+		case "channel":
+			sb.WriteString("<#" + ctx.ChannelId + ">")
+		case "args":
+			sb.WriteString(strings.Join(ctx.Args, " "))
+		case "time":
+			sb.WriteString(fmt.Sprintf("<t:%d:f>", ctx.Now.Unix()))
+		case "random":
+			choices := strings.Split(part.param, "|")
+			sb.WriteString(strings.TrimSpace(choices[rand.Intn(len(choices))]))
+		case "carrier":
+			sb.WriteString(renderCarrierField(part.param, ctx.Now))
+		default: // argN, validated when parsed
+			if n, _ := strconv.Atoi(part.name[3:]); n <= len(ctx.Args) {
+				sb.WriteString(ctx.Args[n-1])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// renderCarrierField looks up a "STATION-ID.field" value, which may have been removed from the config since
+func renderCarrierField(param string, now time.Time) string {
+	stationId, field, _ := cutLast(param, ".")
+	info, err := GetCarrierInfo(strings.ToUpper(stationId))
+	if err != nil {
+		core.LogWarnF("Template refers to a carrier that isn't configured: %s", err)
+		return "unknown"
+	}
+	return carrierFields[strings.ToLower(field)](info, now)
+}
+
+func carrierFieldNames() []string {
+	names := make([]string, 0, len(carrierFields))
+	for name := range carrierFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func stringOrNone(s *string) string {
+	if s == nil || *s == "" {
+		return "none"
+	}
+	return *s
+}
+
+// abbreviate shortens text quoted in an error
+func abbreviate(text string) string {
+	if len(text) > 20 {
+		return text[:20] + "..."
+	}
+	return text
+}
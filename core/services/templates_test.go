@@ -0,0 +1,56 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	setupTestCarriers()
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello {user", "unclosed {"},
+		{"{foo}", "unknown placeholder {foo}"},
+		{"{arg0}", "numbered from {arg1}"},
+		{"{argx}", "numbered from {arg1}"},
+		{"{user:x}", "doesn't take a parameter"},
+		{"{random:}", "needs choices"},
+		{"{carrier:XXX-999.location}", `unknown carrier "XXX-999"`},
+		{"{carrier:ABC-123.speed}", `unknown carrier field "speed"`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseTemplate(tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseTemplate(%q) = %v, want an error containing %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	setupTestCarriers()
+	ctx := TemplateContext{User: "cmdr", UserId: "1", ChannelId: "10", Args: []string{"one", "two"}, Now: time.Unix(1700000000, 0)}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"No placeholders", "No placeholders"},
+		{"Hello {user} ({mention}) in {channel}", "Hello cmdr (<@1>) in <#10>"},
+		{"{args}|{arg2}|{arg3}|{ARG1}", "one two|two||one"},
+		{"It's {time}", "It's <t:1700000000:f>"},
+		{"{{user}} and }} alone }", "{user} and } alone }"},
+		{"{random:same|same}", "same"},
+		{"{carrier:abc-123.name} is at {carrier:ABC-123.location}, leaving {carrier:ABC-123.departure}", "DSEV Odysseus is at Unknown, leaving none"},
+	}
+	for _, tt := range tests {
+		template, err := ParseTemplate(tt.text)
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) failed: %s", tt.text, err)
+			continue
+		}
+		if got := template.Render(ctx); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
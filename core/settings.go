@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/jcelliott/lumber"
 )
@@ -21,7 +22,8 @@ type jsonData struct {
 	CommandPrefix          string
 	Database               string
 	ResourceDirectory      string
-	OwnerIds               []string
+	OwnerIds               []string        // Discord user IDs of bot owners (all permissions, can grant roles)
+	AdminChannelIds        []string        // Channel IDs where everyone has admin permissions
 	CustomCommandCooldown  int             // Cooldown in seconds between same custom command uses (0 = no cooldown)
 	BotChannels            []string        // Channel IDs for bot-spam (cooldown exempt, carriers reply in channel)
 	CarrierOwnerIds        []string        // Discord user IDs who can manage carriers
@@ -33,8 +35,44 @@ type jsonData struct {
 	DisableFlightLogs     bool     // When true, don't post carrier updates to Discord
 	SlashCommandAllowlist []string // When non-empty, only register these slash commands
 	CarrierValidation     []string // Validation modes: "range" (distance check), "time" (cooldown check). Empty = no validation
+	SuggestionMode        string   // How to respond to unknown commands: "suggest", "silent" or "botchannels" (default)
+	RateLimits            RateLimitConfig // Command rate limits, owners and bot channels are exempt
+	ReplyEditWindow       int      // Seconds during which editing a command updates the reply (default 300)
+	UsageRetentionDays    int      // Days to keep command usage records (default 90)
+	HandlerTimeout        int      // Seconds before the context of a command handler is cancelled (default 30)
+	ShutdownTimeout       int      // Seconds to wait for commands in flight when shutting down (default 10)
+	Locale                string   // Language of replies, a Discord locale such as "en-US" or "de" (default en-US)
 }
 
+// RateLimit allows Commands commands every Seconds seconds, with bursts of up to Commands at once.
+// A zero value disables the limit.
+type RateLimit struct {
+	Commands int     `json:"commands"`
+	Seconds  float64 `json:"seconds"`
+}
+
+// Enabled returns true if the limit is configured
+func (r RateLimit) Enabled() bool {
+	return r.Commands > 0 && r.Seconds > 0
+}
+
+// RateLimitConfig holds the rate limits applied by the dispatcher
+type RateLimitConfig struct {
+	User    RateLimit            `json:"user"`    // Commands by a single user
+	Channel RateLimit            `json:"channel"` // Commands in a single channel
+	Groups  map[string]RateLimit `json:"groups"`  // Commands by a single user in a command group, by group name
+}
+
+// Suggestion modes for unknown commands
+const (
+	SuggestAlways      = "suggest"     // Suggest similar commands in every channel
+	SuggestSilent      = "silent"      // Never suggest
+	SuggestBotChannels = "botchannels" // Only suggest in bot channels and DMs
+)
+
+// legacyAdminChannelId is the admin channel from before adminChannelIds was configurable
+const legacyAdminChannelId = "546085681219239936"
+
 type SettingsStorage struct {
 	data jsonData
 }
@@ -69,6 +107,12 @@ func LoadSettings(settingsfile string) {
 		SetLogLevel(lumber.INFO)
 	}
 
+	// The admin channel used to be hard-coded, keep it for configs from before it could be set
+	if Settings.data.AdminChannelIds == nil {
+		Settings.data.AdminChannelIds = []string{legacyAdminChannelId}
+		LogWarnF("adminChannelIds isn't configured, everyone in channel %s has admin permissions. Set it to [] for no admin channel.",
+			legacyAdminChannelId)
+	}
 	if Settings.data.DisableFlightLogs {
 		LogInfo("Flight logs disabled (disableFlightLogs=true)")
 	}
@@ -121,6 +165,26 @@ func (s *SettingsStorage) IsBotChannel(channelID string) bool {
 	return false
 }
 
+// IsOwner checks if a user ID is a bot owner
+func (s *SettingsStorage) IsOwner(userID string) bool {
+	for _, id := range s.data.OwnerIds {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdminChannel checks if a channel ID is an admin channel
+func (s *SettingsStorage) IsAdminChannel(channelID string) bool {
+	for _, id := range s.data.AdminChannelIds {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
+
 // CarrierOwnerIds returns the list of carrier commander Discord user IDs
 func (s *SettingsStorage) CarrierOwnerIds() []string {
 	return s.data.CarrierOwnerIds
@@ -199,3 +263,73 @@ func (s *SettingsStorage) CarrierValidationEnabled(mode string) bool {
 	}
 	return false
 }
+
+// SuggestionMode returns how to respond to unknown commands (default "botchannels")
+func (s *SettingsStorage) SuggestionMode() string {
+	switch s.data.SuggestionMode {
+	case SuggestAlways, SuggestSilent:
+		return s.data.SuggestionMode
+	default:
+		return SuggestBotChannels
+	}
+}
+
+// RateLimits returns the command rate limits
+func (s *SettingsStorage) RateLimits() RateLimitConfig {
+	return s.data.RateLimits
+}
+
+// SetTestDatabase sets the database path for testing purposes, such as ":memory:"
+func (s *SettingsStorage) SetTestDatabase(path string) {
+	s.data.Database = path
+}
+
+// SetTestCustomCommandCooldown sets the custom command cooldown for testing purposes
+func (s *SettingsStorage) SetTestCustomCommandCooldown(seconds int) {
+	s.data.CustomCommandCooldown = seconds
+}
+
+// SetTestRateLimits sets the rate limits for testing purposes
+func (s *SettingsStorage) SetTestRateLimits(limits RateLimitConfig) {
+	s.data.RateLimits = limits
+}
+
+// ReplyEditWindow returns how long after a command editing it updates the reply (default 5 minutes)
+func (s *SettingsStorage) ReplyEditWindow() time.Duration {
+	if s.data.ReplyEditWindow <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(s.data.ReplyEditWindow) * time.Second
+}
+
+// UsageRetentionDays returns how many days command usage records are kept (default 90)
+func (s *SettingsStorage) UsageRetentionDays() int {
+	if s.data.UsageRetentionDays <= 0 {
+		return 90
+	}
+	return s.data.UsageRetentionDays
+}
+
+// HandlerTimeout returns how long a command handler may run before its context is cancelled (default 30 seconds)
+func (s *SettingsStorage) HandlerTimeout() time.Duration {
+	if s.data.HandlerTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(s.data.HandlerTimeout) * time.Second
+}
+
+// ShutdownTimeout returns how long to wait for work in flight when shutting down (default 10 seconds)
+func (s *SettingsStorage) ShutdownTimeout() time.Duration {
+	if s.data.ShutdownTimeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.data.ShutdownTimeout) * time.Second
+}
+
+// Locale returns the language of replies in servers that haven't chosen one (default "en-US")
+func (s *SettingsStorage) Locale() string {
+	if s.data.Locale == "" {
+		return "en-US"
+	}
+	return s.data.Locale
+}
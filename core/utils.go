@@ -1,6 +1,10 @@
 package core
 
-import "net/url"
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
 
 type URLParams struct {
 	Key, Val string
@@ -19,3 +23,12 @@ func MakeURL(rawURL string, params []URLParams) (u *url.URL, err error) {
 	u.RawQuery = q.Encode()
 	return
 }
+
+// HttpGet works like http.Get, but the request is cancelled when ctx is done
+func HttpGet(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"GoBot/core/dispatch/handlers"
+	"GoBot/core/services"
+)
+
+// exportCommandsTo writes all custom commands and categories as JSON to a file, or stdout for "-"
+func exportCommandsTo(path string, out io.Writer) error {
+	export := services.ExportCustomCommands()
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Exported %d commands and %d categories to %s\n", len(export.Commands), len(export.Categories), path)
+	return nil
+}
+
+// importCommandsFrom prints what importing a file would change, and makes the changes if apply is set
+func importCommandsFrom(path, conflicts string, apply bool, out io.Writer) error {
+	if !slices.Contains(services.ImportPolicies, conflicts) {
+		return fmt.Errorf("unknown conflict policy %q, use one of %v", conflicts, services.ImportPolicies)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	export, err := services.ParseCommandExport(data)
+	if err != nil {
+		return err
+	}
+	plan := services.PlanImport(export, services.ImportPolicy(conflicts), handlers.BuiltinConflict)
+	fmt.Fprintln(out, services.FormatImportPlan(plan))
+	if !apply {
+		fmt.Fprintln(out, "Dry run, nothing was changed. Add -import-apply to import.")
+		return nil
+	}
+	applied, err := plan.Apply("", "import")
+	if err != nil {
+		return fmt.Errorf("import failed, nothing was changed: %w", err)
+	}
+	fmt.Fprintf(out, "Imported %d commands.\n", applied)
+	return nil
+}
@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
 
 	"GoBot/core"
 	"GoBot/core/database"
 	_ "GoBot/core/database" // Initialize database
+	"GoBot/core/discord"
 	"GoBot/core/dispatch"
-	"GoBot/core/dispatch/handlers"
 	_ "GoBot/core/dispatch/handlers" // Load the handlers to let them self-register
+	"GoBot/core/i18n"
 	"GoBot/core/services"
 
 	"github.com/bwmarrin/discordgo"
@@ -19,85 +23,162 @@ import (
 
 // Variables used for command line parameters
 var (
-	settingsFile string
+	settingsFile   string
+	consoleMode    bool
+	consoleUser    string
+	consoleChannel string
+	consoleGuild   string
+
+	exportCommandsFile string
+	importCommandsFile string
+	importConflicts    string
+	importApply        bool
 )
 
 func init() {
 
 	flag.StringVar(&settingsFile, "c", "config-dev.json", "Configuration path")
+	flag.BoolVar(&consoleMode, "console", false, "Run commands from stdin instead of connecting to Discord")
+	flag.StringVar(&consoleUser, "console-user", "console-user", "User ID of the sender in console mode")
+	flag.StringVar(&consoleChannel, "console-channel", "console", "Channel ID of the messages in console mode")
+	flag.StringVar(&consoleGuild, "console-guild", "", "Guild ID in console mode. Without one, messages are sent as DMs")
+	flag.StringVar(&exportCommandsFile, "export-commands", "", "Export the custom commands as JSON to a file, or - for stdout, and exit")
+	flag.StringVar(&importCommandsFile, "import-commands", "", "Show what importing custom commands from a JSON file would change, and exit")
+	flag.StringVar(&importConflicts, "import-conflicts", "skip", "What to do with existing commands that differ on import: skip, overwrite or rename")
+	flag.BoolVar(&importApply, "import-apply", false, "Make the changes of -import-commands instead of only showing them")
 	flag.Parse()
 }
 
 func main() {
 	core.LoadSettings(settingsFile)
+	// Translations added to the resource directory, without rebuilding the bot
+	if err := i18n.LoadDirectory(filepath.Join(core.Settings.ResourceDirectory(), "locales")); err != nil {
+		core.LogErrorF("Failed to load the message catalogs: %s", err)
+	}
 	database.InitalizeDatabase()
 	defer database.Close()
 	dispatch.SettingsLoaded()
 
-	// Start EDDN listener for carrier location updates
-	services.StartEDDNListener()
+	// Move custom commands between bots without connecting to Discord
+	if exportCommandsFile != "" || importCommandsFile != "" {
+		var err error
+		if exportCommandsFile != "" {
+			err = exportCommandsTo(exportCommandsFile, os.Stdout)
+		} else {
+			err = importCommandsFrom(importCommandsFile, importConflicts, importApply, os.Stdout)
+		}
+		if err != nil {
+			core.LogErrorF("Failed to transfer the custom commands: %s", err)
+			// os.Exit skips the deferred close
+			database.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Root context of all work. It's cancelled when shutdown stops waiting, which cancels what is still running.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if consoleMode {
+		runConsole(ctx, os.Stdin, os.Stdout)
+		shutdown(cancel)
+		return
+	}
 
 	// Create a new Discord session using the provided bot token.
+	// Errors from here on return instead of calling LogFatal, so the database is closed.
 	dg, err := discordgo.New("Bot " + core.Settings.AuthToken())
 	if err != nil {
-		core.LogFatal("error creating Discord session,", err)
+		core.LogError("error creating Discord session,", err)
+		shutdown(cancel)
 		return
 	}
 
+	// Set Discord session for services (flight log posting), before starting the ones that post
+	services.SetDiscordSession(discord.NewLive(dg))
+
+	// Start EDDN listener for carrier location updates
+	services.StartEDDNListener(ctx)
+
 	// Register handlers
-	dg.AddHandler(messageCreate)
-	dg.AddHandler(messageUpdate)
-	dg.AddHandler(interactionCreate)
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) { messageCreate(ctx, s, m) })
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) { messageUpdate(ctx, s, m) })
+	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) { interactionCreate(ctx, s, i) })
 
 	// Open a websocket connection to Discord and begin listening.
 	err = dg.Open()
 	if err != nil {
-		core.LogFatal("error opening connection,", err)
+		core.LogError("error opening connection,", err)
+		shutdown(cancel)
 		return
 	}
 
+	// Closed after shutdown, so handlers in flight can still reply
 	defer dg.Close()
 
-	// Set Discord session for services (flight log posting)
-	services.SetDiscordSession(dg)
+	// Run scheduled jobs, such as reminders and housekeeping, once connected so due reminders can be sent
+	services.StartScheduler(ctx)
 
 	// Process carrier update channel messages on startup
-	services.ProcessCarrierUpdateChannelOnStartup(dg)
+	services.ProcessCarrierUpdateChannelOnStartup(ctx, discord.NewLive(dg))
 
 	// Register slash commands after connection is open
-	handlers.RegisterAllSlashCommands(dg)
+	dispatch.RegisterSlashCommands(dg)
 
 	// Wait here until CTRL-C or other term signal is received.
 	core.LogInfoF("Bot is now running.  Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
+	core.LogInfo("Shutting down...")
+	shutdown(cancel)
+}
+
+// shutdown stops taking new commands and EDDN messages, and waits for the ones in flight until the shutdown
+// timeout. Work still running after that is cancelled.
+func shutdown(cancel context.CancelFunc) {
+	deadline, done := context.WithTimeout(context.Background(), core.Settings.ShutdownTimeout())
+	defer done()
+
+	stops := map[string]func(context.Context) error{
+		"commands": dispatch.Shutdown,
+		"services": services.Shutdown,
+	}
+	var wg sync.WaitGroup
+	for name, stop := range stops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stop(deadline); err != nil {
+				core.LogWarnF("Gave up waiting for %s to finish: %s", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
 }
 
 // This function will be called (due to AddHandler above) every time a new
 // message is created on any channel that the autenticated bot has access to.
-func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	go dispatch.Dispatch(s, m.Message)
+func messageCreate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) {
+	go dispatch.Dispatch(ctx, discord.NewLive(s), m.Message)
 
 	// Process carrier update channel messages (new messages and edits)
 	if m.Author != nil && m.Content != "" {
-		go services.ProcessCarrierUpdateMessage(m.Author.ID, m.ChannelID, m.Content)
+		go services.ProcessCarrierUpdateMessage(ctx, discord.NewLive(s), m.Message)
 	}
 }
 
-func messageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
-	go dispatch.Dispatch(s, m.Message)
+func messageUpdate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageUpdate) {
+	go dispatch.DispatchEdit(ctx, discord.NewLive(s), m.Message)
 
 	// Process carrier update channel messages
 	if m.Author != nil && m.Content != "" {
-		go services.ProcessCarrierUpdateMessage(m.Author.ID, m.ChannelID, m.Content)
+		go services.ProcessCarrierUpdateMessage(ctx, discord.NewLive(s), m.Message)
 	}
 }
 
-func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Try each handler until one handles the interaction
-	if handlers.HandleEliteDangerousSlashCommand(s, i) {
-		return
-	}
-	handlers.HandleCarrierSlashCommand(s, i)
+func interactionCreate(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	go dispatch.HandleInteraction(ctx, discord.NewLive(s), i)
 }